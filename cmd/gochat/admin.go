@@ -0,0 +1,754 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/slackimport"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var adminCommands = []command{
+	{"messages", "print the latest messages of a room, one JSON object per line", adminMessages},
+	{"send", "send a message to a room", adminSend},
+	{"search", "search the messages of the rooms a user's streams joined, one JSON object per line", adminSearch},
+	{"room-settings", "print a room's settings, or change slow mode, encryption, retention and legal hold", adminRoomSettings},
+	{"retention", "print what retention would remove from each room, one JSON object per line", adminRetention},
+	{"flagged", "print the messages moderation flagged for review, one JSON object per line", adminFlagged},
+	{"restrictions", "print the users spam detection restricted, one JSON object per line", adminRestrictions},
+	{"lift", "lift a user's mute or shadow restriction", adminLift},
+	{"signing-keys", "print a user's message signing keys, one JSON object per line", adminSigningKeys},
+	{"reset-keys", "revoke every signing key of a user who lost theirs", adminResetKeys},
+	{"export-user", "print everything stored about a user, one JSON object per line", adminExportUser},
+	{"erase-user", "anonymize or delete a user's messages and forget their keys", adminEraseUser},
+	{"export", "print a room's whole history, one ChatMessage in JSON per line", adminExport},
+	{"import", "store history from JSON lines of ChatMessage, as written by export", adminImport},
+	{"import-slack", "import the channels of a Slack export zip as rooms", adminImportSlack},
+	{"audit", "print the audit log of every node, one JSON object per line, and check its hash chains", adminAudit},
+}
+
+// runAdmin dispatches "gochat admin <command>". Admin commands talk to a
+// running server over the same connection settings as the gateways.
+func runAdmin(args []string) int {
+	if len(args) > 0 {
+		for _, c := range adminCommands {
+			if c.name == args[0] {
+				return c.run(args[1:])
+			}
+		}
+		fmt.Fprintf(os.Stderr, "gochat admin: unknown command %q\n\n", args[0])
+	}
+	fmt.Fprintln(os.Stderr, "Usage: gochat admin <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range adminCommands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", c.name, c.summary)
+	}
+	return 2
+}
+
+// adminLoader returns a loader for an admin command; callers add their own
+// flags to it before loading.
+func adminLoader(name string) *config.Loader {
+	return config.NewLoader("gochat admin "+name, config.SectionTLS, config.SectionBackend)
+}
+
+// adminContext bounds an admin call. The server records the name in the
+// client certificate (tls.cert_file) as the actor.
+func adminContext(cfg *config.Config) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), cfg.Backend.RequestTimeout)
+}
+
+// adminStreamContext is for a streaming admin call, which runs for as long
+// as the stream has data rather than the request timeout.
+func adminStreamContext() (context.Context, context.CancelFunc) {
+	return context.WithCancel(context.Background())
+}
+
+// adminClient loads the config and connects to the server.
+func adminClient(loader *config.Loader, args []string) (chatv1.ChatServiceClient, *grpc.ClientConn, *config.Config) {
+	cfg, err := loader.Load(args)
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	conn := dialBackend(cfg)
+	return chatv1.NewChatServiceClient(conn), conn, cfg
+}
+
+func adminMessages(args []string) int {
+	loader := adminLoader("messages")
+	roomID := loader.Flags().String("room", "", "room to read (required)")
+	limit := loader.Flags().Int("limit", 20, "how many of the latest messages to print")
+	before := loader.Flags().Uint64("before", 0, "print the messages before this sequence instead of the latest")
+	client, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	if *roomID == "" {
+		log.Fatal("-room is required")
+	}
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.Getmessages(ctx, &chatv1.GetMessageRequest{RoomId: *roomID, Limit: int32(*limit), BeforeSequence: *before})
+	if err != nil {
+		log.Printf("Getmessages: %v", err)
+		return 1
+	}
+	for _, msg := range resp.Message {
+		if err := printJSON(msg); err != nil {
+			log.Printf("encode message %s: %v", msg.Id, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func adminSend(args []string) int {
+	loader := adminLoader("send")
+	roomID := loader.Flags().String("room", "", "room to send to (required)")
+	senderID := loader.Flags().String("sender", "admin", "sender id of the message")
+	text := loader.Flags().String("text", "", "message text (required)")
+	client, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	if *roomID == "" || *text == "" {
+		log.Fatal("-room and -text are required")
+	}
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.SendMessage(ctx, &chatv1.SendMessageRequest{Message: &chatv1.ChatMessage{
+		RoomId:   *roomID,
+		SenderId: *senderID,
+		Text:     *text,
+	}})
+	if err != nil {
+		log.Printf("SendMessage: %v", err)
+		return 1
+	}
+	fmt.Printf("sent %s (sequence %d)\n", resp.Message.Id, resp.Message.Sequence)
+	return 0
+}
+
+func adminSearch(args []string) int {
+	loader := adminLoader("search")
+	userID := loader.Flags().String("user", "", "search as this user, in the rooms their streams joined (required)")
+	query := loader.Flags().String("q", "", "words every message must contain; end one with * to match words it starts")
+	rooms := loader.Flags().String("rooms", "", "comma-separated rooms to search (default: all of the user's)")
+	senders := loader.Flags().String("senders", "", "comma-separated senders to match (default: anyone)")
+	since := loader.Flags().String("since", "", "only messages at or after this time: RFC 3339, or a duration ago like 24h")
+	until := loader.Flags().String("until", "", "only messages before this time: RFC 3339, or a duration ago")
+	limit := loader.Flags().Int("limit", 20, "how many messages to print")
+	pageToken := loader.Flags().String("page-token", "", "continue a previous search from the token it printed")
+	client, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	if *userID == "" {
+		log.Fatal("-user is required")
+	}
+	req := &chatv1.SearchMessagesRequest{Query: *query, Limit: int32(*limit), PageToken: *pageToken}
+	if *rooms != "" {
+		req.RoomIds = strings.Split(*rooms, ",")
+	}
+	if *senders != "" {
+		req.SenderIds = strings.Split(*senders, ",")
+	}
+	for _, t := range []struct {
+		flag, value string
+		dst         **timestamppb.Timestamp
+	}{{"since", *since, &req.Since}, {"until", *until, &req.Until}} {
+		if t.value == "" {
+			continue
+		}
+		at, err := parseTime(t.value)
+		if err != nil {
+			log.Fatalf("-%s: %v", t.flag, err)
+		}
+		*t.dst = timestamppb.New(at)
+	}
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.SearchMessages(auth.WithUser(ctx, *userID), req)
+	if err != nil {
+		log.Printf("SearchMessages: %v", err)
+		return 1
+	}
+	for _, msg := range resp.Messages {
+		if err := printJSON(msg); err != nil {
+			log.Printf("encode message %s: %v", msg.Id, err)
+			return 1
+		}
+	}
+	if resp.NextPageToken != "" {
+		log.Printf("more results: -page-token %s", resp.NextPageToken)
+	}
+	return 0
+}
+
+func adminRoomSettings(args []string) int {
+	loader := adminLoader("room-settings")
+	roomID := loader.Flags().String("room", "", "room to configure (required)")
+	slowMode := loader.Flags().String("slow-mode", "", "let each member post once per this interval, e.g. 30s; 0 turns slow mode off")
+	e2e := loader.Flags().String("e2e", "", "true makes the room end-to-end encrypted, false makes it plain text again")
+	maxAge := loader.Flags().String("max-age", "", "remove messages older than this, e.g. 720h; 0 keeps them whatever their age")
+	maxMessages := loader.Flags().String("max-messages", "", "keep at most this many messages; 0 keeps them all")
+	defaultRetention := loader.Flags().Bool("default-retention", false, "drop the room's own retention policy and use the server's")
+	legalHold := loader.Flags().String("legal-hold", "", "true keeps every message whatever the retention policy, false releases the hold")
+	_, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	if *roomID == "" {
+		log.Fatal("-room is required")
+	}
+	if *defaultRetention && (*maxAge != "" || *maxMessages != "") {
+		log.Fatal("-default-retention can't be combined with -max-age or -max-messages")
+	}
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	got, err := client.GetRoomSettings(ctx, &chatv1.GetRoomSettingsRequest{RoomId: *roomID})
+	if err != nil {
+		log.Printf("GetRoomSettings: %v", err)
+		return 1
+	}
+	settings := got.Settings
+	if *slowMode != "" || *e2e != "" || *maxAge != "" || *maxMessages != "" || *defaultRetention || *legalHold != "" {
+		if *slowMode != "" {
+			d, err := time.ParseDuration(*slowMode)
+			if err != nil {
+				log.Fatalf("-slow-mode: %v", err)
+			}
+			settings.SlowMode = durationpb.New(d)
+		}
+		if *e2e != "" {
+			on, err := strconv.ParseBool(*e2e)
+			if err != nil {
+				log.Fatalf("-e2e: %v", err)
+			}
+			settings.E2E = on
+		}
+		if *defaultRetention {
+			settings.Retention = nil
+		}
+		if *maxAge != "" || *maxMessages != "" {
+			// A room's own policy starts from nothing removed, not from the
+			// server's default.
+			if settings.Retention == nil {
+				settings.Retention = &chatv1.RetentionPolicy{}
+			}
+			if *maxAge != "" {
+				d, err := time.ParseDuration(*maxAge)
+				if err != nil {
+					log.Fatalf("-max-age: %v", err)
+				}
+				settings.Retention.MaxAge = durationpb.New(d)
+			}
+			if *maxMessages != "" {
+				n, err := strconv.ParseUint(*maxMessages, 10, 64)
+				if err != nil {
+					log.Fatalf("-max-messages: %v", err)
+				}
+				settings.Retention.MaxMessages = n
+			}
+		}
+		if *legalHold != "" {
+			on, err := strconv.ParseBool(*legalHold)
+			if err != nil {
+				log.Fatalf("-legal-hold: %v", err)
+			}
+			settings.LegalHold = on
+		}
+		updated, err := client.UpdateRoomSettings(ctx, &chatv1.UpdateRoomSettingsRequest{Settings: settings})
+		if err != nil {
+			log.Printf("UpdateRoomSettings: %v", err)
+			return 1
+		}
+		settings = updated.Settings
+	}
+	if err := printJSON(settings); err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func adminRetention(args []string) int {
+	loader := adminLoader("retention")
+	roomID := loader.Flags().String("room", "", "room to report on (default: every room)")
+	_, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.RetentionReport(ctx, &chatv1.RetentionReportRequest{RoomId: *roomID})
+	if err != nil {
+		log.Printf("RetentionReport: %v", err)
+		return 1
+	}
+	for _, r := range resp.Rooms {
+		if err := printJSON(r); err != nil {
+			log.Printf("encode report of %s: %v", r.RoomId, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func adminFlagged(args []string) int {
+	loader := adminLoader("flagged")
+	roomID := loader.Flags().String("room", "", "room to read (default: every room)")
+	limit := loader.Flags().Int("limit", 20, "how many of the latest flagged messages to print; 0 prints them all")
+	_, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.ListFlaggedMessages(ctx, &chatv1.ListFlaggedMessagesRequest{RoomId: *roomID, Limit: int32(*limit)})
+	if err != nil {
+		log.Printf("ListFlaggedMessages: %v", err)
+		return 1
+	}
+	for _, fm := range resp.Messages {
+		if err := printJSON(fm); err != nil {
+			log.Printf("encode flagged message %s: %v", fm.GetMessage().GetId(), err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func adminRestrictions(args []string) int {
+	loader := adminLoader("restrictions")
+	userID := loader.Flags().String("user", "", "user to read (default: every user)")
+	all := loader.Flags().Bool("all", false, "include expired and lifted restrictions")
+	_, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.ListUserRestrictions(ctx, &chatv1.ListUserRestrictionsRequest{UserId: *userID, IncludeInactive: *all})
+	if err != nil {
+		log.Printf("ListUserRestrictions: %v", err)
+		return 1
+	}
+	for _, r := range resp.Restrictions {
+		if err := printJSON(r); err != nil {
+			log.Printf("encode restriction %s: %v", r.Id, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func adminLift(args []string) int {
+	loader := adminLoader("lift")
+	userID := loader.Flags().String("user", "", "user to lift the restriction of (required)")
+	_, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	if *userID == "" {
+		log.Fatal("-user is required")
+	}
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.LiftUserRestriction(ctx, &chatv1.LiftUserRestrictionRequest{UserId: *userID})
+	if err != nil {
+		log.Printf("LiftUserRestriction: %v", err)
+		return 1
+	}
+	if resp.Restriction == nil {
+		fmt.Printf("%s is not restricted\n", *userID)
+		return 0
+	}
+	if err := printJSON(resp.Restriction); err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func adminSigningKeys(args []string) int {
+	loader := adminLoader("signing-keys")
+	userID := loader.Flags().String("user", "", "user to read (required)")
+	all := loader.Flags().Bool("all", false, "include revoked keys")
+	_, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	if *userID == "" {
+		log.Fatal("-user is required")
+	}
+	client := chatv1.NewKeyServiceClient(conn)
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.ListSigningKeys(ctx, &chatv1.ListSigningKeysRequest{UserId: *userID, IncludeRevoked: *all})
+	if err != nil {
+		log.Printf("ListSigningKeys: %v", err)
+		return 1
+	}
+	for _, k := range resp.Keys {
+		if err := printJSON(k); err != nil {
+			log.Printf("encode signing key %s: %v", k.KeyId, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func adminResetKeys(args []string) int {
+	loader := adminLoader("reset-keys")
+	userID := loader.Flags().String("user", "", "user to revoke the keys of (required)")
+	_, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	if *userID == "" {
+		log.Fatal("-user is required")
+	}
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.ResetSigningKeys(ctx, &chatv1.ResetSigningKeysRequest{UserId: *userID})
+	if err != nil {
+		log.Printf("ResetSigningKeys: %v", err)
+		return 1
+	}
+	if len(resp.Revoked) == 0 {
+		fmt.Printf("%s has no signing keys in force\n", *userID)
+		return 0
+	}
+	for _, k := range resp.Revoked {
+		if err := printJSON(k); err != nil {
+			log.Printf("encode signing key %s: %v", k.KeyId, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func adminExportUser(args []string) int {
+	loader := adminLoader("export-user")
+	userID := loader.Flags().String("user", "", "user to export (required)")
+	_, conn, _ := adminClient(loader, args)
+	defer conn.Close()
+	if *userID == "" {
+		log.Fatal("-user is required")
+	}
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminStreamContext()
+	defer cancel()
+	records, err := client.ExportUserData(ctx, &chatv1.ExportUserDataRequest{UserId: *userID})
+	if err != nil {
+		log.Printf("ExportUserData: %v", err)
+		return 1
+	}
+	for {
+		r, err := records.Recv()
+		if errors.Is(err, io.EOF) {
+			return 0
+		}
+		if err != nil {
+			log.Printf("ExportUserData: %v; the export is incomplete", err)
+			return 1
+		}
+		if err := printJSON(r); err != nil {
+			log.Print(err)
+			return 1
+		}
+	}
+}
+
+func adminEraseUser(args []string) int {
+	loader := adminLoader("erase-user")
+	userID := loader.Flags().String("user", "", "user to erase (required)")
+	mode := loader.Flags().String("mode", "", "anonymize keeps the text under a pseudonym, delete removes it too (required)")
+	alias := loader.Flags().String("alias", "", "pseudonym to use, to finish an erasure that failed part way")
+	_, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	if *userID == "" {
+		log.Fatal("-user is required")
+	}
+	req := &chatv1.EraseUserRequest{UserId: *userID, Alias: *alias}
+	switch *mode {
+	case "anonymize":
+		req.Mode = chatv1.EraseMode_ERASE_MODE_ANONYMIZE
+	case "delete":
+		req.Mode = chatv1.EraseMode_ERASE_MODE_DELETE
+	default:
+		log.Fatal("-mode must be anonymize or delete")
+	}
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.EraseUser(ctx, req)
+	if err != nil {
+		log.Printf("EraseUser: %v", err)
+		return 1
+	}
+	if err := printJSON(resp); err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func adminExport(args []string) int {
+	loader := adminLoader("export")
+	roomID := loader.Flags().String("room", "", "room to export (required)")
+	_, conn, _ := adminClient(loader, args)
+	defer conn.Close()
+	if *roomID == "" {
+		log.Fatal("-room is required")
+	}
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminStreamContext()
+	defer cancel()
+	msgs, err := client.ExportRoom(ctx, &chatv1.ExportRoomRequest{RoomId: *roomID})
+	if err != nil {
+		log.Printf("ExportRoom: %v", err)
+		return 1
+	}
+	for {
+		msg, err := msgs.Recv()
+		if errors.Is(err, io.EOF) {
+			return 0
+		}
+		if err != nil {
+			log.Printf("ExportRoom: %v; the export is incomplete", err)
+			return 1
+		}
+		if err := printJSON(msg); err != nil {
+			log.Printf("encode message %s: %v", msg.Id, err)
+			return 1
+		}
+	}
+}
+
+func adminImport(args []string) int {
+	loader := adminLoader("import")
+	roomID := loader.Flags().String("room", "", "room to import into (default: each message's room_id)")
+	file := loader.Flags().String("file", "-", "JSON lines to read; - reads standard input")
+	_, conn, _ := adminClient(loader, args)
+	defer conn.Close()
+
+	in := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	// Group by room, keeping the order of the file within each.
+	var rooms []string
+	history := make(map[string][]*chatv1.ChatMessage)
+	sc := bufio.NewScanner(in)
+	sc.Buffer(nil, 4<<20)
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		msg := &chatv1.ChatMessage{}
+		if err := protojson.Unmarshal(sc.Bytes(), msg); err != nil {
+			log.Fatalf("line %d: %v", n, err)
+		}
+		if *roomID != "" {
+			msg.RoomId = *roomID
+		}
+		if msg.RoomId == "" {
+			log.Fatalf("line %d: message has no room_id; pass -room", n)
+		}
+		if _, ok := history[msg.RoomId]; !ok {
+			rooms = append(rooms, msg.RoomId)
+		}
+		history[msg.RoomId] = append(history[msg.RoomId], msg)
+	}
+	if err := sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminStreamContext()
+	defer cancel()
+	for _, room := range rooms {
+		resp, err := importRoom(ctx, client, room, history[room])
+		if err != nil {
+			log.Printf("ImportRoom %s: %v", room, err)
+			return 1
+		}
+		fmt.Printf("imported %d messages into %s\n", resp.Imported, resp.RoomId)
+	}
+	return 0
+}
+
+func adminImportSlack(args []string) int {
+	loader := adminLoader("import-slack")
+	zipPath := loader.Flags().String("zip", "", "Slack export archive (required)")
+	channels := loader.Flags().String("channels", "", "comma-separated channel names to import (default: all)")
+	prefix := loader.Flags().String("room-prefix", "", "prefix for the room ids made from channel names")
+	userMap := loader.Flags().String("users", "", `file mapping Slack users to sender ids, "<slack id or handle> <sender id>" per line`)
+	_, conn, _ := adminClient(loader, args)
+	defer conn.Close()
+	if *zipPath == "" {
+		log.Fatal("-zip is required")
+	}
+	opts := slackimport.Options{RoomPrefix: *prefix}
+	if *userMap != "" {
+		f, err := os.Open(*userMap)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Users, err = slackimport.ReadUserMap(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *userMap, err)
+		}
+	}
+	archive, err := slackimport.Open(*zipPath, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer archive.Close()
+	var only []string
+	if *channels != "" {
+		only = strings.Split(*channels, ",")
+	}
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminStreamContext()
+	defer cancel()
+	for _, ch := range archive.Channels() {
+		if only != nil && !slices.Contains(only, ch.Name) {
+			continue
+		}
+		msgs, skipped, err := archive.Messages(ch)
+		if err != nil {
+			log.Printf("read channel %s: %v", ch.Name, err)
+			return 1
+		}
+		if len(msgs) == 0 {
+			fmt.Printf("%s: nothing to import\n", ch.Name)
+			continue
+		}
+		resp, err := importRoom(ctx, client, archive.RoomID(ch), msgs)
+		if err != nil {
+			log.Printf("ImportRoom %s: %v", archive.RoomID(ch), err)
+			return 1
+		}
+		fmt.Printf("%s: imported %d messages into %s, skipped %d channel events\n", ch.Name, resp.Imported, resp.RoomId, skipped)
+	}
+	return 0
+}
+
+// importBatch is how many messages go in each ImportRoom request.
+const importBatch = 500
+
+// importRoom streams a room's history to ImportRoom in batches.
+func importRoom(ctx context.Context, client chatv1.AdminServiceClient, roomID string, msgs []*chatv1.ChatMessage) (*chatv1.ImportRoomResponse, error) {
+	stream, err := client.ImportRoom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(msgs); i += importBatch {
+		req := &chatv1.ImportRoomRequest{Messages: msgs[i:min(i+importBatch, len(msgs))]}
+		if i == 0 {
+			req.RoomId = roomID
+		}
+		if err := stream.Send(req); err != nil {
+			// The server ended the stream; CloseAndRecv returns why.
+			break
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+func adminAudit(args []string) int {
+	loader := adminLoader("audit")
+	byActor := loader.Flags().String("by", "", "only actions of this actor")
+	roomID := loader.Flags().String("room", "", "only actions on this room")
+	since := loader.Flags().String("since", "", "only actions at or after this time: RFC 3339, or a duration ago like 24h")
+	until := loader.Flags().String("until", "", "only actions before this time: RFC 3339, or a duration ago")
+	limit := loader.Flags().Int("limit", 50, "how many of the latest actions to print; 0 prints them all")
+	_, conn, cfg := adminClient(loader, args)
+	defer conn.Close()
+	req := &chatv1.ListAuditEventsRequest{Actor: *byActor, RoomId: *roomID, Limit: int32(*limit)}
+	for _, t := range []struct {
+		flag, value string
+		dst         **timestamppb.Timestamp
+	}{{"since", *since, &req.Since}, {"until", *until, &req.Until}} {
+		if t.value == "" {
+			continue
+		}
+		at, err := parseTime(t.value)
+		if err != nil {
+			log.Fatalf("-%s: %v", t.flag, err)
+		}
+		*t.dst = timestamppb.New(at)
+	}
+	client := chatv1.NewAdminServiceClient(conn)
+
+	ctx, cancel := adminContext(cfg)
+	defer cancel()
+	resp, err := client.ListAuditEvents(ctx, req)
+	if err != nil {
+		log.Printf("ListAuditEvents: %v", err)
+		return 1
+	}
+	for _, e := range resp.Events {
+		if err := printJSON(e); err != nil {
+			log.Printf("encode audit event %s/%d: %v", e.NodeId, e.Sequence, err)
+			return 1
+		}
+	}
+	code := 0
+	for _, c := range resp.Chains {
+		if !c.Intact {
+			log.Printf("audit log of node %s is broken at entry %d of %d: %s", c.NodeId, c.BrokenAt, c.Events, c.Error)
+			code = 1
+		}
+	}
+	return code
+}
+
+// parseTime reads an RFC 3339 time, or a duration meaning that long ago.
+func parseTime(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// printJSON prints msg as one line of JSON with the proto field names.
+func printJSON(msg proto.Message) error {
+	b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	// protojson varies its whitespace; keep one message per line.
+	var line bytes.Buffer
+	json.Compact(&line, b)
+	fmt.Println(line.String())
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/qinyul/go-chat/internal/certs"
+)
+
+// runCerts generates a local CA and the server and client certificates
+// signed by it. An existing CA in the directory is reused, so running it
+// again renews the other certificates without touching what clients trust.
+func runCerts(args []string) int {
+	flags := flag.NewFlagSet("gochat certs", flag.ExitOnError)
+	dir := flags.String("dir", "certs", "directory to write the certificates to")
+	hosts := flags.String("hosts", "localhost,127.0.0.1", "comma separated names and IPs the server certificate is valid for")
+	clients := flags.String("clients", "gateway,node,admin", "comma separated client certificates to issue; each name is also its SAN")
+	validity := flags.Duration("validity", 365*24*time.Hour, "how long server and client certificates are valid")
+	caValidity := flags.Duration("ca-validity", 10*365*24*time.Hour, "how long a new CA is valid")
+	newCA := flags.Bool("new-ca", false, "replace the CA in -dir instead of reusing it")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatalf("unexpected arguments: %v", flags.Args())
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	path := func(name string) string { return filepath.Join(*dir, name) }
+
+	ca, err := certs.LoadAuthority(path("ca.crt"), path("ca.key"))
+	switch {
+	case err == nil && !*newCA:
+		fmt.Printf("reusing CA %s\n", path("ca.crt"))
+	case err == nil || *newCA || errors.Is(err, fs.ErrNotExist):
+		ca, err = certs.NewAuthority("go-chat local CA", *caValidity)
+		if err == nil {
+			err = certs.WriteFiles(ca.Cert, ca.Key, path("ca.crt"), path("ca.key"))
+		}
+		if err != nil {
+			log.Fatalf("create CA: %v", err)
+		}
+		fmt.Printf("wrote %s\n", path("ca.crt"))
+	default:
+		log.Fatalf("load CA: %v", err)
+	}
+
+	issue := func(name string, sans []string, usage certs.Usage) {
+		c, err := ca.Issue(name, sans, usage, *validity)
+		if err == nil {
+			err = certs.WriteFiles(c.Cert, c.Key, path(name+".crt"), path(name+".key"))
+		}
+		if err != nil {
+			log.Fatalf("issue %s: %v", name, err)
+		}
+		fmt.Printf("wrote %s (%s)\n", path(name+".crt"), strings.Join(sans, ", "))
+	}
+	issue("server", split(*hosts), certs.ServerUsage)
+	var names []string
+	for _, name := range split(*clients) {
+		issue(name, []string{name}, certs.ClientUsage)
+		names = append(names, name)
+	}
+
+	fmt.Println()
+	fmt.Println("Run with mutual TLS, for example:")
+	fmt.Printf("  gochat serve -tls-cert %s -tls-key %s -tls-ca %s -tls-client-ca %s -tls-allowed-clients %s -tls-peer-clients node -tls-client-cert %s -tls-client-key %s\n",
+		path("server.crt"), path("server.key"), path("ca.crt"), path("ca.crt"), strings.Join(names, ","), path("node.crt"), path("node.key"))
+	fmt.Printf("  gochat ws-gateway -tls-ca %s -tls-client-cert %s -tls-client-key %s\n",
+		path("ca.crt"), path("gateway.crt"), path("gateway.key"))
+	return 0
+}
+
+// split splits a comma separated list, dropping empty entries.
+func split(list string) []string {
+	var out []string
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+// Command gochat runs the chat server, its REST and WebSocket gateways, or
+// all of them in one process, and carries the admin tools.
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// version is set at build time with
+// -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"serve", "run the chat server", runServe},
+	{"rest-gateway", "run the REST gateway in front of a chat server", runRESTGateway},
+	{"ws-gateway", "run the WebSocket and long-poll gateway in front of a chat server", runWSGateway},
+	{"all-in-one", "run the server and both gateways in one process", runAllInOne},
+	{"admin", "administer a running chat server", runAdmin},
+	{"certs", "generate a local CA and server and client certificates", runCerts},
+	{"version", "print the version", runVersion},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	for _, c := range commands {
+		if c.name == name {
+			os.Exit(c.run(args))
+		}
+	}
+	switch name {
+	case "help", "-h", "-help", "--help":
+		usage()
+		return
+	}
+	fmt.Fprintf(os.Stderr, "gochat: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: gochat <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Run "gochat <command> -h" for the flags of a command.`)
+}
+
+// runVersion prints the build version, the commit it was built from and
+// the Go toolchain.
+func runVersion(args []string) int {
+	fmt.Printf("gochat %s\n", version)
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return 0
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision", "vcs.time", "vcs.modified":
+			fmt.Printf("  %s: %s\n", s.Key, s.Value)
+		}
+	}
+	fmt.Printf("  go: %s\n", info.GoVersion)
+	return 0
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"connectrpc.com/vanguard"
+	"connectrpc.com/vanguard/vanguardgrpc"
+	"github.com/go-chi/chi/v5"
+	"github.com/qinyul/go-chat/internal/certs"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/health"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/metrics"
+	"github.com/qinyul/go-chat/internal/restgateway"
+	"github.com/qinyul/go-chat/internal/server"
+	"github.com/qinyul/go-chat/internal/tracing"
+	"github.com/qinyul/go-chat/internal/wsgateway"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+)
+
+// backendCodec is the codec the gateways talk to the server with.
+var backendCodec = vanguardgrpc.NewCodec(vanguard.JSONCodec{})
+
+func init() {
+	encoding.RegisterCodec(backendCodec)
+}
+
+func runServe(args []string) int {
+	loader := config.NewLoader("gochat serve", config.SectionTLS, config.SectionServer, config.SectionTracing, config.SectionLog, config.SectionRateLimit, config.SectionModeration, config.SectionSpam)
+	cfg := load(loader, args)
+
+	srv, err := server.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return run(loader, cfg, &app{role: "serve", server: srv})
+}
+
+func runRESTGateway(args []string) int {
+	loader := config.NewLoader("gochat rest-gateway", config.SectionTLS, config.SectionBackend, config.SectionREST, config.SectionTracing, config.SectionLog, config.SectionRateLimit)
+	cfg := load(loader, args)
+
+	conn := dialBackend(cfg)
+	rest, err := restgateway.New(cfg, conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return run(loader, cfg, &app{role: "rest-gateway", conn: conn, rest: rest})
+}
+
+func runWSGateway(args []string) int {
+	loader := config.NewLoader("gochat ws-gateway", config.SectionTLS, config.SectionBackend, config.SectionWebSocket, config.SectionTracing, config.SectionLog, config.SectionRateLimit)
+	cfg := load(loader, args)
+
+	conn := dialBackend(cfg)
+	ws, err := wsgateway.New(cfg, conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return run(loader, cfg, &app{role: "ws-gateway", conn: conn, ws: ws})
+}
+
+// runAllInOne runs the server and both gateways. The gateways reach the
+// server through an in-process connection rather than over TLS.
+func runAllInOne(args []string) int {
+	loader := config.NewLoader("gochat all-in-one",
+		config.SectionTLS, config.SectionBackend, config.SectionServer, config.SectionREST, config.SectionWebSocket, config.SectionTracing, config.SectionLog, config.SectionRateLimit, config.SectionModeration, config.SectionSpam)
+	cfg := load(loader, args)
+
+	srv, err := server.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn, err := srv.DialInProcess(grpc.WithDefaultCallOptions(grpc.ForceCodec(backendCodec)))
+	if err != nil {
+		log.Fatalf("dial error: %v", err)
+	}
+	rest, err := restgateway.New(cfg, conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ws, err := wsgateway.New(cfg, conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return run(loader, cfg, &app{role: "all-in-one", server: srv, conn: conn, rest: rest, ws: ws})
+}
+
+// load parses args, handles -print-config and logs the effective config.
+func load(loader *config.Loader, args []string) *config.Config {
+	cfg, err := loader.Load(args)
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	if loader.PrintOnly() {
+		fmt.Print(loader.Format(cfg))
+		os.Exit(0)
+	}
+	logging.Setup(os.Stderr, cfg.Log)
+	slog.Info("effective config", "config", loader.Format(cfg))
+	return cfg
+}
+
+// dialBackend connects a gateway to the chat server over TLS, presenting
+// the client certificate when one is configured. The certificate files are
+// watched for the life of the process.
+func dialBackend(cfg *config.Config) *grpc.ClientConn {
+	w, err := certs.NewWatcher(certs.Files{Cert: cfg.TLS.ClientCertFile, Key: cfg.TLS.ClientKeyFile, CA: cfg.TLS.CAFile})
+	if err != nil {
+		log.Fatalf("failed to load TLS cert: %v", err)
+	}
+	go w.Run(context.Background(), cfg.TLS.ReloadInterval)
+	conn, err := grpc.NewClient(
+		cfg.Backend.Addr,
+		grpc.WithTransportCredentials(credentials.NewTLS(w.ClientTLS(cfg.TLS.ServerName))),
+		grpc.WithStatsHandler(tracing.ClientHandler()),
+		grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(logging.StreamClientInterceptor),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(backendCodec)),
+	)
+	if err != nil {
+		log.Fatalf("dial error: %v", err)
+	}
+	return conn
+}
+
+// app is what one command runs: a chat server, gateways, or both.
+type app struct {
+	role   string // names the spans' service: gochat-<role>
+	server *server.Server
+	conn   *grpc.ClientConn // the gateways' backend connection
+	rest   *restgateway.Server
+	ws     *wsgateway.Server
+}
+
+func (a *app) reload(cfg *config.Config) error {
+	logging.Apply(cfg.Log)
+	var errs []error
+	if a.server != nil {
+		errs = append(errs, a.server.Reload(cfg))
+	}
+	if a.rest != nil {
+		errs = append(errs, a.rest.Reload(cfg))
+	}
+	if a.ws != nil {
+		errs = append(errs, a.ws.Reload(cfg))
+	}
+	return errors.Join(errs...)
+}
+
+// run serves a until SIGINT or SIGTERM, reloading its config on SIGHUP,
+// then shuts it down and returns the exit status. Gateways configured on
+// the same address share one listener.
+func run(loader *config.Loader, cfg *config.Config, a *app) int {
+	flushTraces, err := tracing.Setup(context.Background(), cfg.Tracing, a.role)
+	if err != nil {
+		log.Fatalf("tracing: %v", err)
+	}
+
+	// In all-in-one the gateways report the server's readiness too.
+	checker := health.New()
+	if a.server != nil {
+		checker.Include(a.server.Health())
+	}
+	if a.conn != nil {
+		checker.Add("backend", health.Conn(a.conn))
+	}
+
+	routers := make(map[string]chi.Router)
+	var httpServers []*http.Server
+	router := func(addr string) chi.Router {
+		if r, ok := routers[addr]; ok {
+			return r
+		}
+		r := chi.NewRouter()
+		r.Use(logging.Middleware, metrics.Middleware, tracing.Middleware)
+		r.Method(http.MethodGet, "/metrics", metrics.Handler())
+		r.Get("/healthz", health.Live)
+		r.Get("/readyz", checker.Ready)
+		routers[addr] = r
+		httpServers = append(httpServers, &http.Server{Addr: addr, Handler: r})
+		return r
+	}
+	var gatewayTimeout time.Duration
+	if a.rest != nil {
+		a.rest.Mount(router(cfg.REST.Addr))
+		gatewayTimeout = cfg.REST.ShutdownTimeout
+	}
+	if a.ws != nil {
+		a.ws.Mount(router(cfg.WebSocket.Addr))
+		gatewayTimeout = max(gatewayTimeout, cfg.WebSocket.ShutdownTimeout)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go loader.WatchSIGHUP(ctx, cfg, a.reload)
+
+	serveErr := make(chan error, len(httpServers)+1)
+	if a.server != nil {
+		go func() { serveErr <- a.server.ListenAndServe() }()
+	}
+	for _, hs := range httpServers {
+		go func() {
+			slog.Info("gateway listening", "addr", hs.Addr)
+			serveErr <- hs.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		log.Fatalf("listen: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
+	slog.Info("shutting down")
+	checker.Drain()
+
+	// Gateways go first, so their clients are told to go away before the
+	// server behind them stops.
+	exitCode := 0
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), gatewayTimeout)
+	if a.ws != nil {
+		if err := a.ws.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("websockets did not close in time", "err", err)
+			exitCode = 1
+		}
+	}
+	for _, hs := range httpServers {
+		if err := hs.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("in-flight requests did not finish, forcing close", "err", err)
+			hs.Close()
+			exitCode = 1
+		}
+	}
+	cancel()
+	if a.conn != nil {
+		if err := a.conn.Close(); err != nil {
+			slog.Error("failed to close backend connection", "err", err)
+			exitCode = 1
+		}
+	}
+
+	if a.server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		if err := a.server.Shutdown(shutdownCtx); err != nil {
+			exitCode = 1
+		}
+		cancel()
+	}
+
+	started := len(httpServers)
+	if a.server != nil {
+		started++
+	}
+	for range started {
+		if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("listen failed", "err", err)
+			exitCode = 1
+		}
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := flushTraces(flushCtx); err != nil {
+		slog.Error("failed to flush traces", "err", err)
+	}
+	cancel()
+
+	slog.Info("shutdown complete")
+	return exitCode
+}
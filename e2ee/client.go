@@ -0,0 +1,538 @@
+package e2ee
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Bounds of the state a Client keeps.
+const (
+	// maxSkip is how far ahead of the last message seen a sender key's
+	// chain may jump, and how many recent message keys it keeps for
+	// messages arriving late or read again.
+	maxSkip = 2000
+	// keepSignedPreKeys is how many signed prekeys are kept after
+	// rotation, so envelopes sealed against older ones still open.
+	keepSignedPreKeys = 2
+	// keepPending is how many envelopes are kept for the next Sync while
+	// their senders' identity keys can't be looked up.
+	keepPending = 256
+)
+
+// Client holds one user's keys and the sender keys of the rooms they are
+// in. It is safe for concurrent use. Save its state after each call that
+// changes it and Restore it on the next start: a client that loses it
+// can't read what it was sent.
+type Client struct {
+	mu   sync.Mutex
+	keys chatv1.KeyServiceClient
+	st   state
+}
+
+// state is the part of a Client that Save writes. Byte slices are private
+// keys unless noted.
+type state struct {
+	UserID         string            `json:"user_id"`
+	Identity       []byte            `json:"identity"` // X25519
+	Signing        []byte            `json:"signing"`  // Ed25519 seed
+	SignedPreKeys  map[uint32][]byte `json:"signed_prekeys"`
+	SignedPreKeyID uint32            `json:"signed_prekey_id"` // the published one
+	OneTimePreKeys map[uint32][]byte `json:"one_time_prekeys"`
+	NextPreKeyID   uint32            `json:"next_prekey_id"`
+	// Pinned are the identity keys (public) of the users seen so far.
+	Pinned map[string][]byte `json:"pinned"`
+	// Own are this user's sender keys, by room; Peers the sender keys of
+	// everyone, this user included, by peerKey.
+	Own   map[string]*ownSenderKey  `json:"own"`
+	Peers map[string]*peerSenderKey `json:"peers"`
+	// Pending are envelopes, in wire form, left for the next Sync.
+	Pending [][]byte `json:"pending,omitempty"`
+}
+
+type ownSenderKey struct {
+	KeyID     string   `json:"key_id"`
+	Iteration uint32   `json:"iteration"`
+	ChainKey  []byte   `json:"chain_key"`
+	Signing   []byte   `json:"signing"` // Ed25519 seed
+	SentTo    []string `json:"sent_to"` // members handed the key
+}
+
+type peerSenderKey struct {
+	Iteration uint32            `json:"iteration"` // of ChainKey
+	ChainKey  []byte            `json:"chain_key"`
+	Signing   []byte            `json:"signing"` // Ed25519 public key
+	Keys      map[uint32][]byte `json:"keys"`    // message keys of recent iterations
+}
+
+func peerKey(roomID, senderID, keyID string) string {
+	return roomID + "\x00" + senderID + "\x00" + keyID
+}
+
+// New returns a client with a fresh identity for userID. Call Publish
+// before anyone can send it keys.
+func New(userID string, keys chatv1.KeyServiceClient) (*Client, error) {
+	identity, err := newX25519()
+	if err != nil {
+		return nil, err
+	}
+	signing, err := newSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{keys: keys, st: state{
+		UserID:         userID,
+		Identity:       identity.Bytes(),
+		Signing:        signing.Seed(),
+		SignedPreKeys:  make(map[uint32][]byte),
+		OneTimePreKeys: make(map[uint32][]byte),
+		NextPreKeyID:   1,
+		Pinned:         make(map[string][]byte),
+		Own:            make(map[string]*ownSenderKey),
+		Peers:          make(map[string]*peerSenderKey),
+	}}
+	return c, nil
+}
+
+// Restore returns the client whose state Save wrote.
+func Restore(data []byte, keys chatv1.KeyServiceClient) (*Client, error) {
+	c := &Client{keys: keys}
+	if err := json.Unmarshal(data, &c.st); err != nil {
+		return nil, fmt.Errorf("e2ee: restore state: %w", err)
+	}
+	if _, err := parseX25519Private(c.st.Identity); err != nil {
+		return nil, fmt.Errorf("e2ee: restore state: %w", err)
+	}
+	return c, nil
+}
+
+// Save returns the client's state, private keys included. Store it where
+// only its user can read it.
+func (c *Client) Save() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return json.Marshal(&c.st)
+}
+
+// UserID is the user the client holds the keys of.
+func (c *Client) UserID() string {
+	return c.st.UserID
+}
+
+// Fingerprint is the fingerprint of the user's identity key.
+func (c *Client) Fingerprint() string {
+	identity, _ := parseX25519Private(c.st.Identity)
+	return Fingerprint(identity.PublicKey().Bytes())
+}
+
+// Publish publishes the user's bundle with oneTime new one-time prekeys,
+// creating the signed prekey on first use, and returns how many one-time
+// prekeys the server holds. Publish more when that runs low: without
+// them envelopes still work, with less forward secrecy.
+func (c *Client) Publish(ctx context.Context, oneTime int) (int, error) {
+	return c.publish(ctx, oneTime, false)
+}
+
+// RotateSignedPreKey publishes a new signed prekey. The previous one is
+// kept a while for envelopes already sealed against it.
+func (c *Client) RotateSignedPreKey(ctx context.Context) error {
+	_, err := c.publish(ctx, 0, true)
+	return err
+}
+
+func (c *Client) publish(ctx context.Context, oneTime int, rotate bool) (int, error) {
+	c.mu.Lock()
+	identity, _ := parseX25519Private(c.st.Identity)
+	signing := ed25519.NewKeyFromSeed(c.st.Signing)
+	if rotate || c.st.SignedPreKeys[c.st.SignedPreKeyID] == nil {
+		spk, err := newX25519()
+		if err != nil {
+			c.mu.Unlock()
+			return 0, err
+		}
+		c.st.SignedPreKeyID = c.nextPreKeyID()
+		c.st.SignedPreKeys[c.st.SignedPreKeyID] = spk.Bytes()
+		for len(c.st.SignedPreKeys) > keepSignedPreKeys {
+			delete(c.st.SignedPreKeys, slices.Min(mapKeys(c.st.SignedPreKeys)))
+		}
+	}
+	spk, _ := parseX25519Private(c.st.SignedPreKeys[c.st.SignedPreKeyID])
+	bundle := &chatv1.KeyBundle{
+		UserId:      c.st.UserID,
+		IdentityKey: identity.PublicKey().Bytes(),
+		SigningKey:  signing.Public().(ed25519.PublicKey),
+		SignedPrekey: &chatv1.SignedPreKey{
+			Id:        c.st.SignedPreKeyID,
+			PublicKey: spk.PublicKey().Bytes(),
+			Signature: ed25519.Sign(signing, spk.PublicKey().Bytes()),
+		},
+	}
+	for range oneTime {
+		otk, err := newX25519()
+		if err != nil {
+			c.mu.Unlock()
+			return 0, err
+		}
+		id := c.nextPreKeyID()
+		c.st.OneTimePreKeys[id] = otk.Bytes()
+		bundle.OneTimePrekeys = append(bundle.OneTimePrekeys, &chatv1.PreKey{Id: id, PublicKey: otk.PublicKey().Bytes()})
+	}
+	c.mu.Unlock()
+
+	resp, err := c.keys.PublishKeyBundle(ctx, &chatv1.PublishKeyBundleRequest{Bundle: bundle})
+	if err != nil {
+		return 0, err
+	}
+	return int(resp.OneTimePrekeys), nil
+}
+
+// nextPreKeyID hands out prekey ids; c.mu must be held.
+func (c *Client) nextPreKeyID() uint32 {
+	id := c.st.NextPreKeyID
+	c.st.NextPreKeyID++
+	return id
+}
+
+// Forget drops the identity key pinned for userID, so the next one seen is
+// trusted. Call it once the user's new fingerprint has been verified.
+func (c *Client) Forget(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.st.Pinned, userID)
+}
+
+// pin trusts identityKey for userID on first sight and after that only the
+// same key; c.mu must be held.
+func (c *Client) pin(userID string, identityKey []byte) error {
+	if have, ok := c.st.Pinned[userID]; ok {
+		if !slices.Equal(have, identityKey) {
+			return fmt.Errorf("%w: user %s", ErrIdentityChanged, userID)
+		}
+		return nil
+	}
+	c.st.Pinned[userID] = slices.Clone(identityKey)
+	return nil
+}
+
+// ----- Messages -----
+
+// Encrypt returns a message for roomID carrying text encrypted with the
+// user's sender key for the room. members are everyone in the room; those
+// who don't hold the sender key yet are sent it first. When someone who
+// held it is no longer a member, a new sender key replaces it.
+func (c *Client) Encrypt(ctx context.Context, roomID string, members []string, text string) (*chatv1.ChatMessage, error) {
+	c.mu.Lock()
+	own := c.st.Own[roomID]
+	if own != nil && slices.ContainsFunc(own.SentTo, func(m string) bool { return !slices.Contains(members, m) }) {
+		own = nil
+	}
+	if own == nil {
+		var err error
+		if own, err = c.newSenderKey(roomID); err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+	}
+	var missing []string
+	for _, m := range members {
+		if m != c.st.UserID && !slices.Contains(own.SentTo, m) && !slices.Contains(missing, m) {
+			missing = append(missing, m)
+		}
+	}
+	signing := ed25519.NewKeyFromSeed(own.Signing)
+	dist := &chatv1.SenderKeyDistribution{
+		RoomId:     roomID,
+		KeyId:      own.KeyID,
+		Iteration:  own.Iteration,
+		ChainKey:   slices.Clone(own.ChainKey),
+		SigningKey: signing.Public().(ed25519.PublicKey),
+	}
+	c.mu.Unlock()
+
+	if len(missing) > 0 {
+		if err := c.distribute(ctx, dist, missing); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.st.Own[roomID] != own {
+		return nil, fmt.Errorf("e2ee: sender key of room %s changed while sending", roomID)
+	}
+	own.SentTo = append(own.SentTo, missing...)
+	messageKey, next := chainStep(own.ChainKey)
+	key, nonce, err := deriveKey(messageKey, messageInfo)
+	if err != nil {
+		return nil, err
+	}
+	ad := messageAD(roomID, c.st.UserID, own.KeyID)
+	ciphertext, err := seal(key, nonce, []byte(text), ad)
+	if err != nil {
+		return nil, err
+	}
+	enc := &chatv1.EncryptedPayload{
+		Ciphertext: ciphertext,
+		KeyId:      own.KeyID,
+		Iteration:  own.Iteration,
+		Signature:  ed25519.Sign(signing, signedPart(ad, own.Iteration, ciphertext)),
+	}
+	own.ChainKey, own.Iteration = next, own.Iteration+1
+	return &chatv1.ChatMessage{RoomId: roomID, SenderId: c.st.UserID, Encrypted: enc}, nil
+}
+
+// newSenderKey starts a sender key for roomID, keeping a reading copy so
+// the user can decrypt their own messages; c.mu must be held.
+func (c *Client) newSenderKey(roomID string) (*ownSenderKey, error) {
+	signing, err := newSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	own := &ownSenderKey{
+		KeyID:    hex.EncodeToString(randomBytes(16)),
+		ChainKey: randomBytes(32),
+		Signing:  signing.Seed(),
+	}
+	c.st.Own[roomID] = own
+	c.st.Peers[peerKey(roomID, c.st.UserID, own.KeyID)] = &peerSenderKey{
+		ChainKey: slices.Clone(own.ChainKey),
+		Signing:  signing.Public().(ed25519.PublicKey),
+	}
+	return own, nil
+}
+
+// distribute seals dist for each member against a freshly fetched bundle
+// and sends the envelopes in one call.
+func (c *Client) distribute(ctx context.Context, dist *chatv1.SenderKeyDistribution, members []string) error {
+	identity, _ := parseX25519Private(c.st.Identity)
+	var envelopes []*chatv1.KeyEnvelope
+	for _, m := range members {
+		resp, err := c.keys.GetKeyBundle(ctx, &chatv1.GetKeyBundleRequest{UserId: m})
+		if status.Code(err) == codes.NotFound {
+			return fmt.Errorf("%w: %s", ErrNoKeys, m)
+		}
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		err = c.pin(m, resp.Bundle.GetIdentityKey())
+		c.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		e, err := sealEnvelope(identity, c.st.UserID, resp.Bundle, dist)
+		if err != nil {
+			return err
+		}
+		envelopes = append(envelopes, e)
+	}
+	_, err := c.keys.SendKeyEnvelopes(ctx, &chatv1.SendKeyEnvelopesRequest{Envelopes: envelopes})
+	return err
+}
+
+// Rotate drops the user's sender key for roomID; the next Encrypt starts
+// a new one and sends it to every member.
+func (c *Client) Rotate(roomID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.st.Own, roomID)
+}
+
+// Decrypt returns the text of msg. A message without a payload returns
+// its plain text. When msg's sender key is unknown, Decrypt fetches the
+// envelopes waiting for the user first.
+func (c *Client) Decrypt(ctx context.Context, msg *chatv1.ChatMessage) (string, error) {
+	enc := msg.GetEncrypted()
+	if enc == nil {
+		return msg.GetText(), nil
+	}
+	id := peerKey(msg.RoomId, msg.SenderId, enc.KeyId)
+	c.mu.Lock()
+	_, known := c.st.Peers[id]
+	c.mu.Unlock()
+	if !known {
+		if err := c.Sync(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	peer, ok := c.st.Peers[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %s from %s in %s", ErrUnknownKey, enc.KeyId, msg.SenderId, msg.RoomId)
+	}
+	ad := messageAD(msg.RoomId, msg.SenderId, enc.KeyId)
+	if !ed25519.Verify(peer.Signing, signedPart(ad, enc.Iteration, enc.Ciphertext), enc.Signature) {
+		return "", ErrBadMessage
+	}
+	messageKey, err := peer.messageKey(enc.Iteration)
+	if err != nil {
+		return "", err
+	}
+	key, nonce, err := deriveKey(messageKey, messageInfo)
+	if err != nil {
+		return "", err
+	}
+	text, err := open(key, nonce, enc.Ciphertext, ad)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// messageKey returns the message key of iteration, moving the chain on
+// as far as it. The keys of the latest maxSkip iterations are kept, so
+// history fetched again still decrypts; older messages are the
+// application's to keep.
+func (p *peerSenderKey) messageKey(iteration uint32) ([]byte, error) {
+	if iteration < p.Iteration {
+		mk, ok := p.Keys[iteration]
+		if !ok {
+			return nil, fmt.Errorf("%w: iteration %d is too old", ErrBadMessage, iteration)
+		}
+		return mk, nil
+	}
+	if iteration-p.Iteration > maxSkip {
+		return nil, fmt.Errorf("%w: iteration %d is too far ahead", ErrBadMessage, iteration)
+	}
+	if p.Keys == nil {
+		p.Keys = make(map[uint32][]byte)
+	}
+	for p.Iteration <= iteration {
+		mk, next := chainStep(p.ChainKey)
+		p.Keys[p.Iteration] = mk
+		p.ChainKey, p.Iteration = next, p.Iteration+1
+	}
+	for len(p.Keys) > maxSkip {
+		delete(p.Keys, slices.Min(mapKeys(p.Keys)))
+	}
+	return p.Keys[iteration], nil
+}
+
+// Sync opens the envelopes waiting for the user and keeps the sender keys
+// in them. The first envelope from a user pins the identity key it names
+// only if it is the one the user published. Envelopes that don't open, or
+// come from a user whose identity changed, are dropped and reported
+// together, as are those kept for the next Sync because their sender's
+// key can't be looked up now.
+func (c *Client) Sync(ctx context.Context) error {
+	resp, err := c.keys.FetchKeyEnvelopes(ctx, &chatv1.FetchKeyEnvelopesRequest{UserId: c.st.UserID})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	envelopes := make([]*chatv1.KeyEnvelope, 0, len(c.st.Pending)+len(resp.Envelopes))
+	for _, b := range c.st.Pending {
+		e := &chatv1.KeyEnvelope{}
+		if proto.Unmarshal(b, e) == nil {
+			envelopes = append(envelopes, e)
+		}
+	}
+	c.st.Pending = nil
+	envelopes = append(envelopes, resp.Envelopes...)
+	var unpinned []string
+	for _, e := range envelopes {
+		if _, ok := c.st.Pinned[e.SenderId]; !ok && !slices.Contains(unpinned, e.SenderId) {
+			unpinned = append(unpinned, e.SenderId)
+		}
+	}
+	c.mu.Unlock()
+
+	// Look up without claiming a one-time prekey: nothing is sealed to it.
+	published := make(map[string][]byte)
+	lookupErrs := make(map[string]error)
+	for _, u := range unpinned {
+		resp, err := c.keys.GetKeyBundle(ctx, &chatv1.GetKeyBundleRequest{UserId: u, WithoutOneTimePrekey: true})
+		if status.Code(err) == codes.NotFound {
+			err = fmt.Errorf("%w: %s", ErrNoKeys, u)
+		}
+		if err != nil {
+			lookupErrs[u] = err
+			continue
+		}
+		published[u] = resp.Bundle.GetIdentityKey()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for _, e := range envelopes {
+		if lookupErr, ok := lookupErrs[e.SenderId]; ok && !errors.Is(lookupErr, ErrNoKeys) {
+			if b, err := proto.Marshal(e); err == nil && len(c.st.Pending) < keepPending {
+				c.st.Pending = append(c.st.Pending, b)
+				errs = append(errs, fmt.Errorf("envelope from %s kept for the next Sync: %w", e.SenderId, lookupErr))
+				continue
+			}
+		}
+		if err := c.accept(e, published[e.SenderId]); err != nil {
+			if lookupErr, ok := lookupErrs[e.SenderId]; ok {
+				err = lookupErr
+			}
+			errs = append(errs, fmt.Errorf("envelope from %s: %w", e.SenderId, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// accept opens e and keeps its sender key. published is the identity key
+// the sender's bundle holds, checked when the sender isn't pinned yet;
+// c.mu must be held.
+func (c *Client) accept(e *chatv1.KeyEnvelope, published []byte) error {
+	identity, _ := parseX25519Private(c.st.Identity)
+	spkBytes, ok := c.st.SignedPreKeys[e.SignedPrekeyId]
+	if !ok {
+		return fmt.Errorf("%w: signed prekey %d is gone", ErrBadMessage, e.SignedPrekeyId)
+	}
+	spk, _ := parseX25519Private(spkBytes)
+	var otk *ecdh.PrivateKey
+	if e.OneTimePrekeyId != 0 {
+		b, ok := c.st.OneTimePreKeys[e.OneTimePrekeyId]
+		if !ok {
+			return fmt.Errorf("%w: one-time prekey %d already used", ErrBadMessage, e.OneTimePrekeyId)
+		}
+		otk, _ = parseX25519Private(b)
+	}
+	dist, err := openEnvelope(identity, spk, otk, e)
+	if err != nil {
+		return err
+	}
+	// The envelope opened, so the sender holds the identity key it names,
+	// but anyone can name their own key under someone else's id: trust it
+	// on first contact only if it is the one the sender published.
+	if _, pinned := c.st.Pinned[e.SenderId]; !pinned && !slices.Equal(published, e.IdentityKey) {
+		return fmt.Errorf("%w: user %s sealed the envelope with a key they have not published", ErrIdentityChanged, e.SenderId)
+	}
+	if err := c.pin(e.SenderId, e.IdentityKey); err != nil {
+		return err
+	}
+	delete(c.st.OneTimePreKeys, e.OneTimePrekeyId)
+	id := peerKey(dist.RoomId, e.SenderId, dist.KeyId)
+	if _, ok := c.st.Peers[id]; !ok {
+		c.st.Peers[id] = &peerSenderKey{
+			Iteration: dist.Iteration,
+			ChainKey:  dist.ChainKey,
+			Signing:   dist.SigningKey,
+		}
+	}
+	return nil
+}
+
+func mapKeys[V any](m map[uint32]V) []uint32 {
+	keys := make([]uint32, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
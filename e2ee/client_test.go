@@ -0,0 +1,259 @@
+package e2ee
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// keyServer is an in-memory KeyService, handing out one-time prekeys and
+// emptying mailboxes the way the server does.
+type keyServer struct {
+	chatv1.KeyServiceClient
+
+	mu        sync.Mutex
+	bundles   map[string]*chatv1.KeyBundle
+	mailboxes map[string][]*chatv1.KeyEnvelope
+	sent      []*chatv1.KeyEnvelope // every envelope, for inspection
+	down      bool                  // GetKeyBundle fails while set
+}
+
+func newKeyServer() *keyServer {
+	return &keyServer{
+		bundles:   make(map[string]*chatv1.KeyBundle),
+		mailboxes: make(map[string][]*chatv1.KeyEnvelope),
+	}
+}
+
+func (k *keyServer) PublishKeyBundle(_ context.Context, req *chatv1.PublishKeyBundleRequest, _ ...grpc.CallOption) (*chatv1.PublishKeyBundleResponse, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	b := proto.Clone(req.Bundle).(*chatv1.KeyBundle)
+	if prev, ok := k.bundles[b.UserId]; ok && slices.Equal(prev.IdentityKey, b.IdentityKey) {
+		b.OneTimePrekeys = append(prev.OneTimePrekeys, b.OneTimePrekeys...)
+	}
+	k.bundles[b.UserId] = b
+	return &chatv1.PublishKeyBundleResponse{OneTimePrekeys: uint32(len(b.OneTimePrekeys))}, nil
+}
+
+func (k *keyServer) GetKeyBundle(_ context.Context, req *chatv1.GetKeyBundleRequest, _ ...grpc.CallOption) (*chatv1.GetKeyBundleResponse, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.down {
+		return nil, status.Error(codes.Unavailable, "home node unreachable")
+	}
+	b, ok := k.bundles[req.UserId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "user %s has not published keys", req.UserId)
+	}
+	out := proto.Clone(b).(*chatv1.KeyBundle)
+	out.OneTimePrekeys = nil
+	if !req.WithoutOneTimePrekey && len(b.OneTimePrekeys) > 0 {
+		out.OneTimePrekeys = b.OneTimePrekeys[:1]
+		b.OneTimePrekeys = b.OneTimePrekeys[1:]
+	}
+	return &chatv1.GetKeyBundleResponse{Bundle: out}, nil
+}
+
+func (k *keyServer) SendKeyEnvelopes(_ context.Context, req *chatv1.SendKeyEnvelopesRequest, _ ...grpc.CallOption) (*chatv1.SendKeyEnvelopesResponse, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, e := range req.Envelopes {
+		k.mailboxes[e.RecipientId] = append(k.mailboxes[e.RecipientId], e)
+		k.sent = append(k.sent, e)
+	}
+	return &chatv1.SendKeyEnvelopesResponse{}, nil
+}
+
+func (k *keyServer) FetchKeyEnvelopes(_ context.Context, req *chatv1.FetchKeyEnvelopesRequest, _ ...grpc.CallOption) (*chatv1.FetchKeyEnvelopesResponse, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	envelopes := k.mailboxes[req.UserId]
+	delete(k.mailboxes, req.UserId)
+	return &chatv1.FetchKeyEnvelopesResponse{Envelopes: envelopes}, nil
+}
+
+// lastSent returns the last envelope from sender to recipient.
+func (k *keyServer) lastSent(t *testing.T, sender, recipient string) *chatv1.KeyEnvelope {
+	t.Helper()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, e := range slices.Backward(k.sent) {
+		if e.SenderId == sender && e.RecipientId == recipient {
+			return e
+		}
+	}
+	t.Fatalf("no envelope from %s to %s", sender, recipient)
+	return nil
+}
+
+// newUser returns a client for userID that published oneTime one-time
+// prekeys.
+func newUser(t *testing.T, keys *keyServer, userID string, oneTime int) *Client {
+	t.Helper()
+	c, err := New(userID, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Publish(context.Background(), oneTime); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func encrypt(t *testing.T, c *Client, roomID string, members []string, text string) *chatv1.ChatMessage {
+	t.Helper()
+	msg, err := c.Encrypt(context.Background(), roomID, members, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func wantText(t *testing.T, c *Client, msg *chatv1.ChatMessage, want string) {
+	t.Helper()
+	got, err := c.Decrypt(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("%s decrypting %q: %v", c.UserID(), want, err)
+	}
+	if got != want {
+		t.Errorf("%s decrypted %q, want %q", c.UserID(), got, want)
+	}
+}
+
+func TestEnvelopesWithAndWithoutOneTimePrekey(t *testing.T) {
+	keys := newKeyServer()
+	alice := newUser(t, keys, "alice", 1)
+	bob := newUser(t, keys, "bob", 0)
+	carol := newUser(t, keys, "carol", 0)
+
+	fromBob := encrypt(t, bob, "room", []string{"alice", "bob"}, "with a one-time prekey")
+	if e := keys.lastSent(t, "bob", "alice"); e.OneTimePrekeyId == 0 {
+		t.Error("bob's envelope used no one-time prekey")
+	}
+	fromCarol := encrypt(t, carol, "room", []string{"alice", "carol"}, "without one")
+	if e := keys.lastSent(t, "carol", "alice"); e.OneTimePrekeyId != 0 {
+		t.Errorf("carol's envelope used one-time prekey %d, but none was left", e.OneTimePrekeyId)
+	}
+
+	wantText(t, alice, fromBob, "with a one-time prekey")
+	wantText(t, alice, fromCarol, "without one")
+	wantText(t, bob, fromBob, "with a one-time prekey")
+}
+
+func TestSenderKeyRotatesWhenAMemberLeaves(t *testing.T) {
+	keys := newKeyServer()
+	alice := newUser(t, keys, "alice", 4)
+	bob := newUser(t, keys, "bob", 4)
+	carol := newUser(t, keys, "carol", 4)
+
+	before := encrypt(t, alice, "room", []string{"alice", "bob", "carol"}, "everyone")
+	wantText(t, bob, before, "everyone")
+	wantText(t, carol, before, "everyone")
+
+	after := encrypt(t, alice, "room", []string{"alice", "bob"}, "without carol")
+	if after.Encrypted.KeyId == before.Encrypted.KeyId {
+		t.Fatal("sender key kept after carol left")
+	}
+	wantText(t, bob, after, "without carol")
+	wantText(t, alice, after, "without carol")
+	if _, err := carol.Decrypt(context.Background(), after); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("carol decrypting after she left: err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestIdentityChange(t *testing.T) {
+	keys := newKeyServer()
+	alice := newUser(t, keys, "alice", 4)
+	bob := newUser(t, keys, "bob", 4)
+	wantText(t, alice, encrypt(t, bob, "room", []string{"alice", "bob"}, "hi"), "hi")
+
+	// Alice reinstalls and publishes a new identity.
+	newUser(t, keys, "alice", 4)
+	bob.Rotate("room")
+	if _, err := bob.Encrypt(context.Background(), "room", []string{"alice", "bob"}, "again"); !errors.Is(err, ErrIdentityChanged) {
+		t.Fatalf("sending to a changed identity: err = %v, want ErrIdentityChanged", err)
+	}
+	bob.Forget("alice")
+	encrypt(t, bob, "room", []string{"alice", "bob"}, "after comparing fingerprints")
+}
+
+func TestEnvelopeIdentityMustBePublished(t *testing.T) {
+	keys := newKeyServer()
+	alice := newUser(t, keys, "alice", 4)
+	bob := newUser(t, keys, "bob", 4)
+
+	// Mallory seals an envelope under alice's id with her own identity.
+	mallory, err := New("alice", keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := encrypt(t, mallory, "room", []string{"alice", "bob"}, "from mallory")
+	if _, err := bob.Decrypt(context.Background(), forged); !errors.Is(err, ErrIdentityChanged) {
+		t.Fatalf("forged envelope: err = %v, want ErrIdentityChanged", err)
+	}
+
+	// It didn't pin mallory's key, so alice's own envelopes still open.
+	wantText(t, bob, encrypt(t, alice, "room", []string{"alice", "bob"}, "from alice"), "from alice")
+}
+
+func TestEnvelopeKeptWhileSenderCantBeLookedUp(t *testing.T) {
+	keys := newKeyServer()
+	alice := newUser(t, keys, "alice", 4)
+	bob := newUser(t, keys, "bob", 4)
+	msg := encrypt(t, bob, "room", []string{"alice", "bob"}, "later")
+
+	keys.mu.Lock()
+	keys.down = true
+	keys.mu.Unlock()
+	if _, err := alice.Decrypt(context.Background(), msg); status.Code(err) != codes.Unavailable {
+		t.Fatalf("decrypting while bob's home node is down: err = %v, want Unavailable", err)
+	}
+	// The envelope survives a restart of the client too.
+	state, err := alice.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, err = Restore(state, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys.mu.Lock()
+	keys.down = false
+	keys.mu.Unlock()
+	wantText(t, alice, msg, "later")
+}
+
+func TestIterationsOutOfOrderAndReplayed(t *testing.T) {
+	keys := newKeyServer()
+	alice := newUser(t, keys, "alice", 4)
+	bob := newUser(t, keys, "bob", 4)
+	var msgs []*chatv1.ChatMessage
+	for _, text := range []string{"zero", "one", "two", "three"} {
+		msgs = append(msgs, encrypt(t, alice, "room", []string{"alice", "bob"}, text))
+	}
+
+	wantText(t, bob, msgs[2], "two")
+	wantText(t, bob, msgs[0], "zero")
+	wantText(t, bob, msgs[3], "three")
+	wantText(t, bob, msgs[1], "one")
+	// History fetched again still decrypts.
+	wantText(t, bob, msgs[2], "two")
+	wantText(t, bob, msgs[0], "zero")
+
+	// A message claiming another iteration doesn't verify.
+	moved := proto.Clone(msgs[1]).(*chatv1.ChatMessage)
+	moved.Encrypted.Iteration = 2
+	if _, err := bob.Decrypt(context.Background(), moved); !errors.Is(err, ErrBadMessage) {
+		t.Errorf("message moved to another iteration: err = %v, want ErrBadMessage", err)
+	}
+}
@@ -0,0 +1,146 @@
+package e2ee
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/protobuf/proto"
+)
+
+// sealEnvelope encrypts dist for the owner of bundle with X3DH:
+//
+//	DH1 = DH(sender identity, signed prekey)
+//	DH2 = DH(ephemeral, recipient identity)
+//	DH3 = DH(ephemeral, signed prekey)
+//	DH4 = DH(ephemeral, one-time prekey), when the bundle has one
+//
+// The key and nonce come from HKDF over 32 0xFF bytes and DH1..DH4, and
+// the additional data is both identity keys and both user ids.
+func sealEnvelope(identity *ecdh.PrivateKey, senderID string, bundle *chatv1.KeyBundle, dist *chatv1.SenderKeyDistribution) (*chatv1.KeyEnvelope, error) {
+	spk := bundle.GetSignedPrekey()
+	if spk == nil || !ed25519.Verify(bundle.SigningKey, spk.PublicKey, spk.Signature) {
+		return nil, fmt.Errorf("%w: signed prekey of %s", ErrBadMessage, bundle.UserId)
+	}
+	ephemeral, err := newX25519()
+	if err != nil {
+		return nil, err
+	}
+	pairs := []dhPair{
+		{identity, spk.PublicKey},
+		{ephemeral, bundle.IdentityKey},
+		{ephemeral, spk.PublicKey},
+	}
+	e := &chatv1.KeyEnvelope{
+		SenderId:       senderID,
+		RecipientId:    bundle.UserId,
+		IdentityKey:    identity.PublicKey().Bytes(),
+		EphemeralKey:   ephemeral.PublicKey().Bytes(),
+		SignedPrekeyId: spk.Id,
+	}
+	if len(bundle.OneTimePrekeys) > 0 {
+		otk := bundle.OneTimePrekeys[0]
+		pairs = append(pairs, dhPair{ephemeral, otk.PublicKey})
+		e.OneTimePrekeyId = otk.Id
+	}
+	secret, err := x3dhSecret(pairs)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := proto.Marshal(dist)
+	if err != nil {
+		return nil, err
+	}
+	key, nonce, err := deriveKey(secret, envelopeInfo)
+	if err != nil {
+		return nil, err
+	}
+	e.Ciphertext, err = seal(key, nonce, plaintext, envelopeAD(e.IdentityKey, bundle.IdentityKey, senderID, bundle.UserId))
+	return e, err
+}
+
+// openEnvelope is sealEnvelope for the recipient, who holds the private
+// halves of the prekeys the envelope names.
+func openEnvelope(identity, signedPreKey, oneTimePreKey *ecdh.PrivateKey, e *chatv1.KeyEnvelope) (*chatv1.SenderKeyDistribution, error) {
+	pairs := []dhPair{
+		{signedPreKey, e.IdentityKey},
+		{identity, e.EphemeralKey},
+		{signedPreKey, e.EphemeralKey},
+	}
+	if oneTimePreKey != nil {
+		pairs = append(pairs, dhPair{oneTimePreKey, e.EphemeralKey})
+	}
+	secret, err := x3dhSecret(pairs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadMessage, err)
+	}
+	key, nonce, err := deriveKey(secret, envelopeInfo)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(key, nonce, e.Ciphertext, envelopeAD(e.IdentityKey, identity.PublicKey().Bytes(), e.SenderId, e.RecipientId))
+	if err != nil {
+		return nil, err
+	}
+	dist := &chatv1.SenderKeyDistribution{}
+	if err := proto.Unmarshal(plaintext, dist); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadMessage, err)
+	}
+	return dist, nil
+}
+
+// dhPair is one Diffie-Hellman exchange of X3DH.
+type dhPair struct {
+	priv *ecdh.PrivateKey
+	pub  []byte
+}
+
+// x3dhSecret is 32 0xFF bytes followed by the output of each exchange.
+func x3dhSecret(pairs []dhPair) ([]byte, error) {
+	secret := bytes.Repeat([]byte{0xff}, 32)
+	for _, p := range pairs {
+		shared, err := dh(p.priv, p.pub)
+		if err != nil {
+			return nil, err
+		}
+		secret = append(secret, shared...)
+	}
+	return secret, nil
+}
+
+func envelopeAD(senderKey, recipientKey []byte, senderID, recipientID string) []byte {
+	ad := append(append([]byte(nil), senderKey...), recipientKey...)
+	return append(append(append(append(ad, senderID...), 0), recipientID...), 0)
+}
+
+func seal(key, nonce, plaintext, ad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nil, nonce, plaintext, ad), nil
+}
+
+func open(key, nonce, ciphertext, ad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, ErrBadMessage
+	}
+	return plaintext, nil
+}
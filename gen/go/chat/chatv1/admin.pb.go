@@ -0,0 +1,2235 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.1
+// source: admin.proto
+
+package chatv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RestrictionKind int32
+
+const (
+	RestrictionKind_RESTRICTION_KIND_UNSPECIFIED RestrictionKind = 0
+	RestrictionKind_RESTRICTION_KIND_MUTE        RestrictionKind = 1 // the user's messages are refused
+	RestrictionKind_RESTRICTION_KIND_SHADOW      RestrictionKind = 2 // the user's messages look sent but reach nobody
+)
+
+// Enum value maps for RestrictionKind.
+var (
+	RestrictionKind_name = map[int32]string{
+		0: "RESTRICTION_KIND_UNSPECIFIED",
+		1: "RESTRICTION_KIND_MUTE",
+		2: "RESTRICTION_KIND_SHADOW",
+	}
+	RestrictionKind_value = map[string]int32{
+		"RESTRICTION_KIND_UNSPECIFIED": 0,
+		"RESTRICTION_KIND_MUTE":        1,
+		"RESTRICTION_KIND_SHADOW":      2,
+	}
+)
+
+func (x RestrictionKind) Enum() *RestrictionKind {
+	p := new(RestrictionKind)
+	*p = x
+	return p
+}
+
+func (x RestrictionKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RestrictionKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_admin_proto_enumTypes[0].Descriptor()
+}
+
+func (RestrictionKind) Type() protoreflect.EnumType {
+	return &file_admin_proto_enumTypes[0]
+}
+
+func (x RestrictionKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RestrictionKind.Descriptor instead.
+func (RestrictionKind) EnumDescriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type EraseMode int32
+
+const (
+	EraseMode_ERASE_MODE_UNSPECIFIED EraseMode = 0
+	EraseMode_ERASE_MODE_ANONYMIZE   EraseMode = 1 // replace the sender with a pseudonym, keep the text
+	EraseMode_ERASE_MODE_DELETE      EraseMode = 2 // also delete the text and encrypted payload
+)
+
+// Enum value maps for EraseMode.
+var (
+	EraseMode_name = map[int32]string{
+		0: "ERASE_MODE_UNSPECIFIED",
+		1: "ERASE_MODE_ANONYMIZE",
+		2: "ERASE_MODE_DELETE",
+	}
+	EraseMode_value = map[string]int32{
+		"ERASE_MODE_UNSPECIFIED": 0,
+		"ERASE_MODE_ANONYMIZE":   1,
+		"ERASE_MODE_DELETE":      2,
+	}
+)
+
+func (x EraseMode) Enum() *EraseMode {
+	p := new(EraseMode)
+	*p = x
+	return p
+}
+
+func (x EraseMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EraseMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_admin_proto_enumTypes[1].Descriptor()
+}
+
+func (EraseMode) Type() protoreflect.EnumType {
+	return &file_admin_proto_enumTypes[1]
+}
+
+func (x EraseMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EraseMode.Descriptor instead.
+func (EraseMode) EnumDescriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{1}
+}
+
+type GetRoomSettingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRoomSettingsRequest) Reset() {
+	*x = GetRoomSettingsRequest{}
+	mi := &file_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRoomSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRoomSettingsRequest) ProtoMessage() {}
+
+func (x *GetRoomSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRoomSettingsRequest.ProtoReflect.Descriptor instead.
+func (*GetRoomSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetRoomSettingsRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+type GetRoomSettingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *RoomSettings          `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRoomSettingsResponse) Reset() {
+	*x = GetRoomSettingsResponse{}
+	mi := &file_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRoomSettingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRoomSettingsResponse) ProtoMessage() {}
+
+func (x *GetRoomSettingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRoomSettingsResponse.ProtoReflect.Descriptor instead.
+func (*GetRoomSettingsResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetRoomSettingsResponse) GetSettings() *RoomSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type UpdateRoomSettingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *RoomSettings          `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"` // replaces the room's settings
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRoomSettingsRequest) Reset() {
+	*x = UpdateRoomSettingsRequest{}
+	mi := &file_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRoomSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRoomSettingsRequest) ProtoMessage() {}
+
+func (x *UpdateRoomSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRoomSettingsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRoomSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UpdateRoomSettingsRequest) GetSettings() *RoomSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type UpdateRoomSettingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *RoomSettings          `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRoomSettingsResponse) Reset() {
+	*x = UpdateRoomSettingsResponse{}
+	mi := &file_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRoomSettingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRoomSettingsResponse) ProtoMessage() {}
+
+func (x *UpdateRoomSettingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRoomSettingsResponse.ProtoReflect.Descriptor instead.
+func (*UpdateRoomSettingsResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateRoomSettingsResponse) GetSettings() *RoomSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+// ModerationFlag is why one filter wants a human to look at a message.
+type ModerationFlag struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        string                 `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModerationFlag) Reset() {
+	*x = ModerationFlag{}
+	mi := &file_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModerationFlag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModerationFlag) ProtoMessage() {}
+
+func (x *ModerationFlag) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModerationFlag.ProtoReflect.Descriptor instead.
+func (*ModerationFlag) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ModerationFlag) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *ModerationFlag) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type FlaggedMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       *ChatMessage           `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"` // as stored and broadcast
+	Flags         []*ModerationFlag      `protobuf:"bytes,2,rep,name=flags,proto3" json:"flags,omitempty"`
+	FlaggedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=flagged_at,json=flaggedAt,proto3" json:"flagged_at,omitempty"`
+	OriginalText  string                 `protobuf:"bytes,4,opt,name=original_text,json=originalText,proto3" json:"original_text,omitempty"` // the text as sent, when a filter rewrote it
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlaggedMessage) Reset() {
+	*x = FlaggedMessage{}
+	mi := &file_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlaggedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlaggedMessage) ProtoMessage() {}
+
+func (x *FlaggedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlaggedMessage.ProtoReflect.Descriptor instead.
+func (*FlaggedMessage) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FlaggedMessage) GetMessage() *ChatMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *FlaggedMessage) GetFlags() []*ModerationFlag {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+func (x *FlaggedMessage) GetFlaggedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FlaggedAt
+	}
+	return nil
+}
+
+func (x *FlaggedMessage) GetOriginalText() string {
+	if x != nil {
+		return x.OriginalText
+	}
+	return ""
+}
+
+type ListFlaggedMessagesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"` // empty lists every room, from every node
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`                // the latest limit messages; 0 lists them all
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFlaggedMessagesRequest) Reset() {
+	*x = ListFlaggedMessagesRequest{}
+	mi := &file_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFlaggedMessagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFlaggedMessagesRequest) ProtoMessage() {}
+
+func (x *ListFlaggedMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFlaggedMessagesRequest.ProtoReflect.Descriptor instead.
+func (*ListFlaggedMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListFlaggedMessagesRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *ListFlaggedMessagesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListFlaggedMessagesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*FlaggedMessage      `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"` // oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFlaggedMessagesResponse) Reset() {
+	*x = ListFlaggedMessagesResponse{}
+	mi := &file_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFlaggedMessagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFlaggedMessagesResponse) ProtoMessage() {}
+
+func (x *ListFlaggedMessagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFlaggedMessagesResponse.ProtoReflect.Descriptor instead.
+func (*ListFlaggedMessagesResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListFlaggedMessagesResponse) GetMessages() []*FlaggedMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+// UserRestriction is one decision of spam detection, kept whether it is
+// still in force or not.
+type UserRestriction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Kind          RestrictionKind        `protobuf:"varint,3,opt,name=kind,proto3,enum=chat.v1.RestrictionKind" json:"kind,omitempty"`
+	Signals       []string               `protobuf:"bytes,4,rep,name=signals,proto3" json:"signals,omitempty"` // what raised the score
+	Score         float64                `protobuf:"fixed64,5,opt,name=score,proto3" json:"score,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	LiftedAt      *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=lifted_at,json=liftedAt,proto3" json:"lifted_at,omitempty"` // set when an operator lifted it early
+	LiftedBy      string                 `protobuf:"bytes,9,opt,name=lifted_by,json=liftedBy,proto3" json:"lifted_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserRestriction) Reset() {
+	*x = UserRestriction{}
+	mi := &file_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserRestriction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRestriction) ProtoMessage() {}
+
+func (x *UserRestriction) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRestriction.ProtoReflect.Descriptor instead.
+func (*UserRestriction) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UserRestriction) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UserRestriction) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UserRestriction) GetKind() RestrictionKind {
+	if x != nil {
+		return x.Kind
+	}
+	return RestrictionKind_RESTRICTION_KIND_UNSPECIFIED
+}
+
+func (x *UserRestriction) GetSignals() []string {
+	if x != nil {
+		return x.Signals
+	}
+	return nil
+}
+
+func (x *UserRestriction) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *UserRestriction) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *UserRestriction) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *UserRestriction) GetLiftedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LiftedAt
+	}
+	return nil
+}
+
+func (x *UserRestriction) GetLiftedBy() string {
+	if x != nil {
+		return x.LiftedBy
+	}
+	return ""
+}
+
+type ListUserRestrictionsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                             // empty lists every user, from every node
+	IncludeInactive bool                   `protobuf:"varint,2,opt,name=include_inactive,json=includeInactive,proto3" json:"include_inactive,omitempty"` // also list expired and lifted restrictions
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListUserRestrictionsRequest) Reset() {
+	*x = ListUserRestrictionsRequest{}
+	mi := &file_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserRestrictionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserRestrictionsRequest) ProtoMessage() {}
+
+func (x *ListUserRestrictionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserRestrictionsRequest.ProtoReflect.Descriptor instead.
+func (*ListUserRestrictionsRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListUserRestrictionsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListUserRestrictionsRequest) GetIncludeInactive() bool {
+	if x != nil {
+		return x.IncludeInactive
+	}
+	return false
+}
+
+type ListUserRestrictionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Restrictions  []*UserRestriction     `protobuf:"bytes,1,rep,name=restrictions,proto3" json:"restrictions,omitempty"` // oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserRestrictionsResponse) Reset() {
+	*x = ListUserRestrictionsResponse{}
+	mi := &file_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserRestrictionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserRestrictionsResponse) ProtoMessage() {}
+
+func (x *ListUserRestrictionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserRestrictionsResponse.ProtoReflect.Descriptor instead.
+func (*ListUserRestrictionsResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListUserRestrictionsResponse) GetRestrictions() []*UserRestriction {
+	if x != nil {
+		return x.Restrictions
+	}
+	return nil
+}
+
+type LiftUserRestrictionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LiftUserRestrictionRequest) Reset() {
+	*x = LiftUserRestrictionRequest{}
+	mi := &file_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LiftUserRestrictionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LiftUserRestrictionRequest) ProtoMessage() {}
+
+func (x *LiftUserRestrictionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LiftUserRestrictionRequest.ProtoReflect.Descriptor instead.
+func (*LiftUserRestrictionRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *LiftUserRestrictionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type LiftUserRestrictionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Restriction   *UserRestriction       `protobuf:"bytes,1,opt,name=restriction,proto3" json:"restriction,omitempty"` // as lifted; unset when none was in force
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LiftUserRestrictionResponse) Reset() {
+	*x = LiftUserRestrictionResponse{}
+	mi := &file_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LiftUserRestrictionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LiftUserRestrictionResponse) ProtoMessage() {}
+
+func (x *LiftUserRestrictionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LiftUserRestrictionResponse.ProtoReflect.Descriptor instead.
+func (*LiftUserRestrictionResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *LiftUserRestrictionResponse) GetRestriction() *UserRestriction {
+	if x != nil {
+		return x.Restriction
+	}
+	return nil
+}
+
+// AuditEvent is one entry of a node's append-only audit log. Each entry
+// carries the hash of the one before, so editing, dropping or reordering
+// entries breaks the chain.
+type AuditEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sequence      uint64                 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"` // position in the node's log, from 1
+	Time          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	NodeId        string                 `protobuf:"bytes,3,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Actor         string                 `protobuf:"bytes,4,opt,name=actor,proto3" json:"actor,omitempty"`   // the operator, or "spam" and "sighup" for automatic actions
+	Action        string                 `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"` // e.g. "room.settings.update", "user.restrict"
+	RoomId        string                 `protobuf:"bytes,6,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,7,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // the user acted on
+	Details       map[string]string      `protobuf:"bytes,8,rep,name=details,proto3" json:"details,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	PrevHash      []byte                 `protobuf:"bytes,9,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	Hash          []byte                 `protobuf:"bytes,10,opt,name=hash,proto3" json:"hash,omitempty"` // SHA-256 of prev_hash and the entry without hash
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEvent) Reset() {
+	*x = AuditEvent{}
+	mi := &file_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEvent) ProtoMessage() {}
+
+func (x *AuditEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEvent.ProtoReflect.Descriptor instead.
+func (*AuditEvent) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AuditEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *AuditEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetDetails() map[string]string {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetPrevHash() []byte {
+	if x != nil {
+		return x.PrevHash
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+// AuditChain is the result of checking one node's log end to end.
+type AuditChain struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Events        uint64                 `protobuf:"varint,2,opt,name=events,proto3" json:"events,omitempty"`
+	Intact        bool                   `protobuf:"varint,3,opt,name=intact,proto3" json:"intact,omitempty"`
+	BrokenAt      uint64                 `protobuf:"varint,4,opt,name=broken_at,json=brokenAt,proto3" json:"broken_at,omitempty"` // sequence of the first entry that doesn't verify
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditChain) Reset() {
+	*x = AuditChain{}
+	mi := &file_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditChain) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditChain) ProtoMessage() {}
+
+func (x *AuditChain) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditChain.ProtoReflect.Descriptor instead.
+func (*AuditChain) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *AuditChain) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *AuditChain) GetEvents() uint64 {
+	if x != nil {
+		return x.Events
+	}
+	return 0
+}
+
+func (x *AuditChain) GetIntact() bool {
+	if x != nil {
+		return x.Intact
+	}
+	return false
+}
+
+func (x *AuditChain) GetBrokenAt() uint64 {
+	if x != nil {
+		return x.BrokenAt
+	}
+	return 0
+}
+
+func (x *AuditChain) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ListAuditEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Actor         string                 `protobuf:"bytes,1,opt,name=actor,proto3" json:"actor,omitempty"`
+	RoomId        string                 `protobuf:"bytes,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=since,proto3" json:"since,omitempty"`  // inclusive
+	Until         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=until,proto3" json:"until,omitempty"`  // exclusive
+	Limit         int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"` // the latest limit events; 0 lists them all
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEventsRequest) Reset() {
+	*x = ListAuditEventsRequest{}
+	mi := &file_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEventsRequest) ProtoMessage() {}
+
+func (x *ListAuditEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEventsRequest.ProtoReflect.Descriptor instead.
+func (*ListAuditEventsRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListAuditEventsRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *ListAuditEventsRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *ListAuditEventsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListAuditEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*AuditEvent          `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"` // oldest first
+	Chains        []*AuditChain          `protobuf:"bytes,2,rep,name=chains,proto3" json:"chains,omitempty"` // one per node
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEventsResponse) Reset() {
+	*x = ListAuditEventsResponse{}
+	mi := &file_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEventsResponse) ProtoMessage() {}
+
+func (x *ListAuditEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEventsResponse.ProtoReflect.Descriptor instead.
+func (*ListAuditEventsResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListAuditEventsResponse) GetEvents() []*AuditEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *ListAuditEventsResponse) GetChains() []*AuditChain {
+	if x != nil {
+		return x.Chains
+	}
+	return nil
+}
+
+type ResetSigningKeysRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetSigningKeysRequest) Reset() {
+	*x = ResetSigningKeysRequest{}
+	mi := &file_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetSigningKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetSigningKeysRequest) ProtoMessage() {}
+
+func (x *ResetSigningKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetSigningKeysRequest.ProtoReflect.Descriptor instead.
+func (*ResetSigningKeysRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ResetSigningKeysRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ResetSigningKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Revoked       []*SigningKey          `protobuf:"bytes,1,rep,name=revoked,proto3" json:"revoked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetSigningKeysResponse) Reset() {
+	*x = ResetSigningKeysResponse{}
+	mi := &file_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetSigningKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetSigningKeysResponse) ProtoMessage() {}
+
+func (x *ResetSigningKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetSigningKeysResponse.ProtoReflect.Descriptor instead.
+func (*ResetSigningKeysResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ResetSigningKeysResponse) GetRevoked() []*SigningKey {
+	if x != nil {
+		return x.Revoked
+	}
+	return nil
+}
+
+type ExportUserDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportUserDataRequest) Reset() {
+	*x = ExportUserDataRequest{}
+	mi := &file_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportUserDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportUserDataRequest) ProtoMessage() {}
+
+func (x *ExportUserDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportUserDataRequest.ProtoReflect.Descriptor instead.
+func (*ExportUserDataRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ExportUserDataRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// RoomMembership is a room a user joined or posted in.
+type RoomMembership struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RoomId         string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Messages       uint64                 `protobuf:"varint,2,opt,name=messages,proto3" json:"messages,omitempty"`
+	FirstMessageAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=first_message_at,json=firstMessageAt,proto3" json:"first_message_at,omitempty"`
+	LastMessageAt  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_message_at,json=lastMessageAt,proto3" json:"last_message_at,omitempty"`
+	Joined         bool                   `protobuf:"varint,5,opt,name=joined,proto3" json:"joined,omitempty"` // the user is a member of the room
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RoomMembership) Reset() {
+	*x = RoomMembership{}
+	mi := &file_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomMembership) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomMembership) ProtoMessage() {}
+
+func (x *RoomMembership) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomMembership.ProtoReflect.Descriptor instead.
+func (*RoomMembership) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RoomMembership) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *RoomMembership) GetMessages() uint64 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+func (x *RoomMembership) GetFirstMessageAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FirstMessageAt
+	}
+	return nil
+}
+
+func (x *RoomMembership) GetLastMessageAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastMessageAt
+	}
+	return nil
+}
+
+func (x *RoomMembership) GetJoined() bool {
+	if x != nil {
+		return x.Joined
+	}
+	return false
+}
+
+// UserDataRecord is one entry of a user's data export.
+type UserDataRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Record:
+	//
+	//	*UserDataRecord_Message
+	//	*UserDataRecord_Membership
+	//	*UserDataRecord_SigningKey
+	//	*UserDataRecord_Restriction
+	Record        isUserDataRecord_Record `protobuf_oneof:"record"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserDataRecord) Reset() {
+	*x = UserDataRecord{}
+	mi := &file_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserDataRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserDataRecord) ProtoMessage() {}
+
+func (x *UserDataRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserDataRecord.ProtoReflect.Descriptor instead.
+func (*UserDataRecord) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UserDataRecord) GetRecord() isUserDataRecord_Record {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *UserDataRecord) GetMessage() *ChatMessage {
+	if x != nil {
+		if x, ok := x.Record.(*UserDataRecord_Message); ok {
+			return x.Message
+		}
+	}
+	return nil
+}
+
+func (x *UserDataRecord) GetMembership() *RoomMembership {
+	if x != nil {
+		if x, ok := x.Record.(*UserDataRecord_Membership); ok {
+			return x.Membership
+		}
+	}
+	return nil
+}
+
+func (x *UserDataRecord) GetSigningKey() *SigningKey {
+	if x != nil {
+		if x, ok := x.Record.(*UserDataRecord_SigningKey); ok {
+			return x.SigningKey
+		}
+	}
+	return nil
+}
+
+func (x *UserDataRecord) GetRestriction() *UserRestriction {
+	if x != nil {
+		if x, ok := x.Record.(*UserDataRecord_Restriction); ok {
+			return x.Restriction
+		}
+	}
+	return nil
+}
+
+type isUserDataRecord_Record interface {
+	isUserDataRecord_Record()
+}
+
+type UserDataRecord_Message struct {
+	Message *ChatMessage `protobuf:"bytes,1,opt,name=message,proto3,oneof"`
+}
+
+type UserDataRecord_Membership struct {
+	Membership *RoomMembership `protobuf:"bytes,2,opt,name=membership,proto3,oneof"`
+}
+
+type UserDataRecord_SigningKey struct {
+	SigningKey *SigningKey `protobuf:"bytes,3,opt,name=signing_key,json=signingKey,proto3,oneof"`
+}
+
+type UserDataRecord_Restriction struct {
+	Restriction *UserRestriction `protobuf:"bytes,4,opt,name=restriction,proto3,oneof"`
+}
+
+func (*UserDataRecord_Message) isUserDataRecord_Record() {}
+
+func (*UserDataRecord_Membership) isUserDataRecord_Record() {}
+
+func (*UserDataRecord_SigningKey) isUserDataRecord_Record() {}
+
+func (*UserDataRecord_Restriction) isUserDataRecord_Record() {}
+
+type EraseUserRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Mode   EraseMode              `protobuf:"varint,2,opt,name=mode,proto3,enum=chat.v1.EraseMode" json:"mode,omitempty"`
+	// alias replaces user_id as the sender of the erased messages. Empty
+	// picks a random one; pass the one returned to finish an erasure that
+	// failed part way with the same pseudonym.
+	Alias         string `protobuf:"bytes,3,opt,name=alias,proto3" json:"alias,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EraseUserRequest) Reset() {
+	*x = EraseUserRequest{}
+	mi := &file_admin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EraseUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EraseUserRequest) ProtoMessage() {}
+
+func (x *EraseUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EraseUserRequest.ProtoReflect.Descriptor instead.
+func (*EraseUserRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *EraseUserRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *EraseUserRequest) GetMode() EraseMode {
+	if x != nil {
+		return x.Mode
+	}
+	return EraseMode_ERASE_MODE_UNSPECIFIED
+}
+
+func (x *EraseUserRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+type EraseUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Messages      uint64                 `protobuf:"varint,2,opt,name=messages,proto3" json:"messages,omitempty"` // messages erased
+	Rooms         uint64                 `protobuf:"varint,3,opt,name=rooms,proto3" json:"rooms,omitempty"`       // rooms they were in
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EraseUserResponse) Reset() {
+	*x = EraseUserResponse{}
+	mi := &file_admin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EraseUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EraseUserResponse) ProtoMessage() {}
+
+func (x *EraseUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EraseUserResponse.ProtoReflect.Descriptor instead.
+func (*EraseUserResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *EraseUserResponse) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *EraseUserResponse) GetMessages() uint64 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+func (x *EraseUserResponse) GetRooms() uint64 {
+	if x != nil {
+		return x.Rooms
+	}
+	return 0
+}
+
+type ExportRoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRoomRequest) Reset() {
+	*x = ExportRoomRequest{}
+	mi := &file_admin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRoomRequest) ProtoMessage() {}
+
+func (x *ExportRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRoomRequest.ProtoReflect.Descriptor instead.
+func (*ExportRoomRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ExportRoomRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+// ImportRoomRequest carries a batch of a room's history. The first one
+// names the room; later ones may leave room_id empty.
+type ImportRoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Messages      []*ChatMessage         `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"` // oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportRoomRequest) Reset() {
+	*x = ImportRoomRequest{}
+	mi := &file_admin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRoomRequest) ProtoMessage() {}
+
+func (x *ImportRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRoomRequest.ProtoReflect.Descriptor instead.
+func (*ImportRoomRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ImportRoomRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *ImportRoomRequest) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+type ImportRoomResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Imported      uint64                 `protobuf:"varint,2,opt,name=imported,proto3" json:"imported,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportRoomResponse) Reset() {
+	*x = ImportRoomResponse{}
+	mi := &file_admin_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportRoomResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRoomResponse) ProtoMessage() {}
+
+func (x *ImportRoomResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRoomResponse.ProtoReflect.Descriptor instead.
+func (*ImportRoomResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ImportRoomResponse) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *ImportRoomResponse) GetImported() uint64 {
+	if x != nil {
+		return x.Imported
+	}
+	return 0
+}
+
+type RetentionReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"` // empty reports every room, from every node
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionReportRequest) Reset() {
+	*x = RetentionReportRequest{}
+	mi := &file_admin_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionReportRequest) ProtoMessage() {}
+
+func (x *RetentionReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionReportRequest.ProtoReflect.Descriptor instead.
+func (*RetentionReportRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RetentionReportRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+// RoomRetentionReport is what the next compaction would remove from a room.
+type RoomRetentionReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Policy        *RetentionPolicy       `protobuf:"bytes,2,opt,name=policy,proto3" json:"policy,omitempty"` // in effect: the room's own or the server's default
+	LegalHold     bool                   `protobuf:"varint,3,opt,name=legal_hold,json=legalHold,proto3" json:"legal_hold,omitempty"`
+	Messages      uint64                 `protobuf:"varint,4,opt,name=messages,proto3" json:"messages,omitempty"`                                // stored, tombstones not counted
+	Expired       uint64                 `protobuf:"varint,5,opt,name=expired,proto3" json:"expired,omitempty"`                                  // would be removed
+	FirstSequence uint64                 `protobuf:"varint,6,opt,name=first_sequence,json=firstSequence,proto3" json:"first_sequence,omitempty"` // of the expired messages
+	LastSequence  uint64                 `protobuf:"varint,7,opt,name=last_sequence,json=lastSequence,proto3" json:"last_sequence,omitempty"`
+	Oldest        *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=oldest,proto3" json:"oldest,omitempty"` // created_at of the oldest expired message
+	Newest        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=newest,proto3" json:"newest,omitempty"` // created_at of the newest expired message
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomRetentionReport) Reset() {
+	*x = RoomRetentionReport{}
+	mi := &file_admin_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomRetentionReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomRetentionReport) ProtoMessage() {}
+
+func (x *RoomRetentionReport) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomRetentionReport.ProtoReflect.Descriptor instead.
+func (*RoomRetentionReport) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RoomRetentionReport) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *RoomRetentionReport) GetPolicy() *RetentionPolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+func (x *RoomRetentionReport) GetLegalHold() bool {
+	if x != nil {
+		return x.LegalHold
+	}
+	return false
+}
+
+func (x *RoomRetentionReport) GetMessages() uint64 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+func (x *RoomRetentionReport) GetExpired() uint64 {
+	if x != nil {
+		return x.Expired
+	}
+	return 0
+}
+
+func (x *RoomRetentionReport) GetFirstSequence() uint64 {
+	if x != nil {
+		return x.FirstSequence
+	}
+	return 0
+}
+
+func (x *RoomRetentionReport) GetLastSequence() uint64 {
+	if x != nil {
+		return x.LastSequence
+	}
+	return 0
+}
+
+func (x *RoomRetentionReport) GetOldest() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Oldest
+	}
+	return nil
+}
+
+func (x *RoomRetentionReport) GetNewest() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Newest
+	}
+	return nil
+}
+
+type RetentionReportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rooms         []*RoomRetentionReport `protobuf:"bytes,1,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionReportResponse) Reset() {
+	*x = RetentionReportResponse{}
+	mi := &file_admin_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionReportResponse) ProtoMessage() {}
+
+func (x *RetentionReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionReportResponse.ProtoReflect.Descriptor instead.
+func (*RetentionReportResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RetentionReportResponse) GetRooms() []*RoomRetentionReport {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+var File_admin_proto protoreflect.FileDescriptor
+
+const file_admin_proto_rawDesc = "" +
+	"\n" +
+	"\vadmin.proto\x12\achat.v1\x1a\n" +
+	"chat.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\n" +
+	"keys.proto\"1\n" +
+	"\x16GetRoomSettingsRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\"L\n" +
+	"\x17GetRoomSettingsResponse\x121\n" +
+	"\bsettings\x18\x01 \x01(\v2\x15.chat.v1.RoomSettingsR\bsettings\"N\n" +
+	"\x19UpdateRoomSettingsRequest\x121\n" +
+	"\bsettings\x18\x01 \x01(\v2\x15.chat.v1.RoomSettingsR\bsettings\"O\n" +
+	"\x1aUpdateRoomSettingsResponse\x121\n" +
+	"\bsettings\x18\x01 \x01(\v2\x15.chat.v1.RoomSettingsR\bsettings\"@\n" +
+	"\x0eModerationFlag\x12\x16\n" +
+	"\x06filter\x18\x01 \x01(\tR\x06filter\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\xcf\x01\n" +
+	"\x0eFlaggedMessage\x12.\n" +
+	"\amessage\x18\x01 \x01(\v2\x14.chat.v1.ChatMessageR\amessage\x12-\n" +
+	"\x05flags\x18\x02 \x03(\v2\x17.chat.v1.ModerationFlagR\x05flags\x129\n" +
+	"\n" +
+	"flagged_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tflaggedAt\x12#\n" +
+	"\roriginal_text\x18\x04 \x01(\tR\foriginalText\"K\n" +
+	"\x1aListFlaggedMessagesRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"R\n" +
+	"\x1bListFlaggedMessagesResponse\x123\n" +
+	"\bmessages\x18\x01 \x03(\v2\x17.chat.v1.FlaggedMessageR\bmessages\"\xe4\x02\n" +
+	"\x0fUserRestriction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12,\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x18.chat.v1.RestrictionKindR\x04kind\x12\x18\n" +
+	"\asignals\x18\x04 \x03(\tR\asignals\x12\x14\n" +
+	"\x05score\x18\x05 \x01(\x01R\x05score\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x127\n" +
+	"\tlifted_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\bliftedAt\x12\x1b\n" +
+	"\tlifted_by\x18\t \x01(\tR\bliftedBy\"a\n" +
+	"\x1bListUserRestrictionsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12)\n" +
+	"\x10include_inactive\x18\x02 \x01(\bR\x0fincludeInactive\"\\\n" +
+	"\x1cListUserRestrictionsResponse\x12<\n" +
+	"\frestrictions\x18\x01 \x03(\v2\x18.chat.v1.UserRestrictionR\frestrictions\"5\n" +
+	"\x1aLiftUserRestrictionRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"Y\n" +
+	"\x1bLiftUserRestrictionResponse\x12:\n" +
+	"\vrestriction\x18\x01 \x01(\v2\x18.chat.v1.UserRestrictionR\vrestriction\"\xfa\x02\n" +
+	"\n" +
+	"AuditEvent\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x17\n" +
+	"\anode_id\x18\x03 \x01(\tR\x06nodeId\x12\x14\n" +
+	"\x05actor\x18\x04 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06action\x18\x05 \x01(\tR\x06action\x12\x17\n" +
+	"\aroom_id\x18\x06 \x01(\tR\x06roomId\x12\x17\n" +
+	"\auser_id\x18\a \x01(\tR\x06userId\x12:\n" +
+	"\adetails\x18\b \x03(\v2 .chat.v1.AuditEvent.DetailsEntryR\adetails\x12\x1b\n" +
+	"\tprev_hash\x18\t \x01(\fR\bprevHash\x12\x12\n" +
+	"\x04hash\x18\n" +
+	" \x01(\fR\x04hash\x1a:\n" +
+	"\fDetailsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x88\x01\n" +
+	"\n" +
+	"AuditChain\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x16\n" +
+	"\x06events\x18\x02 \x01(\x04R\x06events\x12\x16\n" +
+	"\x06intact\x18\x03 \x01(\bR\x06intact\x12\x1b\n" +
+	"\tbroken_at\x18\x04 \x01(\x04R\bbrokenAt\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"\xc1\x01\n" +
+	"\x16ListAuditEventsRequest\x12\x14\n" +
+	"\x05actor\x18\x01 \x01(\tR\x05actor\x12\x17\n" +
+	"\aroom_id\x18\x02 \x01(\tR\x06roomId\x120\n" +
+	"\x05since\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\"s\n" +
+	"\x17ListAuditEventsResponse\x12+\n" +
+	"\x06events\x18\x01 \x03(\v2\x13.chat.v1.AuditEventR\x06events\x12+\n" +
+	"\x06chains\x18\x02 \x03(\v2\x13.chat.v1.AuditChainR\x06chains\"2\n" +
+	"\x17ResetSigningKeysRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"I\n" +
+	"\x18ResetSigningKeysResponse\x12-\n" +
+	"\arevoked\x18\x01 \x03(\v2\x13.chat.v1.SigningKeyR\arevoked\"0\n" +
+	"\x15ExportUserDataRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"\xe7\x01\n" +
+	"\x0eRoomMembership\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1a\n" +
+	"\bmessages\x18\x02 \x01(\x04R\bmessages\x12D\n" +
+	"\x10first_message_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x0efirstMessageAt\x12B\n" +
+	"\x0flast_message_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\rlastMessageAt\x12\x16\n" +
+	"\x06joined\x18\x05 \x01(\bR\x06joined\"\xfd\x01\n" +
+	"\x0eUserDataRecord\x120\n" +
+	"\amessage\x18\x01 \x01(\v2\x14.chat.v1.ChatMessageH\x00R\amessage\x129\n" +
+	"\n" +
+	"membership\x18\x02 \x01(\v2\x17.chat.v1.RoomMembershipH\x00R\n" +
+	"membership\x126\n" +
+	"\vsigning_key\x18\x03 \x01(\v2\x13.chat.v1.SigningKeyH\x00R\n" +
+	"signingKey\x12<\n" +
+	"\vrestriction\x18\x04 \x01(\v2\x18.chat.v1.UserRestrictionH\x00R\vrestrictionB\b\n" +
+	"\x06record\"i\n" +
+	"\x10EraseUserRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12&\n" +
+	"\x04mode\x18\x02 \x01(\x0e2\x12.chat.v1.EraseModeR\x04mode\x12\x14\n" +
+	"\x05alias\x18\x03 \x01(\tR\x05alias\"[\n" +
+	"\x11EraseUserResponse\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x1a\n" +
+	"\bmessages\x18\x02 \x01(\x04R\bmessages\x12\x14\n" +
+	"\x05rooms\x18\x03 \x01(\x04R\x05rooms\",\n" +
+	"\x11ExportRoomRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\"^\n" +
+	"\x11ImportRoomRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x120\n" +
+	"\bmessages\x18\x02 \x03(\v2\x14.chat.v1.ChatMessageR\bmessages\"I\n" +
+	"\x12ImportRoomResponse\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1a\n" +
+	"\bimported\x18\x02 \x01(\x04R\bimported\"1\n" +
+	"\x16RetentionReportRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\"\xe9\x02\n" +
+	"\x13RoomRetentionReport\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x120\n" +
+	"\x06policy\x18\x02 \x01(\v2\x18.chat.v1.RetentionPolicyR\x06policy\x12\x1d\n" +
+	"\n" +
+	"legal_hold\x18\x03 \x01(\bR\tlegalHold\x12\x1a\n" +
+	"\bmessages\x18\x04 \x01(\x04R\bmessages\x12\x18\n" +
+	"\aexpired\x18\x05 \x01(\x04R\aexpired\x12%\n" +
+	"\x0efirst_sequence\x18\x06 \x01(\x04R\rfirstSequence\x12#\n" +
+	"\rlast_sequence\x18\a \x01(\x04R\flastSequence\x122\n" +
+	"\x06oldest\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x06oldest\x122\n" +
+	"\x06newest\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x06newest\"M\n" +
+	"\x17RetentionReportResponse\x122\n" +
+	"\x05rooms\x18\x01 \x03(\v2\x1c.chat.v1.RoomRetentionReportR\x05rooms*k\n" +
+	"\x0fRestrictionKind\x12 \n" +
+	"\x1cRESTRICTION_KIND_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15RESTRICTION_KIND_MUTE\x10\x01\x12\x1b\n" +
+	"\x17RESTRICTION_KIND_SHADOW\x10\x02*X\n" +
+	"\tEraseMode\x12\x1a\n" +
+	"\x16ERASE_MODE_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14ERASE_MODE_ANONYMIZE\x10\x01\x12\x15\n" +
+	"\x11ERASE_MODE_DELETE\x10\x022\x8d\b\n" +
+	"\fAdminService\x12T\n" +
+	"\x0fGetRoomSettings\x12\x1f.chat.v1.GetRoomSettingsRequest\x1a .chat.v1.GetRoomSettingsResponse\x12]\n" +
+	"\x12UpdateRoomSettings\x12\".chat.v1.UpdateRoomSettingsRequest\x1a#.chat.v1.UpdateRoomSettingsResponse\x12`\n" +
+	"\x13ListFlaggedMessages\x12#.chat.v1.ListFlaggedMessagesRequest\x1a$.chat.v1.ListFlaggedMessagesResponse\x12c\n" +
+	"\x14ListUserRestrictions\x12$.chat.v1.ListUserRestrictionsRequest\x1a%.chat.v1.ListUserRestrictionsResponse\x12`\n" +
+	"\x13LiftUserRestriction\x12#.chat.v1.LiftUserRestrictionRequest\x1a$.chat.v1.LiftUserRestrictionResponse\x12T\n" +
+	"\x0fListAuditEvents\x12\x1f.chat.v1.ListAuditEventsRequest\x1a .chat.v1.ListAuditEventsResponse\x12W\n" +
+	"\x10ResetSigningKeys\x12 .chat.v1.ResetSigningKeysRequest\x1a!.chat.v1.ResetSigningKeysResponse\x12K\n" +
+	"\x0eExportUserData\x12\x1e.chat.v1.ExportUserDataRequest\x1a\x17.chat.v1.UserDataRecord0\x01\x12B\n" +
+	"\tEraseUser\x12\x19.chat.v1.EraseUserRequest\x1a\x1a.chat.v1.EraseUserResponse\x12@\n" +
+	"\n" +
+	"ExportRoom\x12\x1a.chat.v1.ExportRoomRequest\x1a\x14.chat.v1.ChatMessage0\x01\x12G\n" +
+	"\n" +
+	"ImportRoom\x12\x1a.chat.v1.ImportRoomRequest\x1a\x1b.chat.v1.ImportRoomResponse(\x01\x12T\n" +
+	"\x0fRetentionReport\x12\x1f.chat.v1.RetentionReportRequest\x1a .chat.v1.RetentionReportResponseB\x1bZ\x19gen/go/chat/chatv1;chatv1b\x06proto3"
+
+var (
+	file_admin_proto_rawDescOnce sync.Once
+	file_admin_proto_rawDescData []byte
+)
+
+func file_admin_proto_rawDescGZIP() []byte {
+	file_admin_proto_rawDescOnce.Do(func() {
+		file_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)))
+	})
+	return file_admin_proto_rawDescData
+}
+
+var file_admin_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
+var file_admin_proto_goTypes = []any{
+	(RestrictionKind)(0),                 // 0: chat.v1.RestrictionKind
+	(EraseMode)(0),                       // 1: chat.v1.EraseMode
+	(*GetRoomSettingsRequest)(nil),       // 2: chat.v1.GetRoomSettingsRequest
+	(*GetRoomSettingsResponse)(nil),      // 3: chat.v1.GetRoomSettingsResponse
+	(*UpdateRoomSettingsRequest)(nil),    // 4: chat.v1.UpdateRoomSettingsRequest
+	(*UpdateRoomSettingsResponse)(nil),   // 5: chat.v1.UpdateRoomSettingsResponse
+	(*ModerationFlag)(nil),               // 6: chat.v1.ModerationFlag
+	(*FlaggedMessage)(nil),               // 7: chat.v1.FlaggedMessage
+	(*ListFlaggedMessagesRequest)(nil),   // 8: chat.v1.ListFlaggedMessagesRequest
+	(*ListFlaggedMessagesResponse)(nil),  // 9: chat.v1.ListFlaggedMessagesResponse
+	(*UserRestriction)(nil),              // 10: chat.v1.UserRestriction
+	(*ListUserRestrictionsRequest)(nil),  // 11: chat.v1.ListUserRestrictionsRequest
+	(*ListUserRestrictionsResponse)(nil), // 12: chat.v1.ListUserRestrictionsResponse
+	(*LiftUserRestrictionRequest)(nil),   // 13: chat.v1.LiftUserRestrictionRequest
+	(*LiftUserRestrictionResponse)(nil),  // 14: chat.v1.LiftUserRestrictionResponse
+	(*AuditEvent)(nil),                   // 15: chat.v1.AuditEvent
+	(*AuditChain)(nil),                   // 16: chat.v1.AuditChain
+	(*ListAuditEventsRequest)(nil),       // 17: chat.v1.ListAuditEventsRequest
+	(*ListAuditEventsResponse)(nil),      // 18: chat.v1.ListAuditEventsResponse
+	(*ResetSigningKeysRequest)(nil),      // 19: chat.v1.ResetSigningKeysRequest
+	(*ResetSigningKeysResponse)(nil),     // 20: chat.v1.ResetSigningKeysResponse
+	(*ExportUserDataRequest)(nil),        // 21: chat.v1.ExportUserDataRequest
+	(*RoomMembership)(nil),               // 22: chat.v1.RoomMembership
+	(*UserDataRecord)(nil),               // 23: chat.v1.UserDataRecord
+	(*EraseUserRequest)(nil),             // 24: chat.v1.EraseUserRequest
+	(*EraseUserResponse)(nil),            // 25: chat.v1.EraseUserResponse
+	(*ExportRoomRequest)(nil),            // 26: chat.v1.ExportRoomRequest
+	(*ImportRoomRequest)(nil),            // 27: chat.v1.ImportRoomRequest
+	(*ImportRoomResponse)(nil),           // 28: chat.v1.ImportRoomResponse
+	(*RetentionReportRequest)(nil),       // 29: chat.v1.RetentionReportRequest
+	(*RoomRetentionReport)(nil),          // 30: chat.v1.RoomRetentionReport
+	(*RetentionReportResponse)(nil),      // 31: chat.v1.RetentionReportResponse
+	nil,                                  // 32: chat.v1.AuditEvent.DetailsEntry
+	(*RoomSettings)(nil),                 // 33: chat.v1.RoomSettings
+	(*ChatMessage)(nil),                  // 34: chat.v1.ChatMessage
+	(*timestamppb.Timestamp)(nil),        // 35: google.protobuf.Timestamp
+	(*SigningKey)(nil),                   // 36: chat.v1.SigningKey
+	(*RetentionPolicy)(nil),              // 37: chat.v1.RetentionPolicy
+}
+var file_admin_proto_depIdxs = []int32{
+	33, // 0: chat.v1.GetRoomSettingsResponse.settings:type_name -> chat.v1.RoomSettings
+	33, // 1: chat.v1.UpdateRoomSettingsRequest.settings:type_name -> chat.v1.RoomSettings
+	33, // 2: chat.v1.UpdateRoomSettingsResponse.settings:type_name -> chat.v1.RoomSettings
+	34, // 3: chat.v1.FlaggedMessage.message:type_name -> chat.v1.ChatMessage
+	6,  // 4: chat.v1.FlaggedMessage.flags:type_name -> chat.v1.ModerationFlag
+	35, // 5: chat.v1.FlaggedMessage.flagged_at:type_name -> google.protobuf.Timestamp
+	7,  // 6: chat.v1.ListFlaggedMessagesResponse.messages:type_name -> chat.v1.FlaggedMessage
+	0,  // 7: chat.v1.UserRestriction.kind:type_name -> chat.v1.RestrictionKind
+	35, // 8: chat.v1.UserRestriction.created_at:type_name -> google.protobuf.Timestamp
+	35, // 9: chat.v1.UserRestriction.expires_at:type_name -> google.protobuf.Timestamp
+	35, // 10: chat.v1.UserRestriction.lifted_at:type_name -> google.protobuf.Timestamp
+	10, // 11: chat.v1.ListUserRestrictionsResponse.restrictions:type_name -> chat.v1.UserRestriction
+	10, // 12: chat.v1.LiftUserRestrictionResponse.restriction:type_name -> chat.v1.UserRestriction
+	35, // 13: chat.v1.AuditEvent.time:type_name -> google.protobuf.Timestamp
+	32, // 14: chat.v1.AuditEvent.details:type_name -> chat.v1.AuditEvent.DetailsEntry
+	35, // 15: chat.v1.ListAuditEventsRequest.since:type_name -> google.protobuf.Timestamp
+	35, // 16: chat.v1.ListAuditEventsRequest.until:type_name -> google.protobuf.Timestamp
+	15, // 17: chat.v1.ListAuditEventsResponse.events:type_name -> chat.v1.AuditEvent
+	16, // 18: chat.v1.ListAuditEventsResponse.chains:type_name -> chat.v1.AuditChain
+	36, // 19: chat.v1.ResetSigningKeysResponse.revoked:type_name -> chat.v1.SigningKey
+	35, // 20: chat.v1.RoomMembership.first_message_at:type_name -> google.protobuf.Timestamp
+	35, // 21: chat.v1.RoomMembership.last_message_at:type_name -> google.protobuf.Timestamp
+	34, // 22: chat.v1.UserDataRecord.message:type_name -> chat.v1.ChatMessage
+	22, // 23: chat.v1.UserDataRecord.membership:type_name -> chat.v1.RoomMembership
+	36, // 24: chat.v1.UserDataRecord.signing_key:type_name -> chat.v1.SigningKey
+	10, // 25: chat.v1.UserDataRecord.restriction:type_name -> chat.v1.UserRestriction
+	1,  // 26: chat.v1.EraseUserRequest.mode:type_name -> chat.v1.EraseMode
+	34, // 27: chat.v1.ImportRoomRequest.messages:type_name -> chat.v1.ChatMessage
+	37, // 28: chat.v1.RoomRetentionReport.policy:type_name -> chat.v1.RetentionPolicy
+	35, // 29: chat.v1.RoomRetentionReport.oldest:type_name -> google.protobuf.Timestamp
+	35, // 30: chat.v1.RoomRetentionReport.newest:type_name -> google.protobuf.Timestamp
+	30, // 31: chat.v1.RetentionReportResponse.rooms:type_name -> chat.v1.RoomRetentionReport
+	2,  // 32: chat.v1.AdminService.GetRoomSettings:input_type -> chat.v1.GetRoomSettingsRequest
+	4,  // 33: chat.v1.AdminService.UpdateRoomSettings:input_type -> chat.v1.UpdateRoomSettingsRequest
+	8,  // 34: chat.v1.AdminService.ListFlaggedMessages:input_type -> chat.v1.ListFlaggedMessagesRequest
+	11, // 35: chat.v1.AdminService.ListUserRestrictions:input_type -> chat.v1.ListUserRestrictionsRequest
+	13, // 36: chat.v1.AdminService.LiftUserRestriction:input_type -> chat.v1.LiftUserRestrictionRequest
+	17, // 37: chat.v1.AdminService.ListAuditEvents:input_type -> chat.v1.ListAuditEventsRequest
+	19, // 38: chat.v1.AdminService.ResetSigningKeys:input_type -> chat.v1.ResetSigningKeysRequest
+	21, // 39: chat.v1.AdminService.ExportUserData:input_type -> chat.v1.ExportUserDataRequest
+	24, // 40: chat.v1.AdminService.EraseUser:input_type -> chat.v1.EraseUserRequest
+	26, // 41: chat.v1.AdminService.ExportRoom:input_type -> chat.v1.ExportRoomRequest
+	27, // 42: chat.v1.AdminService.ImportRoom:input_type -> chat.v1.ImportRoomRequest
+	29, // 43: chat.v1.AdminService.RetentionReport:input_type -> chat.v1.RetentionReportRequest
+	3,  // 44: chat.v1.AdminService.GetRoomSettings:output_type -> chat.v1.GetRoomSettingsResponse
+	5,  // 45: chat.v1.AdminService.UpdateRoomSettings:output_type -> chat.v1.UpdateRoomSettingsResponse
+	9,  // 46: chat.v1.AdminService.ListFlaggedMessages:output_type -> chat.v1.ListFlaggedMessagesResponse
+	12, // 47: chat.v1.AdminService.ListUserRestrictions:output_type -> chat.v1.ListUserRestrictionsResponse
+	14, // 48: chat.v1.AdminService.LiftUserRestriction:output_type -> chat.v1.LiftUserRestrictionResponse
+	18, // 49: chat.v1.AdminService.ListAuditEvents:output_type -> chat.v1.ListAuditEventsResponse
+	20, // 50: chat.v1.AdminService.ResetSigningKeys:output_type -> chat.v1.ResetSigningKeysResponse
+	23, // 51: chat.v1.AdminService.ExportUserData:output_type -> chat.v1.UserDataRecord
+	25, // 52: chat.v1.AdminService.EraseUser:output_type -> chat.v1.EraseUserResponse
+	34, // 53: chat.v1.AdminService.ExportRoom:output_type -> chat.v1.ChatMessage
+	28, // 54: chat.v1.AdminService.ImportRoom:output_type -> chat.v1.ImportRoomResponse
+	31, // 55: chat.v1.AdminService.RetentionReport:output_type -> chat.v1.RetentionReportResponse
+	44, // [44:56] is the sub-list for method output_type
+	32, // [32:44] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
+}
+
+func init() { file_admin_proto_init() }
+func file_admin_proto_init() {
+	if File_admin_proto != nil {
+		return
+	}
+	file_chat_proto_init()
+	file_keys_proto_init()
+	file_admin_proto_msgTypes[21].OneofWrappers = []any{
+		(*UserDataRecord_Message)(nil),
+		(*UserDataRecord_Membership)(nil),
+		(*UserDataRecord_SigningKey)(nil),
+		(*UserDataRecord_Restriction)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   31,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_admin_proto_goTypes,
+		DependencyIndexes: file_admin_proto_depIdxs,
+		EnumInfos:         file_admin_proto_enumTypes,
+		MessageInfos:      file_admin_proto_msgTypes,
+	}.Build()
+	File_admin_proto = out.File
+	file_admin_proto_goTypes = nil
+	file_admin_proto_depIdxs = nil
+}
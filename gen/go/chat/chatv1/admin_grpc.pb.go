@@ -0,0 +1,579 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.33.1
+// source: admin.proto
+
+package chatv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AdminService_GetRoomSettings_FullMethodName      = "/chat.v1.AdminService/GetRoomSettings"
+	AdminService_UpdateRoomSettings_FullMethodName   = "/chat.v1.AdminService/UpdateRoomSettings"
+	AdminService_ListFlaggedMessages_FullMethodName  = "/chat.v1.AdminService/ListFlaggedMessages"
+	AdminService_ListUserRestrictions_FullMethodName = "/chat.v1.AdminService/ListUserRestrictions"
+	AdminService_LiftUserRestriction_FullMethodName  = "/chat.v1.AdminService/LiftUserRestriction"
+	AdminService_ListAuditEvents_FullMethodName      = "/chat.v1.AdminService/ListAuditEvents"
+	AdminService_ResetSigningKeys_FullMethodName     = "/chat.v1.AdminService/ResetSigningKeys"
+	AdminService_ExportUserData_FullMethodName       = "/chat.v1.AdminService/ExportUserData"
+	AdminService_EraseUser_FullMethodName            = "/chat.v1.AdminService/EraseUser"
+	AdminService_ExportRoom_FullMethodName           = "/chat.v1.AdminService/ExportRoom"
+	AdminService_ImportRoom_FullMethodName           = "/chat.v1.AdminService/ImportRoom"
+	AdminService_RetentionReport_FullMethodName      = "/chat.v1.AdminService/RetentionReport"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminServiceClient interface {
+	GetRoomSettings(ctx context.Context, in *GetRoomSettingsRequest, opts ...grpc.CallOption) (*GetRoomSettingsResponse, error)
+	UpdateRoomSettings(ctx context.Context, in *UpdateRoomSettingsRequest, opts ...grpc.CallOption) (*UpdateRoomSettingsResponse, error)
+	// ListFlaggedMessages is the moderation review queue.
+	ListFlaggedMessages(ctx context.Context, in *ListFlaggedMessagesRequest, opts ...grpc.CallOption) (*ListFlaggedMessagesResponse, error)
+	// ListUserRestrictions lists the mutes and shadow restrictions spam
+	// detection applied.
+	ListUserRestrictions(ctx context.Context, in *ListUserRestrictionsRequest, opts ...grpc.CallOption) (*ListUserRestrictionsResponse, error)
+	// LiftUserRestriction ends a user's restriction early and clears their
+	// score.
+	LiftUserRestriction(ctx context.Context, in *LiftUserRestrictionRequest, opts ...grpc.CallOption) (*LiftUserRestrictionResponse, error)
+	// ListAuditEvents reads the audit logs of every node and checks their
+	// hash chains.
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
+	// ResetSigningKeys revokes every signing key of a user who lost theirs,
+	// so they can register a new one without an endorsement.
+	ResetSigningKeys(ctx context.Context, in *ResetSigningKeysRequest, opts ...grpc.CallOption) (*ResetSigningKeysResponse, error)
+	// ExportUserData streams everything the cluster holds about a user:
+	// their messages, the rooms they posted in, their signing keys and
+	// their spam restrictions.
+	ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserDataRecord], error)
+	// EraseUser anonymizes or deletes a user's messages in every room,
+	// keeping their place in the history, and forgets the user's keys and
+	// spam record.
+	EraseUser(ctx context.Context, in *EraseUserRequest, opts ...grpc.CallOption) (*EraseUserResponse, error)
+	// ExportRoom streams a room's whole history, oldest first.
+	ExportRoom(ctx context.Context, in *ExportRoomRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatMessage], error)
+	// ImportRoom stores history from an export or another chat tool ahead
+	// of what the room already holds, without broadcasting it.
+	ImportRoom(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportRoomRequest, ImportRoomResponse], error)
+	// RetentionReport is a dry run of retention: what compaction would
+	// remove now, without removing it.
+	RetentionReport(ctx context.Context, in *RetentionReportRequest, opts ...grpc.CallOption) (*RetentionReportResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) GetRoomSettings(ctx context.Context, in *GetRoomSettingsRequest, opts ...grpc.CallOption) (*GetRoomSettingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRoomSettingsResponse)
+	err := c.cc.Invoke(ctx, AdminService_GetRoomSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) UpdateRoomSettings(ctx context.Context, in *UpdateRoomSettingsRequest, opts ...grpc.CallOption) (*UpdateRoomSettingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateRoomSettingsResponse)
+	err := c.cc.Invoke(ctx, AdminService_UpdateRoomSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListFlaggedMessages(ctx context.Context, in *ListFlaggedMessagesRequest, opts ...grpc.CallOption) (*ListFlaggedMessagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFlaggedMessagesResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListFlaggedMessages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListUserRestrictions(ctx context.Context, in *ListUserRestrictionsRequest, opts ...grpc.CallOption) (*ListUserRestrictionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListUserRestrictionsResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListUserRestrictions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) LiftUserRestriction(ctx context.Context, in *LiftUserRestrictionRequest, opts ...grpc.CallOption) (*LiftUserRestrictionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LiftUserRestrictionResponse)
+	err := c.cc.Invoke(ctx, AdminService_LiftUserRestriction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListAuditEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ResetSigningKeys(ctx context.Context, in *ResetSigningKeysRequest, opts ...grpc.CallOption) (*ResetSigningKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetSigningKeysResponse)
+	err := c.cc.Invoke(ctx, AdminService_ResetSigningKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserDataRecord], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[0], AdminService_ExportUserData_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportUserDataRequest, UserDataRecord]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportUserDataClient = grpc.ServerStreamingClient[UserDataRecord]
+
+func (c *adminServiceClient) EraseUser(ctx context.Context, in *EraseUserRequest, opts ...grpc.CallOption) (*EraseUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EraseUserResponse)
+	err := c.cc.Invoke(ctx, AdminService_EraseUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ExportRoom(ctx context.Context, in *ExportRoomRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[1], AdminService_ExportRoom_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportRoomRequest, ChatMessage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportRoomClient = grpc.ServerStreamingClient[ChatMessage]
+
+func (c *adminServiceClient) ImportRoom(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportRoomRequest, ImportRoomResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[2], AdminService_ImportRoom_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportRoomRequest, ImportRoomResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ImportRoomClient = grpc.ClientStreamingClient[ImportRoomRequest, ImportRoomResponse]
+
+func (c *adminServiceClient) RetentionReport(ctx context.Context, in *RetentionReportRequest, opts ...grpc.CallOption) (*RetentionReportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RetentionReportResponse)
+	err := c.cc.Invoke(ctx, AdminService_RetentionReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+type AdminServiceServer interface {
+	GetRoomSettings(context.Context, *GetRoomSettingsRequest) (*GetRoomSettingsResponse, error)
+	UpdateRoomSettings(context.Context, *UpdateRoomSettingsRequest) (*UpdateRoomSettingsResponse, error)
+	// ListFlaggedMessages is the moderation review queue.
+	ListFlaggedMessages(context.Context, *ListFlaggedMessagesRequest) (*ListFlaggedMessagesResponse, error)
+	// ListUserRestrictions lists the mutes and shadow restrictions spam
+	// detection applied.
+	ListUserRestrictions(context.Context, *ListUserRestrictionsRequest) (*ListUserRestrictionsResponse, error)
+	// LiftUserRestriction ends a user's restriction early and clears their
+	// score.
+	LiftUserRestriction(context.Context, *LiftUserRestrictionRequest) (*LiftUserRestrictionResponse, error)
+	// ListAuditEvents reads the audit logs of every node and checks their
+	// hash chains.
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+	// ResetSigningKeys revokes every signing key of a user who lost theirs,
+	// so they can register a new one without an endorsement.
+	ResetSigningKeys(context.Context, *ResetSigningKeysRequest) (*ResetSigningKeysResponse, error)
+	// ExportUserData streams everything the cluster holds about a user:
+	// their messages, the rooms they posted in, their signing keys and
+	// their spam restrictions.
+	ExportUserData(*ExportUserDataRequest, grpc.ServerStreamingServer[UserDataRecord]) error
+	// EraseUser anonymizes or deletes a user's messages in every room,
+	// keeping their place in the history, and forgets the user's keys and
+	// spam record.
+	EraseUser(context.Context, *EraseUserRequest) (*EraseUserResponse, error)
+	// ExportRoom streams a room's whole history, oldest first.
+	ExportRoom(*ExportRoomRequest, grpc.ServerStreamingServer[ChatMessage]) error
+	// ImportRoom stores history from an export or another chat tool ahead
+	// of what the room already holds, without broadcasting it.
+	ImportRoom(grpc.ClientStreamingServer[ImportRoomRequest, ImportRoomResponse]) error
+	// RetentionReport is a dry run of retention: what compaction would
+	// remove now, without removing it.
+	RetentionReport(context.Context, *RetentionReportRequest) (*RetentionReportResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) GetRoomSettings(context.Context, *GetRoomSettingsRequest) (*GetRoomSettingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRoomSettings not implemented")
+}
+func (UnimplementedAdminServiceServer) UpdateRoomSettings(context.Context, *UpdateRoomSettingsRequest) (*UpdateRoomSettingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRoomSettings not implemented")
+}
+func (UnimplementedAdminServiceServer) ListFlaggedMessages(context.Context, *ListFlaggedMessagesRequest) (*ListFlaggedMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFlaggedMessages not implemented")
+}
+func (UnimplementedAdminServiceServer) ListUserRestrictions(context.Context, *ListUserRestrictionsRequest) (*ListUserRestrictionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUserRestrictions not implemented")
+}
+func (UnimplementedAdminServiceServer) LiftUserRestriction(context.Context, *LiftUserRestrictionRequest) (*LiftUserRestrictionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LiftUserRestriction not implemented")
+}
+func (UnimplementedAdminServiceServer) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+func (UnimplementedAdminServiceServer) ResetSigningKeys(context.Context, *ResetSigningKeysRequest) (*ResetSigningKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetSigningKeys not implemented")
+}
+func (UnimplementedAdminServiceServer) ExportUserData(*ExportUserDataRequest, grpc.ServerStreamingServer[UserDataRecord]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportUserData not implemented")
+}
+func (UnimplementedAdminServiceServer) EraseUser(context.Context, *EraseUserRequest) (*EraseUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseUser not implemented")
+}
+func (UnimplementedAdminServiceServer) ExportRoom(*ExportRoomRequest, grpc.ServerStreamingServer[ChatMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportRoom not implemented")
+}
+func (UnimplementedAdminServiceServer) ImportRoom(grpc.ClientStreamingServer[ImportRoomRequest, ImportRoomResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportRoom not implemented")
+}
+func (UnimplementedAdminServiceServer) RetentionReport(context.Context, *RetentionReportRequest) (*RetentionReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetentionReport not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_GetRoomSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRoomSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetRoomSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetRoomSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetRoomSettings(ctx, req.(*GetRoomSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_UpdateRoomSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRoomSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).UpdateRoomSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_UpdateRoomSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).UpdateRoomSettings(ctx, req.(*UpdateRoomSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListFlaggedMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFlaggedMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListFlaggedMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListFlaggedMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListFlaggedMessages(ctx, req.(*ListFlaggedMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListUserRestrictions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUserRestrictionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListUserRestrictions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListUserRestrictions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListUserRestrictions(ctx, req.(*ListUserRestrictionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_LiftUserRestriction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LiftUserRestrictionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).LiftUserRestriction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_LiftUserRestriction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).LiftUserRestriction(ctx, req.(*LiftUserRestrictionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListAuditEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ResetSigningKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetSigningKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ResetSigningKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ResetSigningKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ResetSigningKeys(ctx, req.(*ResetSigningKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ExportUserData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportUserDataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).ExportUserData(m, &grpc.GenericServerStream[ExportUserDataRequest, UserDataRecord]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportUserDataServer = grpc.ServerStreamingServer[UserDataRecord]
+
+func _AdminService_EraseUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EraseUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).EraseUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_EraseUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).EraseUser(ctx, req.(*EraseUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ExportRoom_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRoomRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).ExportRoom(m, &grpc.GenericServerStream[ExportRoomRequest, ChatMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportRoomServer = grpc.ServerStreamingServer[ChatMessage]
+
+func _AdminService_ImportRoom_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServiceServer).ImportRoom(&grpc.GenericServerStream[ImportRoomRequest, ImportRoomResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ImportRoomServer = grpc.ClientStreamingServer[ImportRoomRequest, ImportRoomResponse]
+
+func _AdminService_RetentionReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetentionReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RetentionReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RetentionReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RetentionReport(ctx, req.(*RetentionReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.v1.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRoomSettings",
+			Handler:    _AdminService_GetRoomSettings_Handler,
+		},
+		{
+			MethodName: "UpdateRoomSettings",
+			Handler:    _AdminService_UpdateRoomSettings_Handler,
+		},
+		{
+			MethodName: "ListFlaggedMessages",
+			Handler:    _AdminService_ListFlaggedMessages_Handler,
+		},
+		{
+			MethodName: "ListUserRestrictions",
+			Handler:    _AdminService_ListUserRestrictions_Handler,
+		},
+		{
+			MethodName: "LiftUserRestriction",
+			Handler:    _AdminService_LiftUserRestriction_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _AdminService_ListAuditEvents_Handler,
+		},
+		{
+			MethodName: "ResetSigningKeys",
+			Handler:    _AdminService_ResetSigningKeys_Handler,
+		},
+		{
+			MethodName: "EraseUser",
+			Handler:    _AdminService_EraseUser_Handler,
+		},
+		{
+			MethodName: "RetentionReport",
+			Handler:    _AdminService_RetentionReport_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportUserData",
+			Handler:       _AdminService_ExportUserData_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportRoom",
+			Handler:       _AdminService_ExportRoom_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportRoom",
+			Handler:       _AdminService_ImportRoom_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "admin.proto",
+}
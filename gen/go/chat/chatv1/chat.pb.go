@@ -9,6 +9,7 @@ package chatv1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -83,6 +84,9 @@ const (
 	ControlAction_CONTROL_ACTION_UNSPECIFIED  ControlAction = 0
 	ControlAction_CONTROL_ACTION_START_STREAM ControlAction = 1 // request server to begin streaming
 	ControlAction_CONTROL_ACTION_STOP_STREAM  ControlAction = 2 // optional: unsubscribe
+	ControlAction_CONTROL_ACTION_GOING_AWAY   ControlAction = 3 // server is shutting down: reconnect elsewhere
+	ControlAction_CONTROL_ACTION_RATE_LIMITED ControlAction = 4 // the client's last event was dropped: retry after retry_after
+	ControlAction_CONTROL_ACTION_REJECTED     ControlAction = 5 // the client's last message was refused by moderation or its sender is muted, for reason
 )
 
 // Enum value maps for ControlAction.
@@ -91,11 +95,17 @@ var (
 		0: "CONTROL_ACTION_UNSPECIFIED",
 		1: "CONTROL_ACTION_START_STREAM",
 		2: "CONTROL_ACTION_STOP_STREAM",
+		3: "CONTROL_ACTION_GOING_AWAY",
+		4: "CONTROL_ACTION_RATE_LIMITED",
+		5: "CONTROL_ACTION_REJECTED",
 	}
 	ControlAction_value = map[string]int32{
 		"CONTROL_ACTION_UNSPECIFIED":  0,
 		"CONTROL_ACTION_START_STREAM": 1,
 		"CONTROL_ACTION_STOP_STREAM":  2,
+		"CONTROL_ACTION_GOING_AWAY":   3,
+		"CONTROL_ACTION_RATE_LIMITED": 4,
+		"CONTROL_ACTION_REJECTED":     5,
 	}
 )
 
@@ -127,12 +137,32 @@ func (ControlAction) EnumDescriptor() ([]byte, []int) {
 }
 
 type ChatMessage struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	RoomId        string                 `protobuf:"bytes,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
-	SenderId      string                 `protobuf:"bytes,3,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
-	Text          string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	RoomId    string                 `protobuf:"bytes,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	SenderId  string                 `protobuf:"bytes,3,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	Text      string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Sequence  uint64                 `protobuf:"varint,6,opt,name=sequence,proto3" json:"sequence,omitempty"` // per-room order, assigned by the room's owner node
+	// encrypted replaces text in end-to-end encrypted rooms.
+	Encrypted *EncryptedPayload `protobuf:"bytes,7,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	// signature is an optional Ed25519 signature over the message's
+	// canonical form (see package msgsign) by one of the sender's
+	// registered signing keys.
+	Signature      []byte `protobuf:"bytes,8,opt,name=signature,proto3" json:"signature,omitempty"`
+	SignatureKeyId string `protobuf:"bytes,9,opt,name=signature_key_id,json=signatureKeyId,proto3" json:"signature_key_id,omitempty"`
+	// verified is set by the server when it checked signature against the
+	// sender's registered key; clients can't set it.
+	Verified bool `protobuf:"varint,10,opt,name=verified,proto3" json:"verified,omitempty"`
+	// erased is set once the sender was erased: sender_id is a pseudonym
+	// and, when the content was deleted too, text and encrypted are empty.
+	Erased bool `protobuf:"varint,11,opt,name=erased,proto3" json:"erased,omitempty"`
+	// thread_id is the id of the message that started the thread this one
+	// replies in; empty outside threads.
+	ThreadId string `protobuf:"bytes,12,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	// tombstone marks a message retention removed: only id, room_id,
+	// sequence, created_at and thread_id remain.
+	Tombstone     bool `protobuf:"varint,13,opt,name=tombstone,proto3" json:"tombstone,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -202,6 +232,132 @@ func (x *ChatMessage) GetCreatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *ChatMessage) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetEncrypted() *EncryptedPayload {
+	if x != nil {
+		return x.Encrypted
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetSignatureKeyId() string {
+	if x != nil {
+		return x.SignatureKeyId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetVerified() bool {
+	if x != nil {
+		return x.Verified
+	}
+	return false
+}
+
+func (x *ChatMessage) GetErased() bool {
+	if x != nil {
+		return x.Erased
+	}
+	return false
+}
+
+func (x *ChatMessage) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetTombstone() bool {
+	if x != nil {
+		return x.Tombstone
+	}
+	return false
+}
+
+// EncryptedPayload is a message encrypted with its sender's sender key for
+// the room. Only members holding that key can read it; see keys.proto.
+type EncryptedPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ciphertext    []byte                 `protobuf:"bytes,1,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	KeyId         string                 `protobuf:"bytes,2,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"` // the sender key used
+	Iteration     uint32                 `protobuf:"varint,3,opt,name=iteration,proto3" json:"iteration,omitempty"`     // position in the sender key's chain
+	Signature     []byte                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`      // Ed25519 by the sender key's signing key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EncryptedPayload) Reset() {
+	*x = EncryptedPayload{}
+	mi := &file_chat_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EncryptedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncryptedPayload) ProtoMessage() {}
+
+func (x *EncryptedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncryptedPayload.ProtoReflect.Descriptor instead.
+func (*EncryptedPayload) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EncryptedPayload) GetCiphertext() []byte {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return nil
+}
+
+func (x *EncryptedPayload) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *EncryptedPayload) GetIteration() uint32 {
+	if x != nil {
+		return x.Iteration
+	}
+	return 0
+}
+
+func (x *EncryptedPayload) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
 type TypingEvent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
@@ -213,7 +369,7 @@ type TypingEvent struct {
 
 func (x *TypingEvent) Reset() {
 	*x = TypingEvent{}
-	mi := &file_chat_proto_msgTypes[1]
+	mi := &file_chat_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -225,7 +381,7 @@ func (x *TypingEvent) String() string {
 func (*TypingEvent) ProtoMessage() {}
 
 func (x *TypingEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[1]
+	mi := &file_chat_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -238,7 +394,7 @@ func (x *TypingEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TypingEvent.ProtoReflect.Descriptor instead.
 func (*TypingEvent) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{1}
+	return file_chat_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *TypingEvent) GetRoomId() string {
@@ -272,7 +428,7 @@ type PresenceEvent struct {
 
 func (x *PresenceEvent) Reset() {
 	*x = PresenceEvent{}
-	mi := &file_chat_proto_msgTypes[2]
+	mi := &file_chat_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -284,7 +440,7 @@ func (x *PresenceEvent) String() string {
 func (*PresenceEvent) ProtoMessage() {}
 
 func (x *PresenceEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[2]
+	mi := &file_chat_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -297,7 +453,7 @@ func (x *PresenceEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PresenceEvent.ProtoReflect.Descriptor instead.
 func (*PresenceEvent) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{2}
+	return file_chat_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *PresenceEvent) GetUserId() string {
@@ -323,14 +479,18 @@ type StreamEvent struct {
 	//	*StreamEvent_Typing
 	//	*StreamEvent_Presence
 	//	*StreamEvent_Control
-	Payload       isStreamEvent_Payload `protobuf_oneof:"payload"`
+	Payload isStreamEvent_Payload `protobuf_oneof:"payload"`
+	// W3C trace context (traceparent, tracestate) of the hop that last
+	// handled the event, so one message can be followed from the browser
+	// through the gateway and the server to every recipient.
+	TraceContext  map[string]string `protobuf:"bytes,11,rep,name=trace_context,json=traceContext,proto3" json:"trace_context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StreamEvent) Reset() {
 	*x = StreamEvent{}
-	mi := &file_chat_proto_msgTypes[3]
+	mi := &file_chat_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -342,7 +502,7 @@ func (x *StreamEvent) String() string {
 func (*StreamEvent) ProtoMessage() {}
 
 func (x *StreamEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[3]
+	mi := &file_chat_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -355,7 +515,7 @@ func (x *StreamEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StreamEvent.ProtoReflect.Descriptor instead.
 func (*StreamEvent) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{3}
+	return file_chat_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *StreamEvent) GetType() EventType {
@@ -408,6 +568,13 @@ func (x *StreamEvent) GetControl() *ControlEvent {
 	return nil
 }
 
+func (x *StreamEvent) GetTraceContext() map[string]string {
+	if x != nil {
+		return x.TraceContext
+	}
+	return nil
+}
+
 type isStreamEvent_Payload interface {
 	isStreamEvent_Payload()
 }
@@ -440,13 +607,15 @@ type ControlEvent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Action        ControlAction          `protobuf:"varint,1,opt,name=action,proto3,enum=chat.v1.ControlAction" json:"action,omitempty"`
 	RoomId        string                 `protobuf:"bytes,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	RetryAfter    *durationpb.Duration   `protobuf:"bytes,4,opt,name=retry_after,json=retryAfter,proto3" json:"retry_after,omitempty"` // set with CONTROL_ACTION_RATE_LIMITED
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ControlEvent) Reset() {
 	*x = ControlEvent{}
-	mi := &file_chat_proto_msgTypes[4]
+	mi := &file_chat_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -458,7 +627,7 @@ func (x *ControlEvent) String() string {
 func (*ControlEvent) ProtoMessage() {}
 
 func (x *ControlEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[4]
+	mi := &file_chat_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -471,7 +640,7 @@ func (x *ControlEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ControlEvent.ProtoReflect.Descriptor instead.
 func (*ControlEvent) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{4}
+	return file_chat_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *ControlEvent) GetAction() ControlAction {
@@ -488,6 +657,158 @@ func (x *ControlEvent) GetRoomId() string {
 	return ""
 }
 
+func (x *ControlEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ControlEvent) GetRetryAfter() *durationpb.Duration {
+	if x != nil {
+		return x.RetryAfter
+	}
+	return nil
+}
+
+// RoomSettings are kept by a room's owner next to its history.
+type RoomSettings struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	RoomId string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	// slow_mode lets each member post once per this interval; unset or zero
+	// turns slow mode off.
+	SlowMode *durationpb.Duration `protobuf:"bytes,2,opt,name=slow_mode,json=slowMode,proto3" json:"slow_mode,omitempty"`
+	// e2e makes the room end-to-end encrypted: messages must carry encrypted
+	// instead of text.
+	E2E bool `protobuf:"varint,3,opt,name=e2e,proto3" json:"e2e,omitempty"`
+	// retention is how much history the room keeps; unset follows the
+	// server's server.retention defaults.
+	Retention *RetentionPolicy `protobuf:"bytes,4,opt,name=retention,proto3" json:"retention,omitempty"`
+	// legal_hold keeps all of the room's history whatever its retention.
+	LegalHold     bool `protobuf:"varint,5,opt,name=legal_hold,json=legalHold,proto3" json:"legal_hold,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomSettings) Reset() {
+	*x = RoomSettings{}
+	mi := &file_chat_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomSettings) ProtoMessage() {}
+
+func (x *RoomSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomSettings.ProtoReflect.Descriptor instead.
+func (*RoomSettings) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RoomSettings) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *RoomSettings) GetSlowMode() *durationpb.Duration {
+	if x != nil {
+		return x.SlowMode
+	}
+	return nil
+}
+
+func (x *RoomSettings) GetE2E() bool {
+	if x != nil {
+		return x.E2E
+	}
+	return false
+}
+
+func (x *RoomSettings) GetRetention() *RetentionPolicy {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+func (x *RoomSettings) GetLegalHold() bool {
+	if x != nil {
+		return x.LegalHold
+	}
+	return false
+}
+
+// RetentionPolicy limits a room's history. The room's owner removes
+// messages past either limit.
+type RetentionPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MaxAge        *durationpb.Duration   `protobuf:"bytes,1,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`                 // unset or zero keeps messages of any age
+	MaxMessages   uint64                 `protobuf:"varint,2,opt,name=max_messages,json=maxMessages,proto3" json:"max_messages,omitempty"` // zero keeps any number
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionPolicy) Reset() {
+	*x = RetentionPolicy{}
+	mi := &file_chat_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionPolicy) ProtoMessage() {}
+
+func (x *RetentionPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionPolicy.ProtoReflect.Descriptor instead.
+func (*RetentionPolicy) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RetentionPolicy) GetMaxAge() *durationpb.Duration {
+	if x != nil {
+		return x.MaxAge
+	}
+	return nil
+}
+
+func (x *RetentionPolicy) GetMaxMessages() uint64 {
+	if x != nil {
+		return x.MaxMessages
+	}
+	return 0
+}
+
 type SendMessageRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       *ChatMessage           `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"` // server should fill id/timestamp if absent
@@ -497,7 +818,7 @@ type SendMessageRequest struct {
 
 func (x *SendMessageRequest) Reset() {
 	*x = SendMessageRequest{}
-	mi := &file_chat_proto_msgTypes[5]
+	mi := &file_chat_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -509,7 +830,7 @@ func (x *SendMessageRequest) String() string {
 func (*SendMessageRequest) ProtoMessage() {}
 
 func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[5]
+	mi := &file_chat_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -522,7 +843,7 @@ func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
 func (*SendMessageRequest) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{5}
+	return file_chat_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *SendMessageRequest) GetMessage() *ChatMessage {
@@ -541,7 +862,7 @@ type SendmessageResponse struct {
 
 func (x *SendmessageResponse) Reset() {
 	*x = SendmessageResponse{}
-	mi := &file_chat_proto_msgTypes[6]
+	mi := &file_chat_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -553,7 +874,7 @@ func (x *SendmessageResponse) String() string {
 func (*SendmessageResponse) ProtoMessage() {}
 
 func (x *SendmessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[6]
+	mi := &file_chat_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -566,7 +887,7 @@ func (x *SendmessageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendmessageResponse.ProtoReflect.Descriptor instead.
 func (*SendmessageResponse) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{6}
+	return file_chat_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *SendmessageResponse) GetMessage() *ChatMessage {
@@ -577,16 +898,19 @@ func (x *SendmessageResponse) GetMessage() *ChatMessage {
 }
 
 type GetMessageRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
-	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	RoomId string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Limit  int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// before_sequence pages back through history: only messages with a
+	// lower sequence are returned. Zero reads from the newest.
+	BeforeSequence uint64 `protobuf:"varint,3,opt,name=before_sequence,json=beforeSequence,proto3" json:"before_sequence,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *GetMessageRequest) Reset() {
 	*x = GetMessageRequest{}
-	mi := &file_chat_proto_msgTypes[7]
+	mi := &file_chat_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -598,7 +922,7 @@ func (x *GetMessageRequest) String() string {
 func (*GetMessageRequest) ProtoMessage() {}
 
 func (x *GetMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[7]
+	mi := &file_chat_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -611,7 +935,7 @@ func (x *GetMessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMessageRequest.ProtoReflect.Descriptor instead.
 func (*GetMessageRequest) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{7}
+	return file_chat_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *GetMessageRequest) GetRoomId() string {
@@ -628,6 +952,13 @@ func (x *GetMessageRequest) GetLimit() int32 {
 	return 0
 }
 
+func (x *GetMessageRequest) GetBeforeSequence() uint64 {
+	if x != nil {
+		return x.BeforeSequence
+	}
+	return 0
+}
+
 type GetmessagesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       []*ChatMessage         `protobuf:"bytes,1,rep,name=message,proto3" json:"message,omitempty"`
@@ -637,7 +968,7 @@ type GetmessagesResponse struct {
 
 func (x *GetmessagesResponse) Reset() {
 	*x = GetmessagesResponse{}
-	mi := &file_chat_proto_msgTypes[8]
+	mi := &file_chat_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -649,7 +980,7 @@ func (x *GetmessagesResponse) String() string {
 func (*GetmessagesResponse) ProtoMessage() {}
 
 func (x *GetmessagesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[8]
+	mi := &file_chat_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -662,7 +993,7 @@ func (x *GetmessagesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetmessagesResponse.ProtoReflect.Descriptor instead.
 func (*GetmessagesResponse) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{8}
+	return file_chat_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *GetmessagesResponse) GetMessage() []*ChatMessage {
@@ -672,6 +1003,159 @@ func (x *GetmessagesResponse) GetMessage() []*ChatMessage {
 	return nil
 }
 
+// SearchMessagesRequest searches message text for the calling user, in
+// the rooms they joined. Every filter given must match.
+type SearchMessagesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// query holds the words a message must all contain, in any case; a
+	// word ending in * matches any word it starts. Empty matches every
+	// message the filters let through.
+	Query     string   `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	RoomIds   []string `protobuf:"bytes,3,rep,name=room_ids,json=roomIds,proto3" json:"room_ids,omitempty"`
+	SenderIds []string `protobuf:"bytes,4,rep,name=sender_ids,json=senderIds,proto3" json:"sender_ids,omitempty"`
+	// since and until bound created_at: since included, until excluded.
+	Since *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=since,proto3" json:"since,omitempty"`
+	Until *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=until,proto3" json:"until,omitempty"`
+	Limit int32                  `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	// page_token continues from the next_page_token of a previous page.
+	PageToken     string `protobuf:"bytes,8,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchMessagesRequest) Reset() {
+	*x = SearchMessagesRequest{}
+	mi := &file_chat_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchMessagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchMessagesRequest) ProtoMessage() {}
+
+func (x *SearchMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchMessagesRequest.ProtoReflect.Descriptor instead.
+func (*SearchMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SearchMessagesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchMessagesRequest) GetRoomIds() []string {
+	if x != nil {
+		return x.RoomIds
+	}
+	return nil
+}
+
+func (x *SearchMessagesRequest) GetSenderIds() []string {
+	if x != nil {
+		return x.SenderIds
+	}
+	return nil
+}
+
+func (x *SearchMessagesRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *SearchMessagesRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *SearchMessagesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchMessagesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// SearchMessagesResponse lists matches newest first.
+type SearchMessagesResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Messages []*ChatMessage         `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	// next_page_token is empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchMessagesResponse) Reset() {
+	*x = SearchMessagesResponse{}
+	mi := &file_chat_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchMessagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchMessagesResponse) ProtoMessage() {}
+
+func (x *SearchMessagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchMessagesResponse.ProtoReflect.Descriptor instead.
+func (*SearchMessagesResponse) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SearchMessagesResponse) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *SearchMessagesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
 type StreamRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	RoomIds       []string               `protobuf:"bytes,1,rep,name=room_ids,json=roomIds,proto3" json:"room_ids,omitempty"`
@@ -681,7 +1165,7 @@ type StreamRequest struct {
 
 func (x *StreamRequest) Reset() {
 	*x = StreamRequest{}
-	mi := &file_chat_proto_msgTypes[9]
+	mi := &file_chat_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -693,7 +1177,7 @@ func (x *StreamRequest) String() string {
 func (*StreamRequest) ProtoMessage() {}
 
 func (x *StreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[9]
+	mi := &file_chat_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -706,7 +1190,7 @@ func (x *StreamRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
 func (*StreamRequest) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{9}
+	return file_chat_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *StreamRequest) GetRoomIds() []string {
@@ -716,61 +1200,283 @@ func (x *StreamRequest) GetRoomIds() []string {
 	return nil
 }
 
+// JoinRoomRequest makes the calling user a member of a room. Membership
+// outlives the user's streams and lets them search the room.
+type JoinRoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinRoomRequest) Reset() {
+	*x = JoinRoomRequest{}
+	mi := &file_chat_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinRoomRequest) ProtoMessage() {}
+
+func (x *JoinRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinRoomRequest.ProtoReflect.Descriptor instead.
+func (*JoinRoomRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *JoinRoomRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+type JoinRoomResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinRoomResponse) Reset() {
+	*x = JoinRoomResponse{}
+	mi := &file_chat_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinRoomResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinRoomResponse) ProtoMessage() {}
+
+func (x *JoinRoomResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinRoomResponse.ProtoReflect.Descriptor instead.
+func (*JoinRoomResponse) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{16}
+}
+
+// LeaveRoomRequest ends the calling user's membership of a room.
+type LeaveRoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveRoomRequest) Reset() {
+	*x = LeaveRoomRequest{}
+	mi := &file_chat_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveRoomRequest) ProtoMessage() {}
+
+func (x *LeaveRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveRoomRequest.ProtoReflect.Descriptor instead.
+func (*LeaveRoomRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *LeaveRoomRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+type LeaveRoomResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveRoomResponse) Reset() {
+	*x = LeaveRoomResponse{}
+	mi := &file_chat_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveRoomResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveRoomResponse) ProtoMessage() {}
+
+func (x *LeaveRoomResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveRoomResponse.ProtoReflect.Descriptor instead.
+func (*LeaveRoomResponse) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{18}
+}
+
 var File_chat_proto protoreflect.FileDescriptor
 
 const file_chat_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"chat.proto\x12\achat.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa2\x01\n" +
+	"chat.proto\x12\achat.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xae\x03\n" +
 	"\vChatMessage\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
 	"\aroom_id\x18\x02 \x01(\tR\x06roomId\x12\x1b\n" +
 	"\tsender_id\x18\x03 \x01(\tR\bsenderId\x12\x12\n" +
 	"\x04text\x18\x04 \x01(\tR\x04text\x129\n" +
 	"\n" +
-	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\\\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x1a\n" +
+	"\bsequence\x18\x06 \x01(\x04R\bsequence\x127\n" +
+	"\tencrypted\x18\a \x01(\v2\x19.chat.v1.EncryptedPayloadR\tencrypted\x12\x1c\n" +
+	"\tsignature\x18\b \x01(\fR\tsignature\x12(\n" +
+	"\x10signature_key_id\x18\t \x01(\tR\x0esignatureKeyId\x12\x1a\n" +
+	"\bverified\x18\n" +
+	" \x01(\bR\bverified\x12\x16\n" +
+	"\x06erased\x18\v \x01(\bR\x06erased\x12\x1b\n" +
+	"\tthread_id\x18\f \x01(\tR\bthreadId\x12\x1c\n" +
+	"\ttombstone\x18\r \x01(\bR\ttombstone\"\x85\x01\n" +
+	"\x10EncryptedPayload\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\x01 \x01(\fR\n" +
+	"ciphertext\x12\x15\n" +
+	"\x06key_id\x18\x02 \x01(\tR\x05keyId\x12\x1c\n" +
+	"\titeration\x18\x03 \x01(\rR\titeration\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\fR\tsignature\"\\\n" +
 	"\vTypingEvent\x12\x17\n" +
 	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
 	"\tis_typing\x18\x03 \x01(\bR\bisTyping\"@\n" +
 	"\rPresenceEvent\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
-	"\x06online\x18\x02 \x01(\bR\x06online\"\x8b\x02\n" +
+	"\x06online\x18\x02 \x01(\bR\x06online\"\x99\x03\n" +
 	"\vStreamEvent\x12&\n" +
 	"\x04type\x18\x01 \x01(\x0e2\x12.chat.v1.EventTypeR\x04type\x120\n" +
 	"\amessage\x18\x02 \x01(\v2\x14.chat.v1.ChatMessageH\x00R\amessage\x12.\n" +
 	"\x06typing\x18\x03 \x01(\v2\x14.chat.v1.TypingEventH\x00R\x06typing\x124\n" +
 	"\bpresence\x18\x04 \x01(\v2\x16.chat.v1.PresenceEventH\x00R\bpresence\x121\n" +
 	"\acontrol\x18\n" +
-	" \x01(\v2\x15.chat.v1.ControlEventH\x00R\acontrolB\t\n" +
-	"\apayload\"W\n" +
+	" \x01(\v2\x15.chat.v1.ControlEventH\x00R\acontrol\x12K\n" +
+	"\rtrace_context\x18\v \x03(\v2&.chat.v1.StreamEvent.TraceContextEntryR\ftraceContext\x1a?\n" +
+	"\x11TraceContextEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\t\n" +
+	"\apayload\"\xab\x01\n" +
 	"\fControlEvent\x12.\n" +
 	"\x06action\x18\x01 \x01(\x0e2\x16.chat.v1.ControlActionR\x06action\x12\x17\n" +
-	"\aroom_id\x18\x02 \x01(\tR\x06roomId\"D\n" +
+	"\aroom_id\x18\x02 \x01(\tR\x06roomId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12:\n" +
+	"\vretry_after\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"retryAfter\"\xc8\x01\n" +
+	"\fRoomSettings\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x126\n" +
+	"\tslow_mode\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\bslowMode\x12\x10\n" +
+	"\x03e2e\x18\x03 \x01(\bR\x03e2e\x126\n" +
+	"\tretention\x18\x04 \x01(\v2\x18.chat.v1.RetentionPolicyR\tretention\x12\x1d\n" +
+	"\n" +
+	"legal_hold\x18\x05 \x01(\bR\tlegalHold\"h\n" +
+	"\x0fRetentionPolicy\x122\n" +
+	"\amax_age\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x06maxAge\x12!\n" +
+	"\fmax_messages\x18\x02 \x01(\x04R\vmaxMessages\"D\n" +
 	"\x12SendMessageRequest\x12.\n" +
 	"\amessage\x18\x01 \x01(\v2\x14.chat.v1.ChatMessageR\amessage\"E\n" +
 	"\x13SendmessageResponse\x12.\n" +
-	"\amessage\x18\x01 \x01(\v2\x14.chat.v1.ChatMessageR\amessage\"B\n" +
+	"\amessage\x18\x01 \x01(\v2\x14.chat.v1.ChatMessageR\amessage\"k\n" +
 	"\x11GetMessageRequest\x12\x17\n" +
 	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x14\n" +
-	"\x05limit\x18\x02 \x01(\x05R\x05limit\"E\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12'\n" +
+	"\x0fbefore_sequence\x18\x03 \x01(\x04R\x0ebeforeSequence\"E\n" +
 	"\x13GetmessagesResponse\x12.\n" +
-	"\amessage\x18\x01 \x03(\v2\x14.chat.v1.ChatMessageR\amessage\"*\n" +
+	"\amessage\x18\x01 \x03(\v2\x14.chat.v1.ChatMessageR\amessage\"\x8f\x02\n" +
+	"\x15SearchMessagesRequest\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12\x19\n" +
+	"\broom_ids\x18\x03 \x03(\tR\aroomIds\x12\x1d\n" +
+	"\n" +
+	"sender_ids\x18\x04 \x03(\tR\tsenderIds\x120\n" +
+	"\x05since\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x14\n" +
+	"\x05limit\x18\a \x01(\x05R\x05limit\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\b \x01(\tR\tpageTokenJ\x04\b\x01\x10\x02R\auser_id\"r\n" +
+	"\x16SearchMessagesResponse\x120\n" +
+	"\bmessages\x18\x01 \x03(\v2\x14.chat.v1.ChatMessageR\bmessages\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"*\n" +
 	"\rStreamRequest\x12\x19\n" +
-	"\broom_ids\x18\x01 \x03(\tR\aroomIds*\x87\x01\n" +
+	"\broom_ids\x18\x01 \x03(\tR\aroomIds\"*\n" +
+	"\x0fJoinRoomRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\"\x12\n" +
+	"\x10JoinRoomResponse\"+\n" +
+	"\x10LeaveRoomRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\"\x13\n" +
+	"\x11LeaveRoomResponse*\x87\x01\n" +
 	"\tEventType\x12\x1a\n" +
 	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x16\n" +
 	"\x12EVENT_TYPE_MESSAGE\x10\x01\x12\x15\n" +
 	"\x11EVENT_TYPE_TYPING\x10\x02\x12\x17\n" +
 	"\x13EVENT_TYPE_PRESENCE\x10\x03\x12\x16\n" +
-	"\x12EVENT_TYPE_CONTROL\x10\x04*p\n" +
+	"\x12EVENT_TYPE_CONTROL\x10\x04*\xcd\x01\n" +
 	"\rControlAction\x12\x1e\n" +
 	"\x1aCONTROL_ACTION_UNSPECIFIED\x10\x00\x12\x1f\n" +
 	"\x1bCONTROL_ACTION_START_STREAM\x10\x01\x12\x1e\n" +
-	"\x1aCONTROL_ACTION_STOP_STREAM\x10\x022\xda\x01\n" +
+	"\x1aCONTROL_ACTION_STOP_STREAM\x10\x02\x12\x1d\n" +
+	"\x19CONTROL_ACTION_GOING_AWAY\x10\x03\x12\x1f\n" +
+	"\x1bCONTROL_ACTION_RATE_LIMITED\x10\x04\x12\x1b\n" +
+	"\x17CONTROL_ACTION_REJECTED\x10\x052\xb2\x03\n" +
 	"\vChatService\x12H\n" +
 	"\vSendMessage\x12\x1b.chat.v1.SendMessageRequest\x1a\x1c.chat.v1.SendmessageResponse\x12G\n" +
-	"\vGetmessages\x12\x1a.chat.v1.GetMessageRequest\x1a\x1c.chat.v1.GetmessagesResponse\x128\n" +
+	"\vGetmessages\x12\x1a.chat.v1.GetMessageRequest\x1a\x1c.chat.v1.GetmessagesResponse\x12Q\n" +
+	"\x0eSearchMessages\x12\x1e.chat.v1.SearchMessagesRequest\x1a\x1f.chat.v1.SearchMessagesResponse\x12?\n" +
+	"\bJoinRoom\x12\x18.chat.v1.JoinRoomRequest\x1a\x19.chat.v1.JoinRoomResponse\x12B\n" +
+	"\tLeaveRoom\x12\x19.chat.v1.LeaveRoomRequest\x1a\x1a.chat.v1.LeaveRoomResponse\x128\n" +
 	"\x06Stream\x12\x14.chat.v1.StreamEvent\x1a\x14.chat.v1.StreamEvent(\x010\x01B\x1bZ\x19gen/go/chat/chatv1;chatv1b\x06proto3"
 
 var (
@@ -786,44 +1492,70 @@ func file_chat_proto_rawDescGZIP() []byte {
 }
 
 var file_chat_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_chat_proto_goTypes = []any{
-	(EventType)(0),                // 0: chat.v1.EventType
-	(ControlAction)(0),            // 1: chat.v1.ControlAction
-	(*ChatMessage)(nil),           // 2: chat.v1.ChatMessage
-	(*TypingEvent)(nil),           // 3: chat.v1.TypingEvent
-	(*PresenceEvent)(nil),         // 4: chat.v1.PresenceEvent
-	(*StreamEvent)(nil),           // 5: chat.v1.StreamEvent
-	(*ControlEvent)(nil),          // 6: chat.v1.ControlEvent
-	(*SendMessageRequest)(nil),    // 7: chat.v1.SendMessageRequest
-	(*SendmessageResponse)(nil),   // 8: chat.v1.SendmessageResponse
-	(*GetMessageRequest)(nil),     // 9: chat.v1.GetMessageRequest
-	(*GetmessagesResponse)(nil),   // 10: chat.v1.GetmessagesResponse
-	(*StreamRequest)(nil),         // 11: chat.v1.StreamRequest
-	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+	(EventType)(0),                 // 0: chat.v1.EventType
+	(ControlAction)(0),             // 1: chat.v1.ControlAction
+	(*ChatMessage)(nil),            // 2: chat.v1.ChatMessage
+	(*EncryptedPayload)(nil),       // 3: chat.v1.EncryptedPayload
+	(*TypingEvent)(nil),            // 4: chat.v1.TypingEvent
+	(*PresenceEvent)(nil),          // 5: chat.v1.PresenceEvent
+	(*StreamEvent)(nil),            // 6: chat.v1.StreamEvent
+	(*ControlEvent)(nil),           // 7: chat.v1.ControlEvent
+	(*RoomSettings)(nil),           // 8: chat.v1.RoomSettings
+	(*RetentionPolicy)(nil),        // 9: chat.v1.RetentionPolicy
+	(*SendMessageRequest)(nil),     // 10: chat.v1.SendMessageRequest
+	(*SendmessageResponse)(nil),    // 11: chat.v1.SendmessageResponse
+	(*GetMessageRequest)(nil),      // 12: chat.v1.GetMessageRequest
+	(*GetmessagesResponse)(nil),    // 13: chat.v1.GetmessagesResponse
+	(*SearchMessagesRequest)(nil),  // 14: chat.v1.SearchMessagesRequest
+	(*SearchMessagesResponse)(nil), // 15: chat.v1.SearchMessagesResponse
+	(*StreamRequest)(nil),          // 16: chat.v1.StreamRequest
+	(*JoinRoomRequest)(nil),        // 17: chat.v1.JoinRoomRequest
+	(*JoinRoomResponse)(nil),       // 18: chat.v1.JoinRoomResponse
+	(*LeaveRoomRequest)(nil),       // 19: chat.v1.LeaveRoomRequest
+	(*LeaveRoomResponse)(nil),      // 20: chat.v1.LeaveRoomResponse
+	nil,                            // 21: chat.v1.StreamEvent.TraceContextEntry
+	(*timestamppb.Timestamp)(nil),  // 22: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),    // 23: google.protobuf.Duration
 }
 var file_chat_proto_depIdxs = []int32{
-	12, // 0: chat.v1.ChatMessage.created_at:type_name -> google.protobuf.Timestamp
-	0,  // 1: chat.v1.StreamEvent.type:type_name -> chat.v1.EventType
-	2,  // 2: chat.v1.StreamEvent.message:type_name -> chat.v1.ChatMessage
-	3,  // 3: chat.v1.StreamEvent.typing:type_name -> chat.v1.TypingEvent
-	4,  // 4: chat.v1.StreamEvent.presence:type_name -> chat.v1.PresenceEvent
-	6,  // 5: chat.v1.StreamEvent.control:type_name -> chat.v1.ControlEvent
-	1,  // 6: chat.v1.ControlEvent.action:type_name -> chat.v1.ControlAction
-	2,  // 7: chat.v1.SendMessageRequest.message:type_name -> chat.v1.ChatMessage
-	2,  // 8: chat.v1.SendmessageResponse.message:type_name -> chat.v1.ChatMessage
-	2,  // 9: chat.v1.GetmessagesResponse.message:type_name -> chat.v1.ChatMessage
-	7,  // 10: chat.v1.ChatService.SendMessage:input_type -> chat.v1.SendMessageRequest
-	9,  // 11: chat.v1.ChatService.Getmessages:input_type -> chat.v1.GetMessageRequest
-	5,  // 12: chat.v1.ChatService.Stream:input_type -> chat.v1.StreamEvent
-	8,  // 13: chat.v1.ChatService.SendMessage:output_type -> chat.v1.SendmessageResponse
-	10, // 14: chat.v1.ChatService.Getmessages:output_type -> chat.v1.GetmessagesResponse
-	5,  // 15: chat.v1.ChatService.Stream:output_type -> chat.v1.StreamEvent
-	13, // [13:16] is the sub-list for method output_type
-	10, // [10:13] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	22, // 0: chat.v1.ChatMessage.created_at:type_name -> google.protobuf.Timestamp
+	3,  // 1: chat.v1.ChatMessage.encrypted:type_name -> chat.v1.EncryptedPayload
+	0,  // 2: chat.v1.StreamEvent.type:type_name -> chat.v1.EventType
+	2,  // 3: chat.v1.StreamEvent.message:type_name -> chat.v1.ChatMessage
+	4,  // 4: chat.v1.StreamEvent.typing:type_name -> chat.v1.TypingEvent
+	5,  // 5: chat.v1.StreamEvent.presence:type_name -> chat.v1.PresenceEvent
+	7,  // 6: chat.v1.StreamEvent.control:type_name -> chat.v1.ControlEvent
+	21, // 7: chat.v1.StreamEvent.trace_context:type_name -> chat.v1.StreamEvent.TraceContextEntry
+	1,  // 8: chat.v1.ControlEvent.action:type_name -> chat.v1.ControlAction
+	23, // 9: chat.v1.ControlEvent.retry_after:type_name -> google.protobuf.Duration
+	23, // 10: chat.v1.RoomSettings.slow_mode:type_name -> google.protobuf.Duration
+	9,  // 11: chat.v1.RoomSettings.retention:type_name -> chat.v1.RetentionPolicy
+	23, // 12: chat.v1.RetentionPolicy.max_age:type_name -> google.protobuf.Duration
+	2,  // 13: chat.v1.SendMessageRequest.message:type_name -> chat.v1.ChatMessage
+	2,  // 14: chat.v1.SendmessageResponse.message:type_name -> chat.v1.ChatMessage
+	2,  // 15: chat.v1.GetmessagesResponse.message:type_name -> chat.v1.ChatMessage
+	22, // 16: chat.v1.SearchMessagesRequest.since:type_name -> google.protobuf.Timestamp
+	22, // 17: chat.v1.SearchMessagesRequest.until:type_name -> google.protobuf.Timestamp
+	2,  // 18: chat.v1.SearchMessagesResponse.messages:type_name -> chat.v1.ChatMessage
+	10, // 19: chat.v1.ChatService.SendMessage:input_type -> chat.v1.SendMessageRequest
+	12, // 20: chat.v1.ChatService.Getmessages:input_type -> chat.v1.GetMessageRequest
+	14, // 21: chat.v1.ChatService.SearchMessages:input_type -> chat.v1.SearchMessagesRequest
+	17, // 22: chat.v1.ChatService.JoinRoom:input_type -> chat.v1.JoinRoomRequest
+	19, // 23: chat.v1.ChatService.LeaveRoom:input_type -> chat.v1.LeaveRoomRequest
+	6,  // 24: chat.v1.ChatService.Stream:input_type -> chat.v1.StreamEvent
+	11, // 25: chat.v1.ChatService.SendMessage:output_type -> chat.v1.SendmessageResponse
+	13, // 26: chat.v1.ChatService.Getmessages:output_type -> chat.v1.GetmessagesResponse
+	15, // 27: chat.v1.ChatService.SearchMessages:output_type -> chat.v1.SearchMessagesResponse
+	18, // 28: chat.v1.ChatService.JoinRoom:output_type -> chat.v1.JoinRoomResponse
+	20, // 29: chat.v1.ChatService.LeaveRoom:output_type -> chat.v1.LeaveRoomResponse
+	6,  // 30: chat.v1.ChatService.Stream:output_type -> chat.v1.StreamEvent
+	25, // [25:31] is the sub-list for method output_type
+	19, // [19:25] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_chat_proto_init() }
@@ -831,7 +1563,7 @@ func file_chat_proto_init() {
 	if File_chat_proto != nil {
 		return
 	}
-	file_chat_proto_msgTypes[3].OneofWrappers = []any{
+	file_chat_proto_msgTypes[4].OneofWrappers = []any{
 		(*StreamEvent_Message)(nil),
 		(*StreamEvent_Typing)(nil),
 		(*StreamEvent_Presence)(nil),
@@ -843,7 +1575,7 @@ func file_chat_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chat_proto_rawDesc), len(file_chat_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   10,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
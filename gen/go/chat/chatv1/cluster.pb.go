@@ -0,0 +1,1067 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.1
+// source: cluster.proto
+
+package chatv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ForwardEventRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Event          *StreamEvent           `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	OriginNodeId   string                 `protobuf:"bytes,2,opt,name=origin_node_id,json=originNodeId,proto3" json:"origin_node_id,omitempty"`
+	OriginStreamId string                 `protobuf:"bytes,3,opt,name=origin_stream_id,json=originStreamId,proto3" json:"origin_stream_id,omitempty"` // the sender's stream, skipped on fan-out
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ForwardEventRequest) Reset() {
+	*x = ForwardEventRequest{}
+	mi := &file_cluster_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardEventRequest) ProtoMessage() {}
+
+func (x *ForwardEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardEventRequest.ProtoReflect.Descriptor instead.
+func (*ForwardEventRequest) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ForwardEventRequest) GetEvent() *StreamEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ForwardEventRequest) GetOriginNodeId() string {
+	if x != nil {
+		return x.OriginNodeId
+	}
+	return ""
+}
+
+func (x *ForwardEventRequest) GetOriginStreamId() string {
+	if x != nil {
+		return x.OriginStreamId
+	}
+	return ""
+}
+
+type ForwardEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         *StreamEvent           `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"` // as sequenced and stored by the owner
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardEventResponse) Reset() {
+	*x = ForwardEventResponse{}
+	mi := &file_cluster_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardEventResponse) ProtoMessage() {}
+
+func (x *ForwardEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardEventResponse.ProtoReflect.Descriptor instead.
+func (*ForwardEventResponse) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ForwardEventResponse) GetEvent() *StreamEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type TransferRoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	FromNodeId    string                 `protobuf:"bytes,2,opt,name=from_node_id,json=fromNodeId,proto3" json:"from_node_id,omitempty"`
+	Messages      []*ChatMessage         `protobuf:"bytes,3,rep,name=messages,proto3" json:"messages,omitempty"`
+	Settings      *RoomSettings          `protobuf:"bytes,4,opt,name=settings,proto3" json:"settings,omitempty"`
+	Members       []string               `protobuf:"bytes,5,rep,name=members,proto3" json:"members,omitempty"` // user ids of the room's members
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferRoomRequest) Reset() {
+	*x = TransferRoomRequest{}
+	mi := &file_cluster_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferRoomRequest) ProtoMessage() {}
+
+func (x *TransferRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferRoomRequest.ProtoReflect.Descriptor instead.
+func (*TransferRoomRequest) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TransferRoomRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *TransferRoomRequest) GetFromNodeId() string {
+	if x != nil {
+		return x.FromNodeId
+	}
+	return ""
+}
+
+func (x *TransferRoomRequest) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *TransferRoomRequest) GetSettings() *RoomSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+func (x *TransferRoomRequest) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type TransferRoomResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      int32                  `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferRoomResponse) Reset() {
+	*x = TransferRoomResponse{}
+	mi := &file_cluster_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferRoomResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferRoomResponse) ProtoMessage() {}
+
+func (x *TransferRoomResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferRoomResponse.ProtoReflect.Descriptor instead.
+func (*TransferRoomResponse) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TransferRoomResponse) GetAccepted() int32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+type AppendMessageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       *ChatMessage           `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendMessageRequest) Reset() {
+	*x = AppendMessageRequest{}
+	mi := &file_cluster_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendMessageRequest) ProtoMessage() {}
+
+func (x *AppendMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendMessageRequest.ProtoReflect.Descriptor instead.
+func (*AppendMessageRequest) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AppendMessageRequest) GetMessage() *ChatMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+type AppendMessageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       *ChatMessage           `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"` // with the sequence assigned by the leader
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendMessageResponse) Reset() {
+	*x = AppendMessageResponse{}
+	mi := &file_cluster_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendMessageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendMessageResponse) ProtoMessage() {}
+
+func (x *AppendMessageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendMessageResponse.ProtoReflect.Descriptor instead.
+func (*AppendMessageResponse) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AppendMessageResponse) GetMessage() *ChatMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+type SetRoomSettingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *RoomSettings          `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRoomSettingsRequest) Reset() {
+	*x = SetRoomSettingsRequest{}
+	mi := &file_cluster_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRoomSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRoomSettingsRequest) ProtoMessage() {}
+
+func (x *SetRoomSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRoomSettingsRequest.ProtoReflect.Descriptor instead.
+func (*SetRoomSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetRoomSettingsRequest) GetSettings() *RoomSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type SetRoomSettingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRoomSettingsResponse) Reset() {
+	*x = SetRoomSettingsResponse{}
+	mi := &file_cluster_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRoomSettingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRoomSettingsResponse) ProtoMessage() {}
+
+func (x *SetRoomSettingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRoomSettingsResponse.ProtoReflect.Descriptor instead.
+func (*SetRoomSettingsResponse) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{7}
+}
+
+type CheckSenderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       *ChatMessage           `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSenderRequest) Reset() {
+	*x = CheckSenderRequest{}
+	mi := &file_cluster_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSenderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSenderRequest) ProtoMessage() {}
+
+func (x *CheckSenderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSenderRequest.ProtoReflect.Descriptor instead.
+func (*CheckSenderRequest) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CheckSenderRequest) GetMessage() *ChatMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+type CheckSenderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Restriction   *UserRestriction       `protobuf:"bytes,1,opt,name=restriction,proto3" json:"restriction,omitempty"` // unset when the sender may post
+	Verified      bool                   `protobuf:"varint,2,opt,name=verified,proto3" json:"verified,omitempty"`      // the message's signature checked out
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSenderResponse) Reset() {
+	*x = CheckSenderResponse{}
+	mi := &file_cluster_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSenderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSenderResponse) ProtoMessage() {}
+
+func (x *CheckSenderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSenderResponse.ProtoReflect.Descriptor instead.
+func (*CheckSenderResponse) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CheckSenderResponse) GetRestriction() *UserRestriction {
+	if x != nil {
+		return x.Restriction
+	}
+	return nil
+}
+
+func (x *CheckSenderResponse) GetVerified() bool {
+	if x != nil {
+		return x.Verified
+	}
+	return false
+}
+
+type EraseSenderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	SenderId      string                 `protobuf:"bytes,2,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	Alias         string                 `protobuf:"bytes,3,opt,name=alias,proto3" json:"alias,omitempty"`
+	DeleteContent bool                   `protobuf:"varint,4,opt,name=delete_content,json=deleteContent,proto3" json:"delete_content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EraseSenderRequest) Reset() {
+	*x = EraseSenderRequest{}
+	mi := &file_cluster_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EraseSenderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EraseSenderRequest) ProtoMessage() {}
+
+func (x *EraseSenderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EraseSenderRequest.ProtoReflect.Descriptor instead.
+func (*EraseSenderRequest) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EraseSenderRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *EraseSenderRequest) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *EraseSenderRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *EraseSenderRequest) GetDeleteContent() bool {
+	if x != nil {
+		return x.DeleteContent
+	}
+	return false
+}
+
+type EraseSenderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Erased        int32                  `protobuf:"varint,1,opt,name=erased,proto3" json:"erased,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EraseSenderResponse) Reset() {
+	*x = EraseSenderResponse{}
+	mi := &file_cluster_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EraseSenderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EraseSenderResponse) ProtoMessage() {}
+
+func (x *EraseSenderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EraseSenderResponse.ProtoReflect.Descriptor instead.
+func (*EraseSenderResponse) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EraseSenderResponse) GetErased() int32 {
+	if x != nil {
+		return x.Erased
+	}
+	return 0
+}
+
+type TombstoneRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Sequences     []uint64               `protobuf:"varint,2,rep,packed,name=sequences,proto3" json:"sequences,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TombstoneRequest) Reset() {
+	*x = TombstoneRequest{}
+	mi := &file_cluster_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TombstoneRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TombstoneRequest) ProtoMessage() {}
+
+func (x *TombstoneRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TombstoneRequest.ProtoReflect.Descriptor instead.
+func (*TombstoneRequest) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TombstoneRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *TombstoneRequest) GetSequences() []uint64 {
+	if x != nil {
+		return x.Sequences
+	}
+	return nil
+}
+
+type TombstoneResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tombstoned    int32                  `protobuf:"varint,1,opt,name=tombstoned,proto3" json:"tombstoned,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TombstoneResponse) Reset() {
+	*x = TombstoneResponse{}
+	mi := &file_cluster_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TombstoneResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TombstoneResponse) ProtoMessage() {}
+
+func (x *TombstoneResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TombstoneResponse.ProtoReflect.Descriptor instead.
+func (*TombstoneResponse) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TombstoneResponse) GetTombstoned() int32 {
+	if x != nil {
+		return x.Tombstoned
+	}
+	return 0
+}
+
+type DropRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"` // how many of the oldest messages to remove
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DropRequest) Reset() {
+	*x = DropRequest{}
+	mi := &file_cluster_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DropRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DropRequest) ProtoMessage() {}
+
+func (x *DropRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DropRequest.ProtoReflect.Descriptor instead.
+func (*DropRequest) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DropRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *DropRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type DropResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DropResponse) Reset() {
+	*x = DropResponse{}
+	mi := &file_cluster_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DropResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DropResponse) ProtoMessage() {}
+
+func (x *DropResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DropResponse.ProtoReflect.Descriptor instead.
+func (*DropResponse) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{15}
+}
+
+type SetRoomMemberRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Member        bool                   `protobuf:"varint,3,opt,name=member,proto3" json:"member,omitempty"` // false to leave the room
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRoomMemberRequest) Reset() {
+	*x = SetRoomMemberRequest{}
+	mi := &file_cluster_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRoomMemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRoomMemberRequest) ProtoMessage() {}
+
+func (x *SetRoomMemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRoomMemberRequest.ProtoReflect.Descriptor instead.
+func (*SetRoomMemberRequest) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SetRoomMemberRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *SetRoomMemberRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SetRoomMemberRequest) GetMember() bool {
+	if x != nil {
+		return x.Member
+	}
+	return false
+}
+
+type SetRoomMemberResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRoomMemberResponse) Reset() {
+	*x = SetRoomMemberResponse{}
+	mi := &file_cluster_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRoomMemberResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRoomMemberResponse) ProtoMessage() {}
+
+func (x *SetRoomMemberResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cluster_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRoomMemberResponse.ProtoReflect.Descriptor instead.
+func (*SetRoomMemberResponse) Descriptor() ([]byte, []int) {
+	return file_cluster_proto_rawDescGZIP(), []int{17}
+}
+
+var File_cluster_proto protoreflect.FileDescriptor
+
+const file_cluster_proto_rawDesc = "" +
+	"\n" +
+	"\rcluster.proto\x12\achat.v1\x1a\vadmin.proto\x1a\n" +
+	"chat.proto\"\x91\x01\n" +
+	"\x13ForwardEventRequest\x12*\n" +
+	"\x05event\x18\x01 \x01(\v2\x14.chat.v1.StreamEventR\x05event\x12$\n" +
+	"\x0eorigin_node_id\x18\x02 \x01(\tR\foriginNodeId\x12(\n" +
+	"\x10origin_stream_id\x18\x03 \x01(\tR\x0eoriginStreamId\"B\n" +
+	"\x14ForwardEventResponse\x12*\n" +
+	"\x05event\x18\x01 \x01(\v2\x14.chat.v1.StreamEventR\x05event\"\xcf\x01\n" +
+	"\x13TransferRoomRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12 \n" +
+	"\ffrom_node_id\x18\x02 \x01(\tR\n" +
+	"fromNodeId\x120\n" +
+	"\bmessages\x18\x03 \x03(\v2\x14.chat.v1.ChatMessageR\bmessages\x121\n" +
+	"\bsettings\x18\x04 \x01(\v2\x15.chat.v1.RoomSettingsR\bsettings\x12\x18\n" +
+	"\amembers\x18\x05 \x03(\tR\amembers\"2\n" +
+	"\x14TransferRoomResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x05R\baccepted\"F\n" +
+	"\x14AppendMessageRequest\x12.\n" +
+	"\amessage\x18\x01 \x01(\v2\x14.chat.v1.ChatMessageR\amessage\"G\n" +
+	"\x15AppendMessageResponse\x12.\n" +
+	"\amessage\x18\x01 \x01(\v2\x14.chat.v1.ChatMessageR\amessage\"K\n" +
+	"\x16SetRoomSettingsRequest\x121\n" +
+	"\bsettings\x18\x01 \x01(\v2\x15.chat.v1.RoomSettingsR\bsettings\"\x19\n" +
+	"\x17SetRoomSettingsResponse\"D\n" +
+	"\x12CheckSenderRequest\x12.\n" +
+	"\amessage\x18\x01 \x01(\v2\x14.chat.v1.ChatMessageR\amessage\"m\n" +
+	"\x13CheckSenderResponse\x12:\n" +
+	"\vrestriction\x18\x01 \x01(\v2\x18.chat.v1.UserRestrictionR\vrestriction\x12\x1a\n" +
+	"\bverified\x18\x02 \x01(\bR\bverified\"\x87\x01\n" +
+	"\x12EraseSenderRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1b\n" +
+	"\tsender_id\x18\x02 \x01(\tR\bsenderId\x12\x14\n" +
+	"\x05alias\x18\x03 \x01(\tR\x05alias\x12%\n" +
+	"\x0edelete_content\x18\x04 \x01(\bR\rdeleteContent\"-\n" +
+	"\x13EraseSenderResponse\x12\x16\n" +
+	"\x06erased\x18\x01 \x01(\x05R\x06erased\"I\n" +
+	"\x10TombstoneRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1c\n" +
+	"\tsequences\x18\x02 \x03(\x04R\tsequences\"3\n" +
+	"\x11TombstoneResponse\x12\x1e\n" +
+	"\n" +
+	"tombstoned\x18\x01 \x01(\x05R\n" +
+	"tombstoned\"<\n" +
+	"\vDropRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"\x0e\n" +
+	"\fDropResponse\"`\n" +
+	"\x14SetRoomMemberRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06member\x18\x03 \x01(\bR\x06member\"\x17\n" +
+	"\x15SetRoomMemberResponse2\xad\x05\n" +
+	"\x0eClusterService\x12K\n" +
+	"\fForwardEvent\x12\x1c.chat.v1.ForwardEventRequest\x1a\x1d.chat.v1.ForwardEventResponse\x12K\n" +
+	"\fTransferRoom\x12\x1c.chat.v1.TransferRoomRequest\x1a\x1d.chat.v1.TransferRoomResponse\x12N\n" +
+	"\rAppendMessage\x12\x1d.chat.v1.AppendMessageRequest\x1a\x1e.chat.v1.AppendMessageResponse\x12T\n" +
+	"\x0fSetRoomSettings\x12\x1f.chat.v1.SetRoomSettingsRequest\x1a .chat.v1.SetRoomSettingsResponse\x12H\n" +
+	"\vCheckSender\x12\x1b.chat.v1.CheckSenderRequest\x1a\x1c.chat.v1.CheckSenderResponse\x12H\n" +
+	"\vEraseSender\x12\x1b.chat.v1.EraseSenderRequest\x1a\x1c.chat.v1.EraseSenderResponse\x12B\n" +
+	"\tTombstone\x12\x19.chat.v1.TombstoneRequest\x1a\x1a.chat.v1.TombstoneResponse\x123\n" +
+	"\x04Drop\x12\x14.chat.v1.DropRequest\x1a\x15.chat.v1.DropResponse\x12N\n" +
+	"\rSetRoomMember\x12\x1d.chat.v1.SetRoomMemberRequest\x1a\x1e.chat.v1.SetRoomMemberResponseB\x1bZ\x19gen/go/chat/chatv1;chatv1b\x06proto3"
+
+var (
+	file_cluster_proto_rawDescOnce sync.Once
+	file_cluster_proto_rawDescData []byte
+)
+
+func file_cluster_proto_rawDescGZIP() []byte {
+	file_cluster_proto_rawDescOnce.Do(func() {
+		file_cluster_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_cluster_proto_rawDesc), len(file_cluster_proto_rawDesc)))
+	})
+	return file_cluster_proto_rawDescData
+}
+
+var file_cluster_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_cluster_proto_goTypes = []any{
+	(*ForwardEventRequest)(nil),     // 0: chat.v1.ForwardEventRequest
+	(*ForwardEventResponse)(nil),    // 1: chat.v1.ForwardEventResponse
+	(*TransferRoomRequest)(nil),     // 2: chat.v1.TransferRoomRequest
+	(*TransferRoomResponse)(nil),    // 3: chat.v1.TransferRoomResponse
+	(*AppendMessageRequest)(nil),    // 4: chat.v1.AppendMessageRequest
+	(*AppendMessageResponse)(nil),   // 5: chat.v1.AppendMessageResponse
+	(*SetRoomSettingsRequest)(nil),  // 6: chat.v1.SetRoomSettingsRequest
+	(*SetRoomSettingsResponse)(nil), // 7: chat.v1.SetRoomSettingsResponse
+	(*CheckSenderRequest)(nil),      // 8: chat.v1.CheckSenderRequest
+	(*CheckSenderResponse)(nil),     // 9: chat.v1.CheckSenderResponse
+	(*EraseSenderRequest)(nil),      // 10: chat.v1.EraseSenderRequest
+	(*EraseSenderResponse)(nil),     // 11: chat.v1.EraseSenderResponse
+	(*TombstoneRequest)(nil),        // 12: chat.v1.TombstoneRequest
+	(*TombstoneResponse)(nil),       // 13: chat.v1.TombstoneResponse
+	(*DropRequest)(nil),             // 14: chat.v1.DropRequest
+	(*DropResponse)(nil),            // 15: chat.v1.DropResponse
+	(*SetRoomMemberRequest)(nil),    // 16: chat.v1.SetRoomMemberRequest
+	(*SetRoomMemberResponse)(nil),   // 17: chat.v1.SetRoomMemberResponse
+	(*StreamEvent)(nil),             // 18: chat.v1.StreamEvent
+	(*ChatMessage)(nil),             // 19: chat.v1.ChatMessage
+	(*RoomSettings)(nil),            // 20: chat.v1.RoomSettings
+	(*UserRestriction)(nil),         // 21: chat.v1.UserRestriction
+}
+var file_cluster_proto_depIdxs = []int32{
+	18, // 0: chat.v1.ForwardEventRequest.event:type_name -> chat.v1.StreamEvent
+	18, // 1: chat.v1.ForwardEventResponse.event:type_name -> chat.v1.StreamEvent
+	19, // 2: chat.v1.TransferRoomRequest.messages:type_name -> chat.v1.ChatMessage
+	20, // 3: chat.v1.TransferRoomRequest.settings:type_name -> chat.v1.RoomSettings
+	19, // 4: chat.v1.AppendMessageRequest.message:type_name -> chat.v1.ChatMessage
+	19, // 5: chat.v1.AppendMessageResponse.message:type_name -> chat.v1.ChatMessage
+	20, // 6: chat.v1.SetRoomSettingsRequest.settings:type_name -> chat.v1.RoomSettings
+	19, // 7: chat.v1.CheckSenderRequest.message:type_name -> chat.v1.ChatMessage
+	21, // 8: chat.v1.CheckSenderResponse.restriction:type_name -> chat.v1.UserRestriction
+	0,  // 9: chat.v1.ClusterService.ForwardEvent:input_type -> chat.v1.ForwardEventRequest
+	2,  // 10: chat.v1.ClusterService.TransferRoom:input_type -> chat.v1.TransferRoomRequest
+	4,  // 11: chat.v1.ClusterService.AppendMessage:input_type -> chat.v1.AppendMessageRequest
+	6,  // 12: chat.v1.ClusterService.SetRoomSettings:input_type -> chat.v1.SetRoomSettingsRequest
+	8,  // 13: chat.v1.ClusterService.CheckSender:input_type -> chat.v1.CheckSenderRequest
+	10, // 14: chat.v1.ClusterService.EraseSender:input_type -> chat.v1.EraseSenderRequest
+	12, // 15: chat.v1.ClusterService.Tombstone:input_type -> chat.v1.TombstoneRequest
+	14, // 16: chat.v1.ClusterService.Drop:input_type -> chat.v1.DropRequest
+	16, // 17: chat.v1.ClusterService.SetRoomMember:input_type -> chat.v1.SetRoomMemberRequest
+	1,  // 18: chat.v1.ClusterService.ForwardEvent:output_type -> chat.v1.ForwardEventResponse
+	3,  // 19: chat.v1.ClusterService.TransferRoom:output_type -> chat.v1.TransferRoomResponse
+	5,  // 20: chat.v1.ClusterService.AppendMessage:output_type -> chat.v1.AppendMessageResponse
+	7,  // 21: chat.v1.ClusterService.SetRoomSettings:output_type -> chat.v1.SetRoomSettingsResponse
+	9,  // 22: chat.v1.ClusterService.CheckSender:output_type -> chat.v1.CheckSenderResponse
+	11, // 23: chat.v1.ClusterService.EraseSender:output_type -> chat.v1.EraseSenderResponse
+	13, // 24: chat.v1.ClusterService.Tombstone:output_type -> chat.v1.TombstoneResponse
+	15, // 25: chat.v1.ClusterService.Drop:output_type -> chat.v1.DropResponse
+	17, // 26: chat.v1.ClusterService.SetRoomMember:output_type -> chat.v1.SetRoomMemberResponse
+	18, // [18:27] is the sub-list for method output_type
+	9,  // [9:18] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_cluster_proto_init() }
+func file_cluster_proto_init() {
+	if File_cluster_proto != nil {
+		return
+	}
+	file_admin_proto_init()
+	file_chat_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_cluster_proto_rawDesc), len(file_cluster_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_cluster_proto_goTypes,
+		DependencyIndexes: file_cluster_proto_depIdxs,
+		MessageInfos:      file_cluster_proto_msgTypes,
+	}.Build()
+	File_cluster_proto = out.File
+	file_cluster_proto_goTypes = nil
+	file_cluster_proto_depIdxs = nil
+}
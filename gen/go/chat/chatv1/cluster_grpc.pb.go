@@ -0,0 +1,453 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.33.1
+// source: cluster.proto
+
+package chatv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ClusterService_ForwardEvent_FullMethodName    = "/chat.v1.ClusterService/ForwardEvent"
+	ClusterService_TransferRoom_FullMethodName    = "/chat.v1.ClusterService/TransferRoom"
+	ClusterService_AppendMessage_FullMethodName   = "/chat.v1.ClusterService/AppendMessage"
+	ClusterService_SetRoomSettings_FullMethodName = "/chat.v1.ClusterService/SetRoomSettings"
+	ClusterService_CheckSender_FullMethodName     = "/chat.v1.ClusterService/CheckSender"
+	ClusterService_EraseSender_FullMethodName     = "/chat.v1.ClusterService/EraseSender"
+	ClusterService_Tombstone_FullMethodName       = "/chat.v1.ClusterService/Tombstone"
+	ClusterService_Drop_FullMethodName            = "/chat.v1.ClusterService/Drop"
+	ClusterService_SetRoomMember_FullMethodName   = "/chat.v1.ClusterService/SetRoomMember"
+)
+
+// ClusterServiceClient is the client API for ClusterService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ClusterServiceClient interface {
+	// ForwardEvent hands a stream event to the owner of its room.
+	ForwardEvent(ctx context.Context, in *ForwardEventRequest, opts ...grpc.CallOption) (*ForwardEventResponse, error)
+	// TransferRoom moves a room's history to its new owner after rebalancing.
+	TransferRoom(ctx context.Context, in *TransferRoomRequest, opts ...grpc.CallOption) (*TransferRoomResponse, error)
+	// AppendMessage commits a message through the replicated store's leader.
+	AppendMessage(ctx context.Context, in *AppendMessageRequest, opts ...grpc.CallOption) (*AppendMessageResponse, error)
+	// SetRoomSettings commits room settings through the replicated store's leader.
+	SetRoomSettings(ctx context.Context, in *SetRoomSettingsRequest, opts ...grpc.CallOption) (*SetRoomSettingsResponse, error)
+	// CheckSender verifies and scores a message on its sender's home node
+	// and returns the restriction the sender is under, if any.
+	CheckSender(ctx context.Context, in *CheckSenderRequest, opts ...grpc.CallOption) (*CheckSenderResponse, error)
+	// EraseSender erases a sender's messages in a room through the
+	// replicated store's leader.
+	EraseSender(ctx context.Context, in *EraseSenderRequest, opts ...grpc.CallOption) (*EraseSenderResponse, error)
+	// Tombstone removes expired messages through the replicated store's
+	// leader.
+	Tombstone(ctx context.Context, in *TombstoneRequest, opts ...grpc.CallOption) (*TombstoneResponse, error)
+	// Drop removes a room's oldest messages through the replicated store's
+	// leader.
+	Drop(ctx context.Context, in *DropRequest, opts ...grpc.CallOption) (*DropResponse, error)
+	// SetRoomMember records a user joining or leaving a room through the
+	// replicated store's leader.
+	SetRoomMember(ctx context.Context, in *SetRoomMemberRequest, opts ...grpc.CallOption) (*SetRoomMemberResponse, error)
+}
+
+type clusterServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClusterServiceClient(cc grpc.ClientConnInterface) ClusterServiceClient {
+	return &clusterServiceClient{cc}
+}
+
+func (c *clusterServiceClient) ForwardEvent(ctx context.Context, in *ForwardEventRequest, opts ...grpc.CallOption) (*ForwardEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForwardEventResponse)
+	err := c.cc.Invoke(ctx, ClusterService_ForwardEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) TransferRoom(ctx context.Context, in *TransferRoomRequest, opts ...grpc.CallOption) (*TransferRoomResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferRoomResponse)
+	err := c.cc.Invoke(ctx, ClusterService_TransferRoom_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) AppendMessage(ctx context.Context, in *AppendMessageRequest, opts ...grpc.CallOption) (*AppendMessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendMessageResponse)
+	err := c.cc.Invoke(ctx, ClusterService_AppendMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) SetRoomSettings(ctx context.Context, in *SetRoomSettingsRequest, opts ...grpc.CallOption) (*SetRoomSettingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetRoomSettingsResponse)
+	err := c.cc.Invoke(ctx, ClusterService_SetRoomSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) CheckSender(ctx context.Context, in *CheckSenderRequest, opts ...grpc.CallOption) (*CheckSenderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckSenderResponse)
+	err := c.cc.Invoke(ctx, ClusterService_CheckSender_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) EraseSender(ctx context.Context, in *EraseSenderRequest, opts ...grpc.CallOption) (*EraseSenderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EraseSenderResponse)
+	err := c.cc.Invoke(ctx, ClusterService_EraseSender_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) Tombstone(ctx context.Context, in *TombstoneRequest, opts ...grpc.CallOption) (*TombstoneResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TombstoneResponse)
+	err := c.cc.Invoke(ctx, ClusterService_Tombstone_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) Drop(ctx context.Context, in *DropRequest, opts ...grpc.CallOption) (*DropResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DropResponse)
+	err := c.cc.Invoke(ctx, ClusterService_Drop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) SetRoomMember(ctx context.Context, in *SetRoomMemberRequest, opts ...grpc.CallOption) (*SetRoomMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetRoomMemberResponse)
+	err := c.cc.Invoke(ctx, ClusterService_SetRoomMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClusterServiceServer is the server API for ClusterService service.
+// All implementations must embed UnimplementedClusterServiceServer
+// for forward compatibility.
+type ClusterServiceServer interface {
+	// ForwardEvent hands a stream event to the owner of its room.
+	ForwardEvent(context.Context, *ForwardEventRequest) (*ForwardEventResponse, error)
+	// TransferRoom moves a room's history to its new owner after rebalancing.
+	TransferRoom(context.Context, *TransferRoomRequest) (*TransferRoomResponse, error)
+	// AppendMessage commits a message through the replicated store's leader.
+	AppendMessage(context.Context, *AppendMessageRequest) (*AppendMessageResponse, error)
+	// SetRoomSettings commits room settings through the replicated store's leader.
+	SetRoomSettings(context.Context, *SetRoomSettingsRequest) (*SetRoomSettingsResponse, error)
+	// CheckSender verifies and scores a message on its sender's home node
+	// and returns the restriction the sender is under, if any.
+	CheckSender(context.Context, *CheckSenderRequest) (*CheckSenderResponse, error)
+	// EraseSender erases a sender's messages in a room through the
+	// replicated store's leader.
+	EraseSender(context.Context, *EraseSenderRequest) (*EraseSenderResponse, error)
+	// Tombstone removes expired messages through the replicated store's
+	// leader.
+	Tombstone(context.Context, *TombstoneRequest) (*TombstoneResponse, error)
+	// Drop removes a room's oldest messages through the replicated store's
+	// leader.
+	Drop(context.Context, *DropRequest) (*DropResponse, error)
+	// SetRoomMember records a user joining or leaving a room through the
+	// replicated store's leader.
+	SetRoomMember(context.Context, *SetRoomMemberRequest) (*SetRoomMemberResponse, error)
+	mustEmbedUnimplementedClusterServiceServer()
+}
+
+// UnimplementedClusterServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClusterServiceServer struct{}
+
+func (UnimplementedClusterServiceServer) ForwardEvent(context.Context, *ForwardEventRequest) (*ForwardEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForwardEvent not implemented")
+}
+func (UnimplementedClusterServiceServer) TransferRoom(context.Context, *TransferRoomRequest) (*TransferRoomResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferRoom not implemented")
+}
+func (UnimplementedClusterServiceServer) AppendMessage(context.Context, *AppendMessageRequest) (*AppendMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendMessage not implemented")
+}
+func (UnimplementedClusterServiceServer) SetRoomSettings(context.Context, *SetRoomSettingsRequest) (*SetRoomSettingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRoomSettings not implemented")
+}
+func (UnimplementedClusterServiceServer) CheckSender(context.Context, *CheckSenderRequest) (*CheckSenderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSender not implemented")
+}
+func (UnimplementedClusterServiceServer) EraseSender(context.Context, *EraseSenderRequest) (*EraseSenderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseSender not implemented")
+}
+func (UnimplementedClusterServiceServer) Tombstone(context.Context, *TombstoneRequest) (*TombstoneResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Tombstone not implemented")
+}
+func (UnimplementedClusterServiceServer) Drop(context.Context, *DropRequest) (*DropResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Drop not implemented")
+}
+func (UnimplementedClusterServiceServer) SetRoomMember(context.Context, *SetRoomMemberRequest) (*SetRoomMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRoomMember not implemented")
+}
+func (UnimplementedClusterServiceServer) mustEmbedUnimplementedClusterServiceServer() {}
+func (UnimplementedClusterServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeClusterServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClusterServiceServer will
+// result in compilation errors.
+type UnsafeClusterServiceServer interface {
+	mustEmbedUnimplementedClusterServiceServer()
+}
+
+func RegisterClusterServiceServer(s grpc.ServiceRegistrar, srv ClusterServiceServer) {
+	// If the following call pancis, it indicates UnimplementedClusterServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ClusterService_ServiceDesc, srv)
+}
+
+func _ClusterService_ForwardEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForwardEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).ForwardEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_ForwardEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).ForwardEvent(ctx, req.(*ForwardEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_TransferRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).TransferRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_TransferRoom_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).TransferRoom(ctx, req.(*TransferRoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_AppendMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).AppendMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_AppendMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).AppendMessage(ctx, req.(*AppendMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_SetRoomSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRoomSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).SetRoomSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_SetRoomSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).SetRoomSettings(ctx, req.(*SetRoomSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_CheckSender_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckSenderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).CheckSender(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_CheckSender_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).CheckSender(ctx, req.(*CheckSenderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_EraseSender_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EraseSenderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).EraseSender(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_EraseSender_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).EraseSender(ctx, req.(*EraseSenderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_Tombstone_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TombstoneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).Tombstone(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_Tombstone_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).Tombstone(ctx, req.(*TombstoneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_Drop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DropRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).Drop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_Drop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).Drop(ctx, req.(*DropRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_SetRoomMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRoomMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).SetRoomMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_SetRoomMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).SetRoomMember(ctx, req.(*SetRoomMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ClusterService_ServiceDesc is the grpc.ServiceDesc for ClusterService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClusterService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.v1.ClusterService",
+	HandlerType: (*ClusterServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ForwardEvent",
+			Handler:    _ClusterService_ForwardEvent_Handler,
+		},
+		{
+			MethodName: "TransferRoom",
+			Handler:    _ClusterService_TransferRoom_Handler,
+		},
+		{
+			MethodName: "AppendMessage",
+			Handler:    _ClusterService_AppendMessage_Handler,
+		},
+		{
+			MethodName: "SetRoomSettings",
+			Handler:    _ClusterService_SetRoomSettings_Handler,
+		},
+		{
+			MethodName: "CheckSender",
+			Handler:    _ClusterService_CheckSender_Handler,
+		},
+		{
+			MethodName: "EraseSender",
+			Handler:    _ClusterService_EraseSender_Handler,
+		},
+		{
+			MethodName: "Tombstone",
+			Handler:    _ClusterService_Tombstone_Handler,
+		},
+		{
+			MethodName: "Drop",
+			Handler:    _ClusterService_Drop_Handler,
+		},
+		{
+			MethodName: "SetRoomMember",
+			Handler:    _ClusterService_SetRoomMember_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cluster.proto",
+}
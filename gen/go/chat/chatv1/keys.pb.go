@@ -0,0 +1,1343 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.1
+// source: keys.proto
+
+package chatv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// PreKey is a one-time X25519 prekey, used for one envelope.
+type PreKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"` // from 1
+	PublicKey     []byte                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreKey) Reset() {
+	*x = PreKey{}
+	mi := &file_keys_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreKey) ProtoMessage() {}
+
+func (x *PreKey) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreKey.ProtoReflect.Descriptor instead.
+func (*PreKey) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PreKey) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PreKey) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+// SignedPreKey is a medium-term X25519 prekey signed by the user's
+// signing key.
+type SignedPreKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	PublicKey     []byte                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Signature     []byte                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"` // Ed25519 over public_key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignedPreKey) Reset() {
+	*x = SignedPreKey{}
+	mi := &file_keys_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignedPreKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedPreKey) ProtoMessage() {}
+
+func (x *SignedPreKey) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedPreKey.ProtoReflect.Descriptor instead.
+func (*SignedPreKey) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SignedPreKey) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SignedPreKey) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *SignedPreKey) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+type KeyBundle struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	UserId       string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	IdentityKey  []byte                 `protobuf:"bytes,2,opt,name=identity_key,json=identityKey,proto3" json:"identity_key,omitempty"` // X25519; its fingerprint is what users compare
+	SigningKey   []byte                 `protobuf:"bytes,3,opt,name=signing_key,json=signingKey,proto3" json:"signing_key,omitempty"`    // Ed25519
+	SignedPrekey *SignedPreKey          `protobuf:"bytes,4,opt,name=signed_prekey,json=signedPrekey,proto3" json:"signed_prekey,omitempty"`
+	// one_time_prekeys are added to the ones already published. A fetched
+	// bundle carries at most one, which the server then forgets.
+	OneTimePrekeys []*PreKey `protobuf:"bytes,5,rep,name=one_time_prekeys,json=oneTimePrekeys,proto3" json:"one_time_prekeys,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *KeyBundle) Reset() {
+	*x = KeyBundle{}
+	mi := &file_keys_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyBundle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyBundle) ProtoMessage() {}
+
+func (x *KeyBundle) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyBundle.ProtoReflect.Descriptor instead.
+func (*KeyBundle) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KeyBundle) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *KeyBundle) GetIdentityKey() []byte {
+	if x != nil {
+		return x.IdentityKey
+	}
+	return nil
+}
+
+func (x *KeyBundle) GetSigningKey() []byte {
+	if x != nil {
+		return x.SigningKey
+	}
+	return nil
+}
+
+func (x *KeyBundle) GetSignedPrekey() *SignedPreKey {
+	if x != nil {
+		return x.SignedPrekey
+	}
+	return nil
+}
+
+func (x *KeyBundle) GetOneTimePrekeys() []*PreKey {
+	if x != nil {
+		return x.OneTimePrekeys
+	}
+	return nil
+}
+
+// KeyEnvelope carries a SenderKeyDistribution from one member to another,
+// sealed with X3DH against the recipient's bundle.
+type KeyEnvelope struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SenderId        string                 `protobuf:"bytes,1,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	RecipientId     string                 `protobuf:"bytes,2,opt,name=recipient_id,json=recipientId,proto3" json:"recipient_id,omitempty"`
+	IdentityKey     []byte                 `protobuf:"bytes,3,opt,name=identity_key,json=identityKey,proto3" json:"identity_key,omitempty"` // the sender's
+	EphemeralKey    []byte                 `protobuf:"bytes,4,opt,name=ephemeral_key,json=ephemeralKey,proto3" json:"ephemeral_key,omitempty"`
+	SignedPrekeyId  uint32                 `protobuf:"varint,5,opt,name=signed_prekey_id,json=signedPrekeyId,proto3" json:"signed_prekey_id,omitempty"`
+	OneTimePrekeyId uint32                 `protobuf:"varint,6,opt,name=one_time_prekey_id,json=oneTimePrekeyId,proto3" json:"one_time_prekey_id,omitempty"` // 0 when the bundle had none left
+	Ciphertext      []byte                 `protobuf:"bytes,7,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	SentAt          *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *KeyEnvelope) Reset() {
+	*x = KeyEnvelope{}
+	mi := &file_keys_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyEnvelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyEnvelope) ProtoMessage() {}
+
+func (x *KeyEnvelope) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyEnvelope.ProtoReflect.Descriptor instead.
+func (*KeyEnvelope) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KeyEnvelope) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *KeyEnvelope) GetRecipientId() string {
+	if x != nil {
+		return x.RecipientId
+	}
+	return ""
+}
+
+func (x *KeyEnvelope) GetIdentityKey() []byte {
+	if x != nil {
+		return x.IdentityKey
+	}
+	return nil
+}
+
+func (x *KeyEnvelope) GetEphemeralKey() []byte {
+	if x != nil {
+		return x.EphemeralKey
+	}
+	return nil
+}
+
+func (x *KeyEnvelope) GetSignedPrekeyId() uint32 {
+	if x != nil {
+		return x.SignedPrekeyId
+	}
+	return 0
+}
+
+func (x *KeyEnvelope) GetOneTimePrekeyId() uint32 {
+	if x != nil {
+		return x.OneTimePrekeyId
+	}
+	return 0
+}
+
+func (x *KeyEnvelope) GetCiphertext() []byte {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return nil
+}
+
+func (x *KeyEnvelope) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+// SenderKeyDistribution is the plaintext of a KeyEnvelope: what a member
+// needs to read one sender's messages in a room from iteration on. The
+// server never sees it.
+type SenderKeyDistribution struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	KeyId         string                 `protobuf:"bytes,2,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	Iteration     uint32                 `protobuf:"varint,3,opt,name=iteration,proto3" json:"iteration,omitempty"`
+	ChainKey      []byte                 `protobuf:"bytes,4,opt,name=chain_key,json=chainKey,proto3" json:"chain_key,omitempty"`
+	SigningKey    []byte                 `protobuf:"bytes,5,opt,name=signing_key,json=signingKey,proto3" json:"signing_key,omitempty"` // Ed25519 public key messages are signed with
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SenderKeyDistribution) Reset() {
+	*x = SenderKeyDistribution{}
+	mi := &file_keys_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SenderKeyDistribution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SenderKeyDistribution) ProtoMessage() {}
+
+func (x *SenderKeyDistribution) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SenderKeyDistribution.ProtoReflect.Descriptor instead.
+func (*SenderKeyDistribution) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SenderKeyDistribution) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *SenderKeyDistribution) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *SenderKeyDistribution) GetIteration() uint32 {
+	if x != nil {
+		return x.Iteration
+	}
+	return 0
+}
+
+func (x *SenderKeyDistribution) GetChainKey() []byte {
+	if x != nil {
+		return x.ChainKey
+	}
+	return nil
+}
+
+func (x *SenderKeyDistribution) GetSigningKey() []byte {
+	if x != nil {
+		return x.SigningKey
+	}
+	return nil
+}
+
+// SigningKey is an Ed25519 key a user signs their messages with.
+type SigningKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyId         string                 `protobuf:"bytes,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"` // hex of the first 8 bytes of SHA-256(public_key)
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PublicKey     []byte                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	RevokedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SigningKey) Reset() {
+	*x = SigningKey{}
+	mi := &file_keys_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SigningKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SigningKey) ProtoMessage() {}
+
+func (x *SigningKey) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SigningKey.ProtoReflect.Descriptor instead.
+func (*SigningKey) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SigningKey) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *SigningKey) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SigningKey) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *SigningKey) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *SigningKey) GetRevokedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RevokedAt
+	}
+	return nil
+}
+
+type PublishKeyBundleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bundle        *KeyBundle             `protobuf:"bytes,1,opt,name=bundle,proto3" json:"bundle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishKeyBundleRequest) Reset() {
+	*x = PublishKeyBundleRequest{}
+	mi := &file_keys_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishKeyBundleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishKeyBundleRequest) ProtoMessage() {}
+
+func (x *PublishKeyBundleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishKeyBundleRequest.ProtoReflect.Descriptor instead.
+func (*PublishKeyBundleRequest) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PublishKeyBundleRequest) GetBundle() *KeyBundle {
+	if x != nil {
+		return x.Bundle
+	}
+	return nil
+}
+
+type PublishKeyBundleResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OneTimePrekeys uint32                 `protobuf:"varint,1,opt,name=one_time_prekeys,json=oneTimePrekeys,proto3" json:"one_time_prekeys,omitempty"` // left on the server
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PublishKeyBundleResponse) Reset() {
+	*x = PublishKeyBundleResponse{}
+	mi := &file_keys_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishKeyBundleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishKeyBundleResponse) ProtoMessage() {}
+
+func (x *PublishKeyBundleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishKeyBundleResponse.ProtoReflect.Descriptor instead.
+func (*PublishKeyBundleResponse) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PublishKeyBundleResponse) GetOneTimePrekeys() uint32 {
+	if x != nil {
+		return x.OneTimePrekeys
+	}
+	return 0
+}
+
+type GetKeyBundleRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// without_one_time_prekey looks the bundle up without claiming a
+	// one-time prekey, to check an identity key.
+	WithoutOneTimePrekey bool `protobuf:"varint,2,opt,name=without_one_time_prekey,json=withoutOneTimePrekey,proto3" json:"without_one_time_prekey,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *GetKeyBundleRequest) Reset() {
+	*x = GetKeyBundleRequest{}
+	mi := &file_keys_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetKeyBundleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetKeyBundleRequest) ProtoMessage() {}
+
+func (x *GetKeyBundleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetKeyBundleRequest.ProtoReflect.Descriptor instead.
+func (*GetKeyBundleRequest) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetKeyBundleRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetKeyBundleRequest) GetWithoutOneTimePrekey() bool {
+	if x != nil {
+		return x.WithoutOneTimePrekey
+	}
+	return false
+}
+
+type GetKeyBundleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bundle        *KeyBundle             `protobuf:"bytes,1,opt,name=bundle,proto3" json:"bundle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetKeyBundleResponse) Reset() {
+	*x = GetKeyBundleResponse{}
+	mi := &file_keys_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetKeyBundleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetKeyBundleResponse) ProtoMessage() {}
+
+func (x *GetKeyBundleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetKeyBundleResponse.ProtoReflect.Descriptor instead.
+func (*GetKeyBundleResponse) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetKeyBundleResponse) GetBundle() *KeyBundle {
+	if x != nil {
+		return x.Bundle
+	}
+	return nil
+}
+
+type SendKeyEnvelopesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Envelopes     []*KeyEnvelope         `protobuf:"bytes,1,rep,name=envelopes,proto3" json:"envelopes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendKeyEnvelopesRequest) Reset() {
+	*x = SendKeyEnvelopesRequest{}
+	mi := &file_keys_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendKeyEnvelopesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendKeyEnvelopesRequest) ProtoMessage() {}
+
+func (x *SendKeyEnvelopesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendKeyEnvelopesRequest.ProtoReflect.Descriptor instead.
+func (*SendKeyEnvelopesRequest) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SendKeyEnvelopesRequest) GetEnvelopes() []*KeyEnvelope {
+	if x != nil {
+		return x.Envelopes
+	}
+	return nil
+}
+
+type SendKeyEnvelopesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendKeyEnvelopesResponse) Reset() {
+	*x = SendKeyEnvelopesResponse{}
+	mi := &file_keys_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendKeyEnvelopesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendKeyEnvelopesResponse) ProtoMessage() {}
+
+func (x *SendKeyEnvelopesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendKeyEnvelopesResponse.ProtoReflect.Descriptor instead.
+func (*SendKeyEnvelopesResponse) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{11}
+}
+
+type FetchKeyEnvelopesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchKeyEnvelopesRequest) Reset() {
+	*x = FetchKeyEnvelopesRequest{}
+	mi := &file_keys_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchKeyEnvelopesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchKeyEnvelopesRequest) ProtoMessage() {}
+
+func (x *FetchKeyEnvelopesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchKeyEnvelopesRequest.ProtoReflect.Descriptor instead.
+func (*FetchKeyEnvelopesRequest) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FetchKeyEnvelopesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type FetchKeyEnvelopesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Envelopes     []*KeyEnvelope         `protobuf:"bytes,1,rep,name=envelopes,proto3" json:"envelopes,omitempty"` // oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchKeyEnvelopesResponse) Reset() {
+	*x = FetchKeyEnvelopesResponse{}
+	mi := &file_keys_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchKeyEnvelopesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchKeyEnvelopesResponse) ProtoMessage() {}
+
+func (x *FetchKeyEnvelopesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchKeyEnvelopesResponse.ProtoReflect.Descriptor instead.
+func (*FetchKeyEnvelopesResponse) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *FetchKeyEnvelopesResponse) GetEnvelopes() []*KeyEnvelope {
+	if x != nil {
+		return x.Envelopes
+	}
+	return nil
+}
+
+type RegisterSigningKeyRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	UserId    string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PublicKey []byte                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	// Once a user has a key, a new one must be endorsed by one of theirs:
+	// endorsement is its signature over the new key (see package msgsign).
+	EndorsedBy    string `protobuf:"bytes,3,opt,name=endorsed_by,json=endorsedBy,proto3" json:"endorsed_by,omitempty"`
+	Endorsement   []byte `protobuf:"bytes,4,opt,name=endorsement,proto3" json:"endorsement,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterSigningKeyRequest) Reset() {
+	*x = RegisterSigningKeyRequest{}
+	mi := &file_keys_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterSigningKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterSigningKeyRequest) ProtoMessage() {}
+
+func (x *RegisterSigningKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterSigningKeyRequest.ProtoReflect.Descriptor instead.
+func (*RegisterSigningKeyRequest) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RegisterSigningKeyRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RegisterSigningKeyRequest) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *RegisterSigningKeyRequest) GetEndorsedBy() string {
+	if x != nil {
+		return x.EndorsedBy
+	}
+	return ""
+}
+
+func (x *RegisterSigningKeyRequest) GetEndorsement() []byte {
+	if x != nil {
+		return x.Endorsement
+	}
+	return nil
+}
+
+type RegisterSigningKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           *SigningKey            `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterSigningKeyResponse) Reset() {
+	*x = RegisterSigningKeyResponse{}
+	mi := &file_keys_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterSigningKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterSigningKeyResponse) ProtoMessage() {}
+
+func (x *RegisterSigningKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterSigningKeyResponse.ProtoReflect.Descriptor instead.
+func (*RegisterSigningKeyResponse) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RegisterSigningKeyResponse) GetKey() *SigningKey {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+type ListSigningKeysRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	IncludeRevoked bool                   `protobuf:"varint,2,opt,name=include_revoked,json=includeRevoked,proto3" json:"include_revoked,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListSigningKeysRequest) Reset() {
+	*x = ListSigningKeysRequest{}
+	mi := &file_keys_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSigningKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSigningKeysRequest) ProtoMessage() {}
+
+func (x *ListSigningKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSigningKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListSigningKeysRequest) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListSigningKeysRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListSigningKeysRequest) GetIncludeRevoked() bool {
+	if x != nil {
+		return x.IncludeRevoked
+	}
+	return false
+}
+
+type ListSigningKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*SigningKey          `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"` // oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSigningKeysResponse) Reset() {
+	*x = ListSigningKeysResponse{}
+	mi := &file_keys_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSigningKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSigningKeysResponse) ProtoMessage() {}
+
+func (x *ListSigningKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSigningKeysResponse.ProtoReflect.Descriptor instead.
+func (*ListSigningKeysResponse) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListSigningKeysResponse) GetKeys() []*SigningKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type RevokeSigningKeyRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	KeyId  string                 `protobuf:"bytes,2,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	// signed_by is one of the user's keys, the revoked one included, and
+	// signature its signature over the revocation (see package msgsign).
+	SignedBy      string `protobuf:"bytes,3,opt,name=signed_by,json=signedBy,proto3" json:"signed_by,omitempty"`
+	Signature     []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeSigningKeyRequest) Reset() {
+	*x = RevokeSigningKeyRequest{}
+	mi := &file_keys_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeSigningKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeSigningKeyRequest) ProtoMessage() {}
+
+func (x *RevokeSigningKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeSigningKeyRequest.ProtoReflect.Descriptor instead.
+func (*RevokeSigningKeyRequest) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RevokeSigningKeyRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RevokeSigningKeyRequest) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *RevokeSigningKeyRequest) GetSignedBy() string {
+	if x != nil {
+		return x.SignedBy
+	}
+	return ""
+}
+
+func (x *RevokeSigningKeyRequest) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+type RevokeSigningKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           *SigningKey            `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeSigningKeyResponse) Reset() {
+	*x = RevokeSigningKeyResponse{}
+	mi := &file_keys_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeSigningKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeSigningKeyResponse) ProtoMessage() {}
+
+func (x *RevokeSigningKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keys_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeSigningKeyResponse.ProtoReflect.Descriptor instead.
+func (*RevokeSigningKeyResponse) Descriptor() ([]byte, []int) {
+	return file_keys_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RevokeSigningKeyResponse) GetKey() *SigningKey {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+var File_keys_proto protoreflect.FileDescriptor
+
+const file_keys_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"keys.proto\x12\achat.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"7\n" +
+	"\x06PreKey\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\fR\tpublicKey\"[\n" +
+	"\fSignedPreKey\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\fR\tpublicKey\x12\x1c\n" +
+	"\tsignature\x18\x03 \x01(\fR\tsignature\"\xdf\x01\n" +
+	"\tKeyBundle\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12!\n" +
+	"\fidentity_key\x18\x02 \x01(\fR\videntityKey\x12\x1f\n" +
+	"\vsigning_key\x18\x03 \x01(\fR\n" +
+	"signingKey\x12:\n" +
+	"\rsigned_prekey\x18\x04 \x01(\v2\x15.chat.v1.SignedPreKeyR\fsignedPrekey\x129\n" +
+	"\x10one_time_prekeys\x18\x05 \x03(\v2\x0f.chat.v1.PreKeyR\x0eoneTimePrekeys\"\xc1\x02\n" +
+	"\vKeyEnvelope\x12\x1b\n" +
+	"\tsender_id\x18\x01 \x01(\tR\bsenderId\x12!\n" +
+	"\frecipient_id\x18\x02 \x01(\tR\vrecipientId\x12!\n" +
+	"\fidentity_key\x18\x03 \x01(\fR\videntityKey\x12#\n" +
+	"\rephemeral_key\x18\x04 \x01(\fR\fephemeralKey\x12(\n" +
+	"\x10signed_prekey_id\x18\x05 \x01(\rR\x0esignedPrekeyId\x12+\n" +
+	"\x12one_time_prekey_id\x18\x06 \x01(\rR\x0foneTimePrekeyId\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\a \x01(\fR\n" +
+	"ciphertext\x123\n" +
+	"\asent_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x06sentAt\"\xa3\x01\n" +
+	"\x15SenderKeyDistribution\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x15\n" +
+	"\x06key_id\x18\x02 \x01(\tR\x05keyId\x12\x1c\n" +
+	"\titeration\x18\x03 \x01(\rR\titeration\x12\x1b\n" +
+	"\tchain_key\x18\x04 \x01(\fR\bchainKey\x12\x1f\n" +
+	"\vsigning_key\x18\x05 \x01(\fR\n" +
+	"signingKey\"\xd1\x01\n" +
+	"\n" +
+	"SigningKey\x12\x15\n" +
+	"\x06key_id\x18\x01 \x01(\tR\x05keyId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\fR\tpublicKey\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"revoked_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\trevokedAt\"E\n" +
+	"\x17PublishKeyBundleRequest\x12*\n" +
+	"\x06bundle\x18\x01 \x01(\v2\x12.chat.v1.KeyBundleR\x06bundle\"D\n" +
+	"\x18PublishKeyBundleResponse\x12(\n" +
+	"\x10one_time_prekeys\x18\x01 \x01(\rR\x0eoneTimePrekeys\"e\n" +
+	"\x13GetKeyBundleRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x125\n" +
+	"\x17without_one_time_prekey\x18\x02 \x01(\bR\x14withoutOneTimePrekey\"B\n" +
+	"\x14GetKeyBundleResponse\x12*\n" +
+	"\x06bundle\x18\x01 \x01(\v2\x12.chat.v1.KeyBundleR\x06bundle\"M\n" +
+	"\x17SendKeyEnvelopesRequest\x122\n" +
+	"\tenvelopes\x18\x01 \x03(\v2\x14.chat.v1.KeyEnvelopeR\tenvelopes\"\x1a\n" +
+	"\x18SendKeyEnvelopesResponse\"3\n" +
+	"\x18FetchKeyEnvelopesRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"O\n" +
+	"\x19FetchKeyEnvelopesResponse\x122\n" +
+	"\tenvelopes\x18\x01 \x03(\v2\x14.chat.v1.KeyEnvelopeR\tenvelopes\"\x96\x01\n" +
+	"\x19RegisterSigningKeyRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\fR\tpublicKey\x12\x1f\n" +
+	"\vendorsed_by\x18\x03 \x01(\tR\n" +
+	"endorsedBy\x12 \n" +
+	"\vendorsement\x18\x04 \x01(\fR\vendorsement\"C\n" +
+	"\x1aRegisterSigningKeyResponse\x12%\n" +
+	"\x03key\x18\x01 \x01(\v2\x13.chat.v1.SigningKeyR\x03key\"Z\n" +
+	"\x16ListSigningKeysRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0finclude_revoked\x18\x02 \x01(\bR\x0eincludeRevoked\"B\n" +
+	"\x17ListSigningKeysResponse\x12'\n" +
+	"\x04keys\x18\x01 \x03(\v2\x13.chat.v1.SigningKeyR\x04keys\"\x84\x01\n" +
+	"\x17RevokeSigningKeyRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x15\n" +
+	"\x06key_id\x18\x02 \x01(\tR\x05keyId\x12\x1b\n" +
+	"\tsigned_by\x18\x03 \x01(\tR\bsignedBy\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\fR\tsignature\"A\n" +
+	"\x18RevokeSigningKeyResponse\x12%\n" +
+	"\x03key\x18\x01 \x01(\v2\x13.chat.v1.SigningKeyR\x03key2\xf5\x04\n" +
+	"\n" +
+	"KeyService\x12W\n" +
+	"\x10PublishKeyBundle\x12 .chat.v1.PublishKeyBundleRequest\x1a!.chat.v1.PublishKeyBundleResponse\x12K\n" +
+	"\fGetKeyBundle\x12\x1c.chat.v1.GetKeyBundleRequest\x1a\x1d.chat.v1.GetKeyBundleResponse\x12W\n" +
+	"\x10SendKeyEnvelopes\x12 .chat.v1.SendKeyEnvelopesRequest\x1a!.chat.v1.SendKeyEnvelopesResponse\x12Z\n" +
+	"\x11FetchKeyEnvelopes\x12!.chat.v1.FetchKeyEnvelopesRequest\x1a\".chat.v1.FetchKeyEnvelopesResponse\x12]\n" +
+	"\x12RegisterSigningKey\x12\".chat.v1.RegisterSigningKeyRequest\x1a#.chat.v1.RegisterSigningKeyResponse\x12T\n" +
+	"\x0fListSigningKeys\x12\x1f.chat.v1.ListSigningKeysRequest\x1a .chat.v1.ListSigningKeysResponse\x12W\n" +
+	"\x10RevokeSigningKey\x12 .chat.v1.RevokeSigningKeyRequest\x1a!.chat.v1.RevokeSigningKeyResponseB\x1bZ\x19gen/go/chat/chatv1;chatv1b\x06proto3"
+
+var (
+	file_keys_proto_rawDescOnce sync.Once
+	file_keys_proto_rawDescData []byte
+)
+
+func file_keys_proto_rawDescGZIP() []byte {
+	file_keys_proto_rawDescOnce.Do(func() {
+		file_keys_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_keys_proto_rawDesc), len(file_keys_proto_rawDesc)))
+	})
+	return file_keys_proto_rawDescData
+}
+
+var file_keys_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_keys_proto_goTypes = []any{
+	(*PreKey)(nil),                     // 0: chat.v1.PreKey
+	(*SignedPreKey)(nil),               // 1: chat.v1.SignedPreKey
+	(*KeyBundle)(nil),                  // 2: chat.v1.KeyBundle
+	(*KeyEnvelope)(nil),                // 3: chat.v1.KeyEnvelope
+	(*SenderKeyDistribution)(nil),      // 4: chat.v1.SenderKeyDistribution
+	(*SigningKey)(nil),                 // 5: chat.v1.SigningKey
+	(*PublishKeyBundleRequest)(nil),    // 6: chat.v1.PublishKeyBundleRequest
+	(*PublishKeyBundleResponse)(nil),   // 7: chat.v1.PublishKeyBundleResponse
+	(*GetKeyBundleRequest)(nil),        // 8: chat.v1.GetKeyBundleRequest
+	(*GetKeyBundleResponse)(nil),       // 9: chat.v1.GetKeyBundleResponse
+	(*SendKeyEnvelopesRequest)(nil),    // 10: chat.v1.SendKeyEnvelopesRequest
+	(*SendKeyEnvelopesResponse)(nil),   // 11: chat.v1.SendKeyEnvelopesResponse
+	(*FetchKeyEnvelopesRequest)(nil),   // 12: chat.v1.FetchKeyEnvelopesRequest
+	(*FetchKeyEnvelopesResponse)(nil),  // 13: chat.v1.FetchKeyEnvelopesResponse
+	(*RegisterSigningKeyRequest)(nil),  // 14: chat.v1.RegisterSigningKeyRequest
+	(*RegisterSigningKeyResponse)(nil), // 15: chat.v1.RegisterSigningKeyResponse
+	(*ListSigningKeysRequest)(nil),     // 16: chat.v1.ListSigningKeysRequest
+	(*ListSigningKeysResponse)(nil),    // 17: chat.v1.ListSigningKeysResponse
+	(*RevokeSigningKeyRequest)(nil),    // 18: chat.v1.RevokeSigningKeyRequest
+	(*RevokeSigningKeyResponse)(nil),   // 19: chat.v1.RevokeSigningKeyResponse
+	(*timestamppb.Timestamp)(nil),      // 20: google.protobuf.Timestamp
+}
+var file_keys_proto_depIdxs = []int32{
+	1,  // 0: chat.v1.KeyBundle.signed_prekey:type_name -> chat.v1.SignedPreKey
+	0,  // 1: chat.v1.KeyBundle.one_time_prekeys:type_name -> chat.v1.PreKey
+	20, // 2: chat.v1.KeyEnvelope.sent_at:type_name -> google.protobuf.Timestamp
+	20, // 3: chat.v1.SigningKey.created_at:type_name -> google.protobuf.Timestamp
+	20, // 4: chat.v1.SigningKey.revoked_at:type_name -> google.protobuf.Timestamp
+	2,  // 5: chat.v1.PublishKeyBundleRequest.bundle:type_name -> chat.v1.KeyBundle
+	2,  // 6: chat.v1.GetKeyBundleResponse.bundle:type_name -> chat.v1.KeyBundle
+	3,  // 7: chat.v1.SendKeyEnvelopesRequest.envelopes:type_name -> chat.v1.KeyEnvelope
+	3,  // 8: chat.v1.FetchKeyEnvelopesResponse.envelopes:type_name -> chat.v1.KeyEnvelope
+	5,  // 9: chat.v1.RegisterSigningKeyResponse.key:type_name -> chat.v1.SigningKey
+	5,  // 10: chat.v1.ListSigningKeysResponse.keys:type_name -> chat.v1.SigningKey
+	5,  // 11: chat.v1.RevokeSigningKeyResponse.key:type_name -> chat.v1.SigningKey
+	6,  // 12: chat.v1.KeyService.PublishKeyBundle:input_type -> chat.v1.PublishKeyBundleRequest
+	8,  // 13: chat.v1.KeyService.GetKeyBundle:input_type -> chat.v1.GetKeyBundleRequest
+	10, // 14: chat.v1.KeyService.SendKeyEnvelopes:input_type -> chat.v1.SendKeyEnvelopesRequest
+	12, // 15: chat.v1.KeyService.FetchKeyEnvelopes:input_type -> chat.v1.FetchKeyEnvelopesRequest
+	14, // 16: chat.v1.KeyService.RegisterSigningKey:input_type -> chat.v1.RegisterSigningKeyRequest
+	16, // 17: chat.v1.KeyService.ListSigningKeys:input_type -> chat.v1.ListSigningKeysRequest
+	18, // 18: chat.v1.KeyService.RevokeSigningKey:input_type -> chat.v1.RevokeSigningKeyRequest
+	7,  // 19: chat.v1.KeyService.PublishKeyBundle:output_type -> chat.v1.PublishKeyBundleResponse
+	9,  // 20: chat.v1.KeyService.GetKeyBundle:output_type -> chat.v1.GetKeyBundleResponse
+	11, // 21: chat.v1.KeyService.SendKeyEnvelopes:output_type -> chat.v1.SendKeyEnvelopesResponse
+	13, // 22: chat.v1.KeyService.FetchKeyEnvelopes:output_type -> chat.v1.FetchKeyEnvelopesResponse
+	15, // 23: chat.v1.KeyService.RegisterSigningKey:output_type -> chat.v1.RegisterSigningKeyResponse
+	17, // 24: chat.v1.KeyService.ListSigningKeys:output_type -> chat.v1.ListSigningKeysResponse
+	19, // 25: chat.v1.KeyService.RevokeSigningKey:output_type -> chat.v1.RevokeSigningKeyResponse
+	19, // [19:26] is the sub-list for method output_type
+	12, // [12:19] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_keys_proto_init() }
+func file_keys_proto_init() {
+	if File_keys_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_keys_proto_rawDesc), len(file_keys_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_keys_proto_goTypes,
+		DependencyIndexes: file_keys_proto_depIdxs,
+		MessageInfos:      file_keys_proto_msgTypes,
+	}.Build()
+	File_keys_proto = out.File
+	file_keys_proto_goTypes = nil
+	file_keys_proto_depIdxs = nil
+}
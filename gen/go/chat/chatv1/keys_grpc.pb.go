@@ -0,0 +1,371 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.33.1
+// source: keys.proto
+
+package chatv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	KeyService_PublishKeyBundle_FullMethodName   = "/chat.v1.KeyService/PublishKeyBundle"
+	KeyService_GetKeyBundle_FullMethodName       = "/chat.v1.KeyService/GetKeyBundle"
+	KeyService_SendKeyEnvelopes_FullMethodName   = "/chat.v1.KeyService/SendKeyEnvelopes"
+	KeyService_FetchKeyEnvelopes_FullMethodName  = "/chat.v1.KeyService/FetchKeyEnvelopes"
+	KeyService_RegisterSigningKey_FullMethodName = "/chat.v1.KeyService/RegisterSigningKey"
+	KeyService_ListSigningKeys_FullMethodName    = "/chat.v1.KeyService/ListSigningKeys"
+	KeyService_RevokeSigningKey_FullMethodName   = "/chat.v1.KeyService/RevokeSigningKey"
+)
+
+// KeyServiceClient is the client API for KeyService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type KeyServiceClient interface {
+	// PublishKeyBundle replaces a user's identity and signed prekey and
+	// adds to their one-time prekeys.
+	PublishKeyBundle(ctx context.Context, in *PublishKeyBundleRequest, opts ...grpc.CallOption) (*PublishKeyBundleResponse, error)
+	// GetKeyBundle returns a user's bundle with one of their one-time
+	// prekeys, which no one else gets, unless without_one_time_prekey is
+	// set.
+	GetKeyBundle(ctx context.Context, in *GetKeyBundleRequest, opts ...grpc.CallOption) (*GetKeyBundleResponse, error)
+	// SendKeyEnvelopes leaves envelopes for their recipients.
+	SendKeyEnvelopes(ctx context.Context, in *SendKeyEnvelopesRequest, opts ...grpc.CallOption) (*SendKeyEnvelopesResponse, error)
+	// FetchKeyEnvelopes hands a user the envelopes left for them and
+	// forgets them.
+	FetchKeyEnvelopes(ctx context.Context, in *FetchKeyEnvelopesRequest, opts ...grpc.CallOption) (*FetchKeyEnvelopesResponse, error)
+	// RegisterSigningKey adds a key to the user's message signing keys.
+	// The first one is trusted as is; later ones need an endorsement.
+	RegisterSigningKey(ctx context.Context, in *RegisterSigningKeyRequest, opts ...grpc.CallOption) (*RegisterSigningKeyResponse, error)
+	ListSigningKeys(ctx context.Context, in *ListSigningKeysRequest, opts ...grpc.CallOption) (*ListSigningKeysResponse, error)
+	// RevokeSigningKey stops a key verifying new messages.
+	RevokeSigningKey(ctx context.Context, in *RevokeSigningKeyRequest, opts ...grpc.CallOption) (*RevokeSigningKeyResponse, error)
+}
+
+type keyServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKeyServiceClient(cc grpc.ClientConnInterface) KeyServiceClient {
+	return &keyServiceClient{cc}
+}
+
+func (c *keyServiceClient) PublishKeyBundle(ctx context.Context, in *PublishKeyBundleRequest, opts ...grpc.CallOption) (*PublishKeyBundleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishKeyBundleResponse)
+	err := c.cc.Invoke(ctx, KeyService_PublishKeyBundle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyServiceClient) GetKeyBundle(ctx context.Context, in *GetKeyBundleRequest, opts ...grpc.CallOption) (*GetKeyBundleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetKeyBundleResponse)
+	err := c.cc.Invoke(ctx, KeyService_GetKeyBundle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyServiceClient) SendKeyEnvelopes(ctx context.Context, in *SendKeyEnvelopesRequest, opts ...grpc.CallOption) (*SendKeyEnvelopesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendKeyEnvelopesResponse)
+	err := c.cc.Invoke(ctx, KeyService_SendKeyEnvelopes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyServiceClient) FetchKeyEnvelopes(ctx context.Context, in *FetchKeyEnvelopesRequest, opts ...grpc.CallOption) (*FetchKeyEnvelopesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FetchKeyEnvelopesResponse)
+	err := c.cc.Invoke(ctx, KeyService_FetchKeyEnvelopes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyServiceClient) RegisterSigningKey(ctx context.Context, in *RegisterSigningKeyRequest, opts ...grpc.CallOption) (*RegisterSigningKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterSigningKeyResponse)
+	err := c.cc.Invoke(ctx, KeyService_RegisterSigningKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyServiceClient) ListSigningKeys(ctx context.Context, in *ListSigningKeysRequest, opts ...grpc.CallOption) (*ListSigningKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSigningKeysResponse)
+	err := c.cc.Invoke(ctx, KeyService_ListSigningKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyServiceClient) RevokeSigningKey(ctx context.Context, in *RevokeSigningKeyRequest, opts ...grpc.CallOption) (*RevokeSigningKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeSigningKeyResponse)
+	err := c.cc.Invoke(ctx, KeyService_RevokeSigningKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KeyServiceServer is the server API for KeyService service.
+// All implementations must embed UnimplementedKeyServiceServer
+// for forward compatibility.
+type KeyServiceServer interface {
+	// PublishKeyBundle replaces a user's identity and signed prekey and
+	// adds to their one-time prekeys.
+	PublishKeyBundle(context.Context, *PublishKeyBundleRequest) (*PublishKeyBundleResponse, error)
+	// GetKeyBundle returns a user's bundle with one of their one-time
+	// prekeys, which no one else gets, unless without_one_time_prekey is
+	// set.
+	GetKeyBundle(context.Context, *GetKeyBundleRequest) (*GetKeyBundleResponse, error)
+	// SendKeyEnvelopes leaves envelopes for their recipients.
+	SendKeyEnvelopes(context.Context, *SendKeyEnvelopesRequest) (*SendKeyEnvelopesResponse, error)
+	// FetchKeyEnvelopes hands a user the envelopes left for them and
+	// forgets them.
+	FetchKeyEnvelopes(context.Context, *FetchKeyEnvelopesRequest) (*FetchKeyEnvelopesResponse, error)
+	// RegisterSigningKey adds a key to the user's message signing keys.
+	// The first one is trusted as is; later ones need an endorsement.
+	RegisterSigningKey(context.Context, *RegisterSigningKeyRequest) (*RegisterSigningKeyResponse, error)
+	ListSigningKeys(context.Context, *ListSigningKeysRequest) (*ListSigningKeysResponse, error)
+	// RevokeSigningKey stops a key verifying new messages.
+	RevokeSigningKey(context.Context, *RevokeSigningKeyRequest) (*RevokeSigningKeyResponse, error)
+	mustEmbedUnimplementedKeyServiceServer()
+}
+
+// UnimplementedKeyServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedKeyServiceServer struct{}
+
+func (UnimplementedKeyServiceServer) PublishKeyBundle(context.Context, *PublishKeyBundleRequest) (*PublishKeyBundleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishKeyBundle not implemented")
+}
+func (UnimplementedKeyServiceServer) GetKeyBundle(context.Context, *GetKeyBundleRequest) (*GetKeyBundleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetKeyBundle not implemented")
+}
+func (UnimplementedKeyServiceServer) SendKeyEnvelopes(context.Context, *SendKeyEnvelopesRequest) (*SendKeyEnvelopesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendKeyEnvelopes not implemented")
+}
+func (UnimplementedKeyServiceServer) FetchKeyEnvelopes(context.Context, *FetchKeyEnvelopesRequest) (*FetchKeyEnvelopesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchKeyEnvelopes not implemented")
+}
+func (UnimplementedKeyServiceServer) RegisterSigningKey(context.Context, *RegisterSigningKeyRequest) (*RegisterSigningKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterSigningKey not implemented")
+}
+func (UnimplementedKeyServiceServer) ListSigningKeys(context.Context, *ListSigningKeysRequest) (*ListSigningKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSigningKeys not implemented")
+}
+func (UnimplementedKeyServiceServer) RevokeSigningKey(context.Context, *RevokeSigningKeyRequest) (*RevokeSigningKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSigningKey not implemented")
+}
+func (UnimplementedKeyServiceServer) mustEmbedUnimplementedKeyServiceServer() {}
+func (UnimplementedKeyServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeKeyServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KeyServiceServer will
+// result in compilation errors.
+type UnsafeKeyServiceServer interface {
+	mustEmbedUnimplementedKeyServiceServer()
+}
+
+func RegisterKeyServiceServer(s grpc.ServiceRegistrar, srv KeyServiceServer) {
+	// If the following call pancis, it indicates UnimplementedKeyServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&KeyService_ServiceDesc, srv)
+}
+
+func _KeyService_PublishKeyBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishKeyBundleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyServiceServer).PublishKeyBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyService_PublishKeyBundle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyServiceServer).PublishKeyBundle(ctx, req.(*PublishKeyBundleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyService_GetKeyBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetKeyBundleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyServiceServer).GetKeyBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyService_GetKeyBundle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyServiceServer).GetKeyBundle(ctx, req.(*GetKeyBundleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyService_SendKeyEnvelopes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendKeyEnvelopesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyServiceServer).SendKeyEnvelopes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyService_SendKeyEnvelopes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyServiceServer).SendKeyEnvelopes(ctx, req.(*SendKeyEnvelopesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyService_FetchKeyEnvelopes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchKeyEnvelopesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyServiceServer).FetchKeyEnvelopes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyService_FetchKeyEnvelopes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyServiceServer).FetchKeyEnvelopes(ctx, req.(*FetchKeyEnvelopesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyService_RegisterSigningKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterSigningKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyServiceServer).RegisterSigningKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyService_RegisterSigningKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyServiceServer).RegisterSigningKey(ctx, req.(*RegisterSigningKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyService_ListSigningKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSigningKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyServiceServer).ListSigningKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyService_ListSigningKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyServiceServer).ListSigningKeys(ctx, req.(*ListSigningKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyService_RevokeSigningKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSigningKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyServiceServer).RevokeSigningKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyService_RevokeSigningKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyServiceServer).RevokeSigningKey(ctx, req.(*RevokeSigningKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// KeyService_ServiceDesc is the grpc.ServiceDesc for KeyService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var KeyService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.v1.KeyService",
+	HandlerType: (*KeyServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PublishKeyBundle",
+			Handler:    _KeyService_PublishKeyBundle_Handler,
+		},
+		{
+			MethodName: "GetKeyBundle",
+			Handler:    _KeyService_GetKeyBundle_Handler,
+		},
+		{
+			MethodName: "SendKeyEnvelopes",
+			Handler:    _KeyService_SendKeyEnvelopes_Handler,
+		},
+		{
+			MethodName: "FetchKeyEnvelopes",
+			Handler:    _KeyService_FetchKeyEnvelopes_Handler,
+		},
+		{
+			MethodName: "RegisterSigningKey",
+			Handler:    _KeyService_RegisterSigningKey_Handler,
+		},
+		{
+			MethodName: "ListSigningKeys",
+			Handler:    _KeyService_ListSigningKeys_Handler,
+		},
+		{
+			MethodName: "RevokeSigningKey",
+			Handler:    _KeyService_RevokeSigningKey_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "keys.proto",
+}
@@ -0,0 +1,231 @@
+// Package audit keeps a node's append-only log of administrative and
+// moderation actions. Every entry carries the SHA-256 of the entry before
+// it, so editing, dropping or reordering entries in the file breaks the
+// chain, and reading the log checks it end to end.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxLine bounds one entry of the file.
+const maxLine = 1 << 20
+
+// Log is one node's audit log: a file of JSON lines, one AuditEvent each.
+// Without a file the log is kept in memory, which is only good for
+// development.
+type Log struct {
+	mu     sync.Mutex
+	path   string
+	nodeID string
+	f      *os.File
+	events []*chatv1.AuditEvent // without a file only
+	seq    uint64
+	last   []byte // hash of the latest entry
+}
+
+// Open opens the log at path, creating it if needed, and continues its
+// chain. A chain that is already broken is logged; new entries still
+// follow the last one in the file.
+func Open(path, nodeID string) (*Log, error) {
+	l := &Log{path: path, nodeID: nodeID}
+	if path == "" {
+		return l, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	events, err := readFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if chain := Verify(events); !chain.Intact {
+		slog.Error("audit log chain is broken", "path", path, "broken_at", chain.BrokenAt, "err", chain.Error)
+	}
+	if len(events) > 0 {
+		tail := events[len(events)-1]
+		l.seq, l.last = tail.Sequence, tail.Hash
+	}
+	l.f, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record appends e, filling in its sequence, node, time if unset and
+// hashes. It returns once the entry is on disk.
+func (l *Log) Record(e *chatv1.AuditEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.Sequence = l.seq + 1
+	e.NodeId = l.nodeID
+	if e.Time == nil {
+		e.Time = timestamppb.Now()
+	}
+	e.PrevHash = l.last
+	hash, err := Hash(e)
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+
+	if l.f == nil {
+		l.events = append(l.events, proto.Clone(e).(*chatv1.AuditEvent))
+	} else {
+		line, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(e)
+		if err != nil {
+			return err
+		}
+		// protojson may space its output but never breaks lines; one
+		// entry per line is what readFile relies on.
+		if _, err := l.f.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		if err := l.f.Sync(); err != nil {
+			return err
+		}
+	}
+	l.seq, l.last = e.Sequence, e.Hash
+	return nil
+}
+
+// Read returns the entries matching f, oldest first, and the state of the
+// whole chain. The file is read back from disk, so changes made to it
+// behind the node's back show up as a broken chain.
+func (l *Log) Read(f Filter) ([]*chatv1.AuditEvent, *chatv1.AuditChain, error) {
+	var events []*chatv1.AuditEvent
+	if l.f == nil {
+		l.mu.Lock()
+		for _, e := range l.events {
+			events = append(events, proto.Clone(e).(*chatv1.AuditEvent))
+		}
+		l.mu.Unlock()
+	} else {
+		var err error
+		if events, err = readFile(l.path); err != nil {
+			return nil, nil, err
+		}
+	}
+	chain := Verify(events)
+	chain.NodeId = l.nodeID
+	return f.Apply(events), chain, nil
+}
+
+// Close closes the file of the log.
+func (l *Log) Close() error {
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+// Hash is the SHA-256 of e's previous hash and its deterministic protobuf
+// encoding without the hash itself.
+func Hash(e *chatv1.AuditEvent) ([]byte, error) {
+	unhashed := proto.Clone(e).(*chatv1.AuditEvent)
+	unhashed.Hash = nil
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(unhashed)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(e.PrevHash)
+	h.Write(b)
+	return h.Sum(nil), nil
+}
+
+// Verify checks that events number from 1 without gaps, each links to the
+// one before and each hash matches its entry.
+func Verify(events []*chatv1.AuditEvent) *chatv1.AuditChain {
+	chain := &chatv1.AuditChain{Events: uint64(len(events)), Intact: true}
+	var prev []byte
+	for i, e := range events {
+		var problem string
+		switch hash, err := Hash(e); {
+		case e.Sequence != uint64(i+1):
+			problem = fmt.Sprintf("entry %d has sequence %d", i+1, e.Sequence)
+		case !bytes.Equal(e.PrevHash, prev):
+			problem = fmt.Sprintf("entry %d does not follow the one before", e.Sequence)
+		case err != nil || !bytes.Equal(hash, e.Hash):
+			problem = fmt.Sprintf("entry %d does not match its hash", e.Sequence)
+		}
+		if problem != "" {
+			chain.Intact, chain.BrokenAt, chain.Error = false, uint64(i+1), problem
+			return chain
+		}
+		prev = e.Hash
+	}
+	return chain
+}
+
+// readFile parses every line of the log at path. A line that doesn't parse
+// is kept as an empty entry, which then fails verification in its place.
+func readFile(path string) ([]*chatv1.AuditEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []*chatv1.AuditEvent
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), maxLine)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		e := &chatv1.AuditEvent{}
+		if err := protojson.Unmarshal(sc.Bytes(), e); err != nil {
+			e = &chatv1.AuditEvent{}
+		}
+		events = append(events, e)
+	}
+	return events, sc.Err()
+}
+
+// ----- Filters -----
+
+// Filter picks entries by actor, room and time; zero fields match
+// everything. Limit keeps the latest entries.
+type Filter struct {
+	Actor  string
+	RoomID string
+	Since  time.Time // inclusive
+	Until  time.Time // exclusive
+	Limit  int
+}
+
+// Apply returns the events f matches, keeping their order.
+func (f Filter) Apply(events []*chatv1.AuditEvent) []*chatv1.AuditEvent {
+	var out []*chatv1.AuditEvent
+	for _, e := range events {
+		t := e.Time.AsTime()
+		switch {
+		case f.Actor != "" && e.Actor != f.Actor,
+			f.RoomID != "" && e.RoomId != f.RoomID,
+			!f.Since.IsZero() && t.Before(f.Since),
+			!f.Until.IsZero() && !t.Before(f.Until):
+			continue
+		}
+		out = append(out, e)
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+)
+
+// writeLog records four entries at path, closing and reopening the log
+// halfway so the chain has to continue from the file.
+func writeLog(t *testing.T, path string) {
+	t.Helper()
+	for _, actors := range [][]string{{"alice", "bob"}, {"alice", "carol"}} {
+		l, err := Open(path, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, actor := range actors {
+			if err := l.Record(&chatv1.AuditEvent{Actor: actor, Action: "room.settings", RoomId: "dev"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadChecksTheChain(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		tamper   func(lines [][]byte) [][]byte
+		events   uint64
+		brokenAt uint64 // 0 for an intact chain
+	}{
+		{"untouched", func(lines [][]byte) [][]byte { return lines }, 4, 0},
+		{"changed", func(lines [][]byte) [][]byte {
+			lines[1] = bytes.Replace(lines[1], []byte(`"bob"`), []byte(`"mallory"`), 1)
+			return lines
+		}, 4, 2},
+		{"removed", func(lines [][]byte) [][]byte { return slices.Delete(lines, 1, 2) }, 3, 2},
+		{"reordered", func(lines [][]byte) [][]byte {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		}, 4, 2},
+		{"garbled", func(lines [][]byte) [][]byte {
+			lines[3] = []byte("{not json")
+			return lines
+		}, 4, 4},
+	} {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		writeLog(t, path)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := tc.tamper(bytes.Split(bytes.TrimSpace(b), []byte("\n")))
+		if err := os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		l, err := Open(path, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, chain, err := l.Read(Filter{})
+		l.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chain.Events != tc.events || chain.Intact != (tc.brokenAt == 0) || chain.BrokenAt != tc.brokenAt {
+			t.Errorf("%s: chain = %d events, intact %t, broken at %d (%s); want %d events, broken at %d",
+				tc.name, chain.Events, chain.Intact, chain.BrokenAt, chain.Error, tc.events, tc.brokenAt)
+		}
+	}
+}
+
+func TestVerifyInMemory(t *testing.T) {
+	l, err := Open("", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, actor := range []string{"alice", "bob", "carol"} {
+		if err := l.Record(&chatv1.AuditEvent{Actor: actor, Action: "user.erase"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, chain, err := l.Read(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chain.Intact || chain.Events != 3 {
+		t.Fatalf("chain = %d events, intact %t (%s); want 3, intact", chain.Events, chain.Intact, chain.Error)
+	}
+
+	// Read hands out copies, so changing them leaves the log intact.
+	events[0].Actor = "mallory"
+	if chain := Verify(events); chain.Intact || chain.BrokenAt != 1 {
+		t.Errorf("changed copy: intact %t, broken at %d; want broken at 1", chain.Intact, chain.BrokenAt)
+	}
+	if _, chain, _ := l.Read(Filter{}); !chain.Intact {
+		t.Errorf("log broken after changing a copy: %s", chain.Error)
+	}
+}
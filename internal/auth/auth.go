@@ -0,0 +1,86 @@
+// Package auth carries the users the gateways authenticated to the server.
+// A gateway checks a bearer token or a ticket and names the user in the
+// UserKey metadata of its calls; the server acts for that user and ignores
+// any user id in the request itself.
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// UserKey is the metadata naming the user a call is made for. Anyone who
+// can call the server can set it, so only the gateways should be let in;
+// see tls.allowed_clients.
+const UserKey = "x-gochat-user"
+
+// WithUser names userID in the outgoing metadata of ctx; an empty userID
+// leaves ctx as is.
+func WithUser(ctx context.Context, userID string) context.Context {
+	if userID == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, UserKey, userID)
+}
+
+// UserFrom returns the user an incoming call is made for.
+func UserFrom(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(UserKey); len(v) > 0 && v[0] != "" {
+		return v[0], true
+	}
+	return "", false
+}
+
+// ----- API tokens -----
+
+// TokenAuth authenticates REST calls by bearer token. Tokens are loaded from
+// a file with one "<token> <user_id>" pair per line; blank lines and lines
+// starting with # are ignored.
+type TokenAuth struct {
+	tokens map[string]string // token → user id
+}
+
+func LoadTokenAuth(path string) (*TokenAuth, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	a := &TokenAuth{tokens: make(map[string]string)}
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want \"<token> <user_id>\"", path, line)
+		}
+		a.tokens[fields[0]] = fields[1]
+	}
+	return a, sc.Err()
+}
+
+// Authenticate returns the user for the request's bearer token.
+func (a *TokenAuth) Authenticate(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	for t, user := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return user, true
+		}
+	}
+	return "", false
+}
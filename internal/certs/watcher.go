@@ -0,0 +1,216 @@
+// Package certs keeps TLS certificates loaded from PEM files, reloading
+// them when the files change, and issues certificates from a local CA.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Files names the PEM files a Watcher keeps loaded. Cert and Key are a key
+// pair to present; CA holds the certificates that verify the other side.
+// Any of them may be empty.
+type Files struct {
+	Cert string
+	Key  string
+	CA   string
+}
+
+// Watcher holds the current key pair and CA pool. Handshakes always use
+// the latest files, so rotating certificates never drops a connection
+// that is already established.
+type Watcher struct {
+	files Files
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+}
+
+// NewWatcher loads files; Run keeps them fresh.
+func NewWatcher(files Files) (*Watcher, error) {
+	if (files.Cert == "") != (files.Key == "") {
+		return nil, errors.New("a certificate needs both a cert and a key file")
+	}
+	w := &Watcher{files: files, modTime: make(map[string]time.Time)}
+	if _, err := w.reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Run checks the files every interval until ctx is done and reloads them
+// when one has changed. A file that fails to load is logged and the
+// previous certificates are kept.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		changed, err := w.reload()
+		if err != nil {
+			slog.Error("failed to reload TLS files, keeping current ones", "err", err)
+		} else if changed {
+			slog.Info("reloaded TLS files", "files", w.describe())
+		}
+	}
+}
+
+// reload reads the files again if any of them changed since the last load.
+func (w *Watcher) reload() (bool, error) {
+	mod := make(map[string]time.Time)
+	changed := false
+	for _, path := range []string{w.files.Cert, w.files.Key, w.files.CA} {
+		if path == "" {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		mod[path] = fi.ModTime()
+		w.mu.RLock()
+		if prev, ok := w.modTime[path]; !ok || !prev.Equal(fi.ModTime()) {
+			changed = true
+		}
+		w.mu.RUnlock()
+	}
+	if !changed {
+		return false, nil
+	}
+
+	var cert *tls.Certificate
+	if w.files.Cert != "" {
+		c, err := tls.LoadX509KeyPair(w.files.Cert, w.files.Key)
+		if err != nil {
+			return false, fmt.Errorf("load key pair %s: %w", w.files.Cert, err)
+		}
+		cert = &c
+	}
+	var pool *x509.CertPool
+	if w.files.CA != "" {
+		pem, err := os.ReadFile(w.files.CA)
+		if err != nil {
+			return false, err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("%s: no certificates found", w.files.CA)
+		}
+	}
+
+	w.mu.Lock()
+	w.cert, w.pool, w.modTime = cert, pool, mod
+	w.mu.Unlock()
+	return true, nil
+}
+
+func (w *Watcher) describe() string {
+	var names []string
+	for _, path := range []string{w.files.Cert, w.files.Key, w.files.CA} {
+		if path != "" {
+			names = append(names, path)
+		}
+	}
+	return fmt.Sprint(names)
+}
+
+func (w *Watcher) certificate() (*tls.Certificate, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.cert == nil {
+		return nil, errors.New("no certificate configured")
+	}
+	return w.cert, nil
+}
+
+// Pool returns the current CA pool, nil without a CA file.
+func (w *Watcher) Pool() *x509.CertPool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.pool
+}
+
+// ServerTLS returns a server config presenting the watched certificate.
+// With a CA file, clients must present a certificate it signed.
+func (w *Watcher) ServerTLS() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return w.certificate()
+		},
+	}
+	if w.files.CA == "" {
+		return base
+	}
+	base.ClientAuth = tls.RequireAndVerifyClientCert
+	// ClientCAs can't change on a live config, so each handshake gets a
+	// copy with the current pool. NextProtos is read at handshake time
+	// because http2.ConfigureServer fills it in after this returns.
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientCAs = w.Pool()
+		return cfg, nil
+	}
+	return base
+}
+
+// PeerNames returns the DNS and URI SANs of a verified client certificate,
+// falling back to its common name when it has none.
+func PeerNames(cert *x509.Certificate) []string {
+	names := append([]string(nil), cert.DNSNames...)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	if len(names) == 0 && cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return names
+}
+
+// ClientTLS returns a client config that verifies the server as serverName
+// against the watched CA and, with a key pair, presents it as the client
+// certificate.
+func (w *Watcher) ClientTLS(serverName string) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// Verification happens in VerifyConnection against the current
+		// pool; RootCAs would pin the pool loaded at startup.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+			opts := x509.VerifyOptions{
+				DNSName:       serverName,
+				Roots:         w.Pool(),
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, c := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(c)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+	if w.files.Cert != "" {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return w.certificate()
+		}
+	}
+	return cfg
+}
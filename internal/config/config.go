@@ -0,0 +1,529 @@
+// Package config is the configuration shared by the server, the REST client
+// and the WebSocket gateway. Each binary loads the sections it uses from
+// defaults, a YAML or TOML file, GOCHAT_* environment variables and flags,
+// in that order of precedence.
+//
+// Fields tagged reload:"true" are safe to change at runtime and are
+// re-applied on SIGHUP; changes to any other field need a restart.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// Section names, as used in files and passed to NewLoader.
+const (
+	SectionTLS        = "tls"
+	SectionBackend    = "backend"
+	SectionServer     = "server"
+	SectionREST       = "rest"
+	SectionWebSocket  = "websocket"
+	SectionTracing    = "tracing"
+	SectionLog        = "log"
+	SectionRateLimit  = "rate_limit"
+	SectionModeration = "moderation"
+	SectionSpam       = "spam"
+)
+
+type Config struct {
+	TLS        TLSConfig        `yaml:"tls" toml:"tls"`
+	Backend    BackendConfig    `yaml:"backend" toml:"backend"`
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	REST       RESTConfig       `yaml:"rest" toml:"rest"`
+	WebSocket  WebSocketConfig  `yaml:"websocket" toml:"websocket"`
+	Tracing    TracingConfig    `yaml:"tracing" toml:"tracing"`
+	Log        LogConfig        `yaml:"log" toml:"log"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit" toml:"rate_limit"`
+	Moderation ModerationConfig `yaml:"moderation" toml:"moderation"`
+	Spam       SpamConfig       `yaml:"spam" toml:"spam"`
+}
+
+// TLSConfig holds the server certificate, what clients trust to verify
+// it and, for mutual TLS, the client certificates. Certificate files are
+// reloaded when they change, whatever the reload tags say.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file" flag:"tls-cert" usage:"server certificate"`
+	KeyFile  string `yaml:"key_file" toml:"key_file" flag:"tls-key" usage:"server private key"`
+	// CAFile verifies the server when dialing it: the gateways' backend
+	// connection and node to node traffic.
+	CAFile     string `yaml:"ca_file" toml:"ca_file" flag:"tls-ca" usage:"certificate trusted when dialing the server"`
+	ServerName string `yaml:"server_name" toml:"server_name" flag:"tls-server-name" usage:"name expected in the server certificate"`
+
+	// ClientCertFile and ClientKeyFile are presented when dialing the
+	// server, by gateways, admin commands and other nodes.
+	ClientCertFile string `yaml:"client_cert_file" toml:"client_cert_file" flag:"tls-client-cert" usage:"client certificate presented when dialing the server"`
+	ClientKeyFile  string `yaml:"client_key_file" toml:"client_key_file" flag:"tls-client-key" usage:"client private key"`
+	// ClientCAFile turns on mutual TLS: the server only accepts clients
+	// with a certificate it signed.
+	ClientCAFile string `yaml:"client_ca_file" toml:"client_ca_file" flag:"tls-client-ca" usage:"CA that signs client certificates; setting it makes the server require one"`
+	// AllowedClients narrows mutual TLS to certificates carrying one of
+	// these DNS or URI SANs. Empty accepts any certificate ClientCAFile
+	// signed.
+	AllowedClients []string `yaml:"allowed_clients" toml:"allowed_clients" flag:"tls-allowed-clients" usage:"comma separated client certificate SANs allowed to call the server (default: any signed by the client CA)" reload:"true"`
+	// PeerClients are the client certificate SANs of the other nodes.
+	// Only callers presenting one may call ClusterService or have a call
+	// taken as forwarded by a node.
+	PeerClients []string `yaml:"peer_clients" toml:"peer_clients" flag:"tls-peer-clients" usage:"comma separated client certificate SANs of the other server nodes" reload:"true"`
+	// ReloadInterval is how often certificate files are checked for
+	// changes.
+	ReloadInterval time.Duration `yaml:"reload_interval" toml:"reload_interval" flag:"tls-reload-interval" usage:"how often certificate files are checked for changes"`
+}
+
+// BackendConfig is how the gateways reach the server.
+type BackendConfig struct {
+	Addr           string        `yaml:"addr" toml:"addr" flag:"backend-addr" usage:"gRPC target of the chat server"`
+	RequestTimeout time.Duration `yaml:"request_timeout" toml:"request_timeout" flag:"backend-request-timeout" usage:"deadline for unary backend calls" reload:"true"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr" toml:"addr" flag:"addr" usage:"address to listen on"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" flag:"shutdown-timeout" usage:"how long in-flight calls get to finish on shutdown"`
+	// NodeID defaults to a random id per process.
+	NodeID string `yaml:"node_id" toml:"node_id" flag:"node-id" usage:"unique id of this node on the broker (default: random)"`
+	// ForwardTimeout bounds calls forwarded to a room's owner.
+	ForwardTimeout time.Duration `yaml:"forward_timeout" toml:"forward_timeout" flag:"forward-timeout" usage:"deadline for stream messages forwarded to a room's owner" reload:"true"`
+	// MaxPageSize caps how many messages one Getmessages call returns.
+	MaxPageSize int `yaml:"max_page_size" toml:"max_page_size" flag:"max-page-size" usage:"most messages one Getmessages call returns" reload:"true"`
+	// StreamBuffer is how many events may queue for one Stream client; a
+	// client that lets more pile up is disconnected.
+	StreamBuffer int `yaml:"stream_buffer" toml:"stream_buffer" flag:"stream-buffer" usage:"events queued per Stream client before it is disconnected as too slow" reload:"true"`
+
+	Broker    BrokerConfig    `yaml:"broker" toml:"broker"`
+	Cluster   ClusterConfig   `yaml:"cluster" toml:"cluster"`
+	Store     StoreConfig     `yaml:"store" toml:"store"`
+	Audit     AuditConfig     `yaml:"audit" toml:"audit"`
+	Signing   SigningConfig   `yaml:"signing" toml:"signing"`
+	Retention RetentionConfig `yaml:"retention" toml:"retention"`
+}
+
+type BrokerConfig struct {
+	Kind    string `yaml:"kind" toml:"kind" flag:"broker" usage:"event broker between nodes: \"memory\" (single node) or \"nats\""`
+	NATSURL string `yaml:"nats_url" toml:"nats_url" flag:"nats-url" usage:"NATS server URL for -broker=nats"`
+	Subject string `yaml:"subject" toml:"subject" flag:"broker-subject" usage:"subject nodes publish stream events on"`
+}
+
+type ClusterConfig struct {
+	AdvertiseAddr string        `yaml:"advertise_addr" toml:"advertise_addr" flag:"advertise-addr" usage:"address other nodes dial to reach this one"`
+	PeersFile     string        `yaml:"peers_file" toml:"peers_file" flag:"cluster-peers-file" usage:"membership list, one \"<node_id> <address>\" per line; re-read every -cluster-refresh (default: single node)"`
+	Refresh       time.Duration `yaml:"refresh" toml:"refresh" flag:"cluster-refresh" usage:"how often the peers file is re-read"`
+}
+
+type StoreConfig struct {
+	Kind string     `yaml:"kind" toml:"kind" flag:"store" usage:"message store: \"memory\" or \"raft\" (replicated)"`
+	Raft RaftConfig `yaml:"raft" toml:"raft"`
+}
+
+type RaftConfig struct {
+	Dir               string        `yaml:"dir" toml:"dir" flag:"raft-dir" usage:"directory for the Raft log and snapshots"`
+	Addr              string        `yaml:"addr" toml:"addr" flag:"raft-addr" usage:"Raft transport bind address"`
+	PeersFile         string        `yaml:"peers_file" toml:"peers_file" flag:"raft-peers-file" usage:"Raft group, one \"<node_id> <raft_address> <grpc_address>\" per line"`
+	Bootstrap         bool          `yaml:"bootstrap" toml:"bootstrap" flag:"raft-bootstrap" usage:"bootstrap the Raft group from -raft-peers-file on first start"`
+	ApplyTimeout      time.Duration `yaml:"apply_timeout" toml:"apply_timeout" flag:"raft-apply-timeout" usage:"how long a write waits to be committed"`
+	SnapshotThreshold uint64        `yaml:"snapshot_threshold" toml:"snapshot_threshold" flag:"raft-snapshot-threshold" usage:"log entries between snapshots"`
+}
+
+// AuditConfig is where a node keeps its audit log of admin and moderation
+// actions. Each node logs what it did itself.
+type AuditConfig struct {
+	File string `yaml:"file" toml:"file" flag:"audit-file" usage:"append-only audit log, one JSON entry per line; empty keeps it in memory"`
+}
+
+// RetentionConfig is how much history rooms keep unless their settings
+// say otherwise, and how often room owners remove what is past it.
+type RetentionConfig struct {
+	Interval    time.Duration `yaml:"interval" toml:"interval" flag:"retention-interval" usage:"how often room owners remove expired messages; 0 turns retention off"`
+	MaxAge      time.Duration `yaml:"max_age" toml:"max_age" flag:"retention-max-age" usage:"default age past which messages are removed; 0 keeps them" reload:"true"`
+	MaxMessages int           `yaml:"max_messages" toml:"max_messages" flag:"retention-max-messages" usage:"default number of latest messages a room keeps; 0 keeps them all" reload:"true"`
+}
+
+// SigningConfig is the registry of the keys users sign messages with. Each
+// node keeps the keys of the users it is home to.
+type SigningConfig struct {
+	KeysFile string `yaml:"keys_file" toml:"keys_file" flag:"signing-keys-file" usage:"file the registered signing keys are kept in; empty keeps them in memory. The raft store replicates them instead"`
+	// RejectUnsigned refuses unsigned messages from users who registered
+	// a key, so no one else can post as them.
+	RejectUnsigned bool `yaml:"reject_unsigned" toml:"reject_unsigned" flag:"signing-reject-unsigned" usage:"reject unsigned messages from users with a registered signing key" reload:"true"`
+}
+
+type RESTConfig struct {
+	Addr            string        `yaml:"addr" toml:"addr" flag:"addr" usage:"address to listen on"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" flag:"shutdown-timeout" usage:"how long in-flight requests get to finish on shutdown"`
+	// APITokensFile is re-read on every reload.
+	APITokensFile string `yaml:"api_tokens_file" toml:"api_tokens_file" flag:"rest-api-tokens-file" usage:"file of \"<token> <user_id>\" lines authenticating GET /search" reload:"true"`
+}
+
+type WebSocketConfig struct {
+	Addr            string        `yaml:"addr" toml:"addr" flag:"addr" usage:"address to listen on"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" flag:"shutdown-timeout" usage:"how long open sockets and requests get to finish on shutdown"`
+
+	// Connection tuning; a reload applies to sockets opened afterwards.
+	PingInterval   time.Duration `yaml:"ping_interval" toml:"ping_interval" flag:"ws-ping-interval" usage:"interval between pings sent to idle clients" reload:"true"`
+	PongWait       time.Duration `yaml:"pong_wait" toml:"pong_wait" flag:"ws-pong-wait" usage:"time without any frame from the client before the socket is dropped" reload:"true"`
+	WriteWait      time.Duration `yaml:"write_wait" toml:"write_wait" flag:"ws-write-wait" usage:"deadline for a single frame write" reload:"true"`
+	MaxMessageSize int64         `yaml:"max_message_size" toml:"max_message_size" flag:"ws-max-message-size" usage:"largest frame accepted from a client, in bytes" reload:"true"`
+	SendBuffer     int           `yaml:"send_buffer" toml:"send_buffer" flag:"ws-send-buffer" usage:"outbound frames queued per socket" reload:"true"`
+	Compression    bool          `yaml:"compression" toml:"compression" flag:"ws-compression" usage:"negotiate permessage-deflate"`
+
+	AllowedOrigins   []string      `yaml:"allowed_origins" toml:"allowed_origins" flag:"ws-allowed-origins" usage:"comma separated origins allowed to open sockets, e.g. https://chat.example.com,https://*.example.com (default: same origin only)" reload:"true"`
+	RequireTicket    bool          `yaml:"require_ticket" toml:"require_ticket" flag:"ws-require-ticket" usage:"require a ticket from POST /ws/ticket on every handshake"`
+	TicketTTL        time.Duration `yaml:"ticket_ttl" toml:"ticket_ttl" flag:"ws-ticket-ttl" usage:"lifetime of handshake tickets" reload:"true"`
+	TicketSecretFile string        `yaml:"ticket_secret_file" toml:"ticket_secret_file" flag:"ws-ticket-secret-file" usage:"file holding the ticket signing key, shared by all gateway replicas (default: random per process)"`
+	// APITokensFile is re-read on every reload.
+	APITokensFile string `yaml:"api_tokens_file" toml:"api_tokens_file" flag:"ws-api-tokens-file" usage:"file of \"<token> <user_id>\" lines authenticating POST /ws/ticket" reload:"true"`
+
+	Poll PollConfig `yaml:"poll" toml:"poll"`
+}
+
+type PollConfig struct {
+	Wait        time.Duration `yaml:"wait" toml:"wait" flag:"poll-wait" usage:"how long GET /poll waits for events before returning empty" reload:"true"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout" flag:"poll-idle-timeout" usage:"expire long-poll sessions not polled for this long"`
+	MaxBuffered int           `yaml:"max_buffered" toml:"max_buffered" flag:"poll-max-buffered" usage:"events buffered per long-poll session between polls" reload:"true"`
+}
+
+// TracingConfig is where OpenTelemetry spans go. Trace context is
+// propagated whatever the exporter.
+type TracingConfig struct {
+	// Exporter is "none" or "otlp" (OTLP over gRPC).
+	Exporter    string  `yaml:"exporter" toml:"exporter" flag:"tracing-exporter" usage:"where spans are sent: none or otlp"`
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint" flag:"tracing-endpoint" usage:"host:port of the OTLP gRPC collector"`
+	Insecure    bool    `yaml:"insecure" toml:"insecure" flag:"tracing-insecure" usage:"send spans to the collector without TLS"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" flag:"tracing-sample-ratio" usage:"fraction of new traces recorded; traces started upstream follow the caller's decision"`
+	// ServiceName defaults to the role, e.g. gochat-serve.
+	ServiceName string `yaml:"service_name" toml:"service_name" flag:"tracing-service-name" usage:"service.name of the spans (default: gochat-<role>)"`
+}
+
+// LogConfig shapes the structured log every role writes to stderr.
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" flag:"log-level" usage:"lowest level logged: debug, info, warn or error" reload:"true"`
+	Format string `yaml:"format" toml:"format" flag:"log-format" usage:"text or json"`
+	// Content logs message text, which is redacted otherwise. It only
+	// takes effect at the debug level.
+	Content bool `yaml:"content" toml:"content" flag:"log-content" usage:"log message text at the debug level instead of redacting it" reload:"true"`
+}
+
+// RateLimitConfig sizes the token buckets that throttle clients. A rate is
+// in tokens per second and a burst is the bucket size; a rate of 0 turns
+// that limit off. A message costs one token; typing and presence events
+// cost less. The server limits users and rooms, the gateways client IPs.
+type RateLimitConfig struct {
+	UserRate     float64 `yaml:"user_rate" toml:"user_rate" flag:"rate-limit-user" usage:"tokens per second each user gets; 0 turns the limit off" reload:"true"`
+	UserBurst    float64 `yaml:"user_burst" toml:"user_burst" flag:"rate-limit-user-burst" usage:"tokens a user can spend at once" reload:"true"`
+	RoomRate     float64 `yaml:"room_rate" toml:"room_rate" flag:"rate-limit-room" usage:"tokens per second each room gets, shared by its members; 0 turns the limit off" reload:"true"`
+	RoomBurst    float64 `yaml:"room_burst" toml:"room_burst" flag:"rate-limit-room-burst" usage:"tokens a room can spend at once" reload:"true"`
+	IPRate       float64 `yaml:"ip_rate" toml:"ip_rate" flag:"rate-limit-ip" usage:"requests and WebSocket frames per second each client IP gets at a gateway; 0 turns the limit off" reload:"true"`
+	IPBurst      float64 `yaml:"ip_burst" toml:"ip_burst" flag:"rate-limit-ip-burst" usage:"requests and frames a client IP can send at once" reload:"true"`
+	TypingCost   float64 `yaml:"typing_cost" toml:"typing_cost" flag:"rate-limit-typing-cost" usage:"tokens a typing event costs, where a message costs 1" reload:"true"`
+	PresenceCost float64 `yaml:"presence_cost" toml:"presence_cost" flag:"rate-limit-presence-cost" usage:"tokens a presence event costs, where a message costs 1" reload:"true"`
+}
+
+// ModerationConfig sets up the built-in filters every message passes
+// through on the server before it is stored. Lists and thresholds left
+// empty or at 0 turn their filter off.
+type ModerationConfig struct {
+	// BannedWords are matched as whole words, ignoring case.
+	BannedWords       []string `yaml:"banned_words" toml:"banned_words" flag:"moderation-banned-words" usage:"comma separated words messages may not contain" reload:"true"`
+	BannedWordsAction string   `yaml:"banned_words_action" toml:"banned_words_action" flag:"moderation-banned-words-action" usage:"what a banned word does to a message: mask, flag or reject" reload:"true"`
+	// LinkAllow and LinkDeny match a link's host and its subdomains.
+	LinkAllow []string `yaml:"link_allow" toml:"link_allow" flag:"moderation-link-allow" usage:"comma separated hosts links may point to; links elsewhere are rejected (default: any host)" reload:"true"`
+	LinkDeny  []string `yaml:"link_deny" toml:"link_deny" flag:"moderation-link-deny" usage:"comma separated hosts links may not point to" reload:"true"`
+	MaxLength int      `yaml:"max_length" toml:"max_length" flag:"moderation-max-length" usage:"longest message text accepted, in characters; 0 turns the limit off" reload:"true"`
+	// MaxCaps flags shouting: messages with at least CapsMinLetters
+	// letters, more than this fraction of them upper case.
+	MaxCaps        float64 `yaml:"max_caps" toml:"max_caps" flag:"moderation-max-caps" usage:"fraction of upper case letters above which a message is flagged; 0 turns the check off" reload:"true"`
+	CapsMinLetters int     `yaml:"caps_min_letters" toml:"caps_min_letters" flag:"moderation-caps-min-letters" usage:"letters a message needs before -moderation-max-caps applies" reload:"true"`
+	// MaxRepeat squeezes longer runs of one character down to this many.
+	MaxRepeat int `yaml:"max_repeat" toml:"max_repeat" flag:"moderation-max-repeat" usage:"longest run of one repeated character kept in a message; 0 turns the check off" reload:"true"`
+	// ReviewQueue bounds the flagged messages kept per room; the oldest
+	// go first.
+	ReviewQueue int `yaml:"review_queue" toml:"review_queue" flag:"moderation-review-queue" usage:"flagged messages kept for review per room" reload:"true"`
+}
+
+// SpamConfig tunes the behavior scoring that mutes or shadow-restricts
+// users. Each signal seen in a message adds to the sender's score, which
+// halves every ScoreHalfLife.
+type SpamConfig struct {
+	Enabled bool          `yaml:"enabled" toml:"enabled" flag:"spam" usage:"score senders' behavior and restrict spammers" reload:"true"`
+	Window  time.Duration `yaml:"window" toml:"window" flag:"spam-window" usage:"how far back a sender's messages are compared" reload:"true"`
+	// DuplicateRooms is how many rooms the same text must reach within
+	// Window to count as a duplicate.
+	DuplicateRooms int `yaml:"duplicate_rooms" toml:"duplicate_rooms" flag:"spam-duplicate-rooms" usage:"rooms the same text must be posted to within -spam-window to score" reload:"true"`
+	BurstMessages  int `yaml:"burst_messages" toml:"burst_messages" flag:"spam-burst-messages" usage:"messages within -spam-window, across rooms, that score as a burst" reload:"true"`
+	// NewUserAge is how long after their first message a user still
+	// counts as new, and scores for posting links.
+	NewUserAge     time.Duration `yaml:"new_user_age" toml:"new_user_age" flag:"spam-new-user-age" usage:"how long after their first message a user's links score" reload:"true"`
+	MaxMentions    int           `yaml:"max_mentions" toml:"max_mentions" flag:"spam-max-mentions" usage:"@mentions in one message above which it scores" reload:"true"`
+	ScoreHalfLife  time.Duration `yaml:"score_half_life" toml:"score_half_life" flag:"spam-score-half-life" usage:"time for a sender's score to halve" reload:"true"`
+	MuteScore      float64       `yaml:"mute_score" toml:"mute_score" flag:"spam-mute-score" usage:"score at which a sender is muted" reload:"true"`
+	MuteDuration   time.Duration `yaml:"mute_duration" toml:"mute_duration" flag:"spam-mute-duration" usage:"how long an automatic mute lasts" reload:"true"`
+	ShadowScore    float64       `yaml:"shadow_score" toml:"shadow_score" flag:"spam-shadow-score" usage:"score at which a sender is shadow-restricted instead of muted" reload:"true"`
+	ShadowDuration time.Duration `yaml:"shadow_duration" toml:"shadow_duration" flag:"spam-shadow-duration" usage:"how long an automatic shadow restriction lasts" reload:"true"`
+	// FailOpen lets unsigned messages through unscored when their
+	// sender's home node can't be reached, instead of refusing them.
+	FailOpen bool `yaml:"fail_open" toml:"fail_open" flag:"spam-fail-open" usage:"let unsigned messages through unscored while the sender's home node is unreachable" reload:"true"`
+}
+
+// Default returns the built-in defaults, which match running every binary
+// on one machine with the repository's server.crt and server.key.
+func Default() *Config {
+	return &Config{
+		TLS: TLSConfig{
+			CertFile:       "server.crt",
+			KeyFile:        "server.key",
+			CAFile:         "server.crt",
+			ServerName:     "localhost",
+			ReloadInterval: 10 * time.Second,
+		},
+		Backend: BackendConfig{
+			Addr:           "dns:///localhost:8443",
+			RequestTimeout: 3 * time.Second,
+		},
+		Server: ServerConfig{
+			Addr:            ":8443",
+			ShutdownTimeout: 15 * time.Second,
+			ForwardTimeout:  5 * time.Second,
+			MaxPageSize:     100,
+			StreamBuffer:    256,
+			Broker: BrokerConfig{
+				Kind:    "memory",
+				NATSURL: "nats://127.0.0.1:4222",
+				Subject: "gochat.events",
+			},
+			Cluster: ClusterConfig{
+				AdvertiseAddr: "localhost:8443",
+				Refresh:       5 * time.Second,
+			},
+			Store: StoreConfig{
+				Kind: "memory",
+				Raft: RaftConfig{
+					Dir:               "data/raft",
+					Addr:              "127.0.0.1:7000",
+					ApplyTimeout:      5 * time.Second,
+					SnapshotThreshold: 8192,
+				},
+			},
+			Audit: AuditConfig{
+				File: "data/audit.jsonl",
+			},
+			Signing: SigningConfig{
+				KeysFile: "data/signing_keys.jsonl",
+			},
+			Retention: RetentionConfig{
+				Interval: time.Hour,
+			},
+		},
+		REST: RESTConfig{
+			Addr:            ":8080",
+			ShutdownTimeout: 15 * time.Second,
+		},
+		WebSocket: WebSocketConfig{
+			Addr:            ":8080",
+			ShutdownTimeout: 15 * time.Second,
+			PingInterval:    30 * time.Second,
+			PongWait:        60 * time.Second,
+			WriteWait:       10 * time.Second,
+			MaxMessageSize:  64 << 10,
+			SendBuffer:      64,
+			Compression:     true,
+			TicketTTL:       30 * time.Second,
+			Poll: PollConfig{
+				Wait:        25 * time.Second,
+				IdleTimeout: time.Minute,
+				MaxBuffered: 256,
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "localhost:4317",
+			Insecure:    true,
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		RateLimit: RateLimitConfig{
+			UserRate:     5,
+			UserBurst:    10,
+			RoomRate:     50,
+			RoomBurst:    100,
+			IPRate:       20,
+			IPBurst:      40,
+			TypingCost:   0.1,
+			PresenceCost: 0.1,
+		},
+		Moderation: ModerationConfig{
+			BannedWordsAction: "mask",
+			MaxLength:         4000,
+			MaxCaps:           0.7,
+			CapsMinLetters:    12,
+			MaxRepeat:         10,
+			ReviewQueue:       1000,
+		},
+		Spam: SpamConfig{
+			Enabled:        true,
+			Window:         time.Minute,
+			DuplicateRooms: 3,
+			BurstMessages:  30,
+			NewUserAge:     10 * time.Minute,
+			MaxMentions:    5,
+			ScoreHalfLife:  10 * time.Minute,
+			MuteScore:      4,
+			MuteDuration:   10 * time.Minute,
+			ShadowScore:    8,
+			ShadowDuration: time.Hour,
+			FailOpen:       true,
+		},
+	}
+}
+
+// Validate checks the given sections and reports every problem found.
+func (c *Config) Validate(sections ...string) error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	positive := func(d time.Duration, name string) {
+		check(d > 0, "%s must be positive", name)
+	}
+
+	for _, section := range sections {
+		switch section {
+		case SectionTLS:
+			check(c.TLS.CAFile != "", "tls.ca_file is required")
+			check(c.TLS.ServerName != "", "tls.server_name is required")
+			check((c.TLS.ClientCertFile == "") == (c.TLS.ClientKeyFile == ""), "tls.client_cert_file and tls.client_key_file must be set together")
+			positive(c.TLS.ReloadInterval, "tls.reload_interval")
+			if slices.Contains(sections, SectionServer) {
+				check(c.TLS.CertFile != "" && c.TLS.KeyFile != "", "tls.cert_file and tls.key_file are required")
+				check(len(c.TLS.AllowedClients) == 0 || c.TLS.ClientCAFile != "", "tls.allowed_clients needs tls.client_ca_file")
+				check(len(c.TLS.PeerClients) == 0 || c.TLS.ClientCAFile != "", "tls.peer_clients needs tls.client_ca_file")
+				// Nodes trust each other's forwarded calls, so they must
+				// know each other by certificate.
+				if c.Server.Cluster.PeersFile != "" || c.Server.Store.Kind == "raft" {
+					check(c.TLS.ClientCAFile != "" && len(c.TLS.PeerClients) > 0, "more than one node needs tls.client_ca_file and tls.peer_clients")
+				}
+			}
+
+		case SectionBackend:
+			check(c.Backend.Addr != "", "backend.addr is required")
+			positive(c.Backend.RequestTimeout, "backend.request_timeout")
+
+		case SectionServer:
+			s := c.Server
+			check(s.Addr != "", "server.addr is required")
+			positive(s.ShutdownTimeout, "server.shutdown_timeout")
+			positive(s.ForwardTimeout, "server.forward_timeout")
+			check(s.MaxPageSize > 0, "server.max_page_size must be positive")
+			check(s.StreamBuffer > 0, "server.stream_buffer must be positive")
+			check(s.Retention.Interval >= 0, "server.retention.interval must not be negative")
+			check(s.Retention.MaxAge >= 0, "server.retention.max_age must not be negative")
+			check(s.Retention.MaxMessages >= 0, "server.retention.max_messages must not be negative")
+
+			switch s.Broker.Kind {
+			case "memory":
+				// Nodes only see each other's events through a shared
+				// broker.
+				check(s.Cluster.PeersFile == "" && s.Store.Kind != "raft", "server.broker.kind memory only serves a single node; use nats with server.cluster.peers_file or the raft store")
+			case "nats":
+				check(s.Broker.NATSURL != "", "server.broker.nats_url is required for the nats broker")
+			default:
+				check(false, "unknown server.broker.kind %q", s.Broker.Kind)
+			}
+			check(s.Broker.Subject != "", "server.broker.subject is required")
+			check(s.Cluster.AdvertiseAddr != "", "server.cluster.advertise_addr is required")
+			positive(s.Cluster.Refresh, "server.cluster.refresh")
+
+			switch s.Store.Kind {
+			case "memory":
+			case "raft":
+				// Every Raft member holds the full history, so rooms
+				// are not sharded on top of it.
+				check(s.Cluster.PeersFile == "", "server.store.kind raft cannot be combined with server.cluster.peers_file")
+				check(s.Store.Raft.PeersFile != "", "server.store.raft.peers_file is required for the raft store")
+				check(s.Store.Raft.Dir != "" && s.Store.Raft.Addr != "", "server.store.raft.dir and server.store.raft.addr are required")
+				positive(s.Store.Raft.ApplyTimeout, "server.store.raft.apply_timeout")
+				check(s.Store.Raft.SnapshotThreshold > 0, "server.store.raft.snapshot_threshold must be positive")
+			default:
+				check(false, "unknown server.store.kind %q", s.Store.Kind)
+			}
+
+		case SectionREST:
+			check(c.REST.Addr != "", "rest.addr is required")
+			positive(c.REST.ShutdownTimeout, "rest.shutdown_timeout")
+
+		case SectionWebSocket:
+			w := c.WebSocket
+			check(w.Addr != "", "websocket.addr is required")
+			positive(w.ShutdownTimeout, "websocket.shutdown_timeout")
+			positive(w.PingInterval, "websocket.ping_interval")
+			positive(w.WriteWait, "websocket.write_wait")
+			check(w.PongWait > w.PingInterval, "websocket.pong_wait must be longer than websocket.ping_interval")
+			check(w.MaxMessageSize > 0, "websocket.max_message_size must be positive")
+			check(w.SendBuffer > 0, "websocket.send_buffer must be positive")
+			positive(w.TicketTTL, "websocket.ticket_ttl")
+			check(!w.RequireTicket || w.APITokensFile != "", "websocket.require_ticket needs websocket.api_tokens_file")
+			positive(w.Poll.Wait, "websocket.poll.wait")
+			check(w.Poll.IdleTimeout > w.Poll.Wait, "websocket.poll.idle_timeout must be longer than websocket.poll.wait")
+			check(w.Poll.MaxBuffered > 0, "websocket.poll.max_buffered must be positive")
+
+		case SectionTracing:
+			t := c.Tracing
+			switch t.Exporter {
+			case "none":
+			case "otlp":
+				check(t.Endpoint != "", "tracing.endpoint is required for the otlp exporter")
+			default:
+				check(false, "unknown tracing.exporter %q", t.Exporter)
+			}
+			check(t.SampleRatio >= 0 && t.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+		case SectionLog:
+			var level slog.Level
+			check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "unknown log.level %q", c.Log.Level)
+			check(c.Log.Format == "text" || c.Log.Format == "json", "unknown log.format %q", c.Log.Format)
+
+		case SectionRateLimit:
+			r := c.RateLimit
+			bucket := func(rate, burst float64, name string) {
+				check(rate >= 0, "rate_limit.%s_rate must not be negative", name)
+				check(rate == 0 || burst >= 1, "rate_limit.%s_burst must be at least 1, the cost of a message", name)
+			}
+			bucket(r.UserRate, r.UserBurst, "user")
+			bucket(r.RoomRate, r.RoomBurst, "room")
+			bucket(r.IPRate, r.IPBurst, "ip")
+			check(r.TypingCost >= 0 && r.PresenceCost >= 0, "rate_limit costs must not be negative")
+
+		case SectionModeration:
+			m := c.Moderation
+			check(slices.Contains([]string{"mask", "flag", "reject"}, m.BannedWordsAction), "unknown moderation.banned_words_action %q", m.BannedWordsAction)
+			check(m.MaxLength >= 0, "moderation.max_length must not be negative")
+			check(m.MaxCaps >= 0 && m.MaxCaps <= 1, "moderation.max_caps must be between 0 and 1")
+			check(m.CapsMinLetters >= 0, "moderation.caps_min_letters must not be negative")
+			check(m.MaxRepeat >= 0, "moderation.max_repeat must not be negative")
+			check(m.ReviewQueue > 0, "moderation.review_queue must be positive")
+
+		case SectionSpam:
+			sp := c.Spam
+			positive(sp.Window, "spam.window")
+			check(sp.DuplicateRooms >= 2, "spam.duplicate_rooms must be at least 2")
+			check(sp.BurstMessages > 0, "spam.burst_messages must be positive")
+			check(sp.NewUserAge >= 0, "spam.new_user_age must not be negative")
+			check(sp.MaxMentions > 0, "spam.max_mentions must be positive")
+			positive(sp.ScoreHalfLife, "spam.score_half_life")
+			check(sp.MuteScore > 0, "spam.mute_score must be positive")
+			check(sp.ShadowScore > sp.MuteScore, "spam.shadow_score must be above spam.mute_score")
+			positive(sp.MuteDuration, "spam.mute_duration")
+			positive(sp.ShadowDuration, "spam.shadow_duration")
+
+		default:
+			errs = append(errs, fmt.Errorf("unknown config section %q", section))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,394 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix starts every environment variable, e.g. GOCHAT_SERVER_ADDR for
+// server.addr or GOCHAT_WEBSOCKET_POLL_WAIT for websocket.poll.wait.
+const envPrefix = "GOCHAT_"
+
+// Loader builds a command's effective config and rebuilds it on reload.
+type Loader struct {
+	sections []string
+	defaults *Config
+
+	fs    *flag.FlagSet
+	file  string
+	print bool
+	flags []*flagValue
+}
+
+// NewLoader returns a loader for the command name that uses sections.
+// Only those sections get flags, are validated and are printed. When two
+// sections define the same flag, as -addr, the later one is prefixed with
+// its section name: -rest-addr, -websocket-addr.
+func NewLoader(name string, sections ...string) *Loader {
+	l := &Loader{
+		sections: sections,
+		defaults: Default(),
+		fs:       flag.NewFlagSet(name, flag.ExitOnError),
+	}
+	// Chosen once, so a reload doesn't see the node id change.
+	l.defaults.Server.NodeID = uuid.NewString()
+
+	l.fs.StringVar(&l.file, "config", os.Getenv(envPrefix+"CONFIG"), "YAML (.yaml, .yml) or TOML (.toml) config file")
+	l.fs.BoolVar(&l.print, "print-config", false, "print the effective config and exit")
+	for _, section := range sections {
+		for _, f := range fields {
+			if f.flag == "" || f.section() != section {
+				continue
+			}
+			if l.fs.Lookup(f.flag) != nil {
+				f.flag = section + "-" + f.flag
+			}
+			def := f.in(l.defaults)
+			v := &flagValue{field: f, def: format(def), isBool: def.Kind() == reflect.Bool}
+			l.fs.Var(v, f.flag, f.usage)
+			l.flags = append(l.flags, v)
+		}
+	}
+	return l
+}
+
+// Flags is the loader's flag set, for commands that take flags of their
+// own besides the config ones.
+func (l *Loader) Flags() *flag.FlagSet {
+	return l.fs
+}
+
+// Load parses args and returns the validated config: defaults, overlaid by
+// the file from -config, then GOCHAT_* environment variables, then flags.
+func (l *Loader) Load(args []string) (*Config, error) {
+	l.fs.Parse(args)
+	if l.fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", l.fs.Args())
+	}
+	return l.build()
+}
+
+// PrintOnly reports whether -print-config was passed.
+func (l *Loader) PrintOnly() bool {
+	return l.print
+}
+
+// Format renders the loader's sections of c as YAML, in the same shape a
+// config file takes.
+func (l *Loader) Format(c *Config) string {
+	doc := make(map[string]any, len(l.sections))
+	v := reflect.ValueOf(c).Elem()
+	for i := range v.NumField() {
+		if key := v.Type().Field(i).Tag.Get("yaml"); slices.Contains(l.sections, key) {
+			doc[key] = v.Field(i).Interface()
+		}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Sprintf("<unprintable config: %v>", err)
+	}
+	enc.Close()
+	return buf.String()
+}
+
+// build layers the file, the environment and the flags over the defaults
+// and validates the result.
+func (l *Loader) build() (*Config, error) {
+	c := l.defaults.clone()
+	if l.file != "" {
+		if err := readFile(l.file, c); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range fields {
+		if s, ok := os.LookupEnv(f.env()); ok {
+			if err := set(f.in(c), s); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.env(), err)
+			}
+		}
+	}
+	for _, v := range l.flags {
+		if v.isSet {
+			if err := set(v.field.in(c), v.raw); err != nil {
+				return nil, fmt.Errorf("-%s: %w", v.field.flag, err)
+			}
+		}
+	}
+	if err := c.Validate(l.sections...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func readFile(path string, c *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if unknown := md.Undecoded(); len(unknown) > 0 {
+			return fmt.Errorf("%s: unknown keys %v", path, unknown)
+		}
+	default:
+		return fmt.Errorf("%s: config file must end in .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+func (c *Config) clone() *Config {
+	cp := *c
+	cp.WebSocket.AllowedOrigins = slices.Clone(c.WebSocket.AllowedOrigins)
+	cp.Moderation.BannedWords = slices.Clone(c.Moderation.BannedWords)
+	cp.Moderation.LinkAllow = slices.Clone(c.Moderation.LinkAllow)
+	cp.Moderation.LinkDeny = slices.Clone(c.Moderation.LinkDeny)
+	return &cp
+}
+
+// ----- Reload -----
+
+// Reload re-reads the file and environment (flags keep their values) and
+// returns cur with the reloadable settings updated. Changes to any other
+// setting are logged and ignored until restart.
+func (l *Loader) Reload(cur *Config) (*Config, error) {
+	next, err := l.build()
+	if err != nil {
+		return nil, err
+	}
+
+	merged := cur.clone()
+	for _, f := range fields {
+		if !slices.Contains(l.sections, f.section()) {
+			continue
+		}
+		old, updated := f.in(cur), f.in(next)
+		if reflect.DeepEqual(old.Interface(), updated.Interface()) {
+			continue
+		}
+		if !f.reload {
+			slog.Warn("config setting changed, restart to apply it", "setting", f.path)
+			continue
+		}
+		slog.Info("config setting changed", "setting", f.path, "old", format(old), "new", format(updated))
+		f.in(merged).Set(updated)
+	}
+	// Reloadable settings are checked against each other too.
+	if err := merged.Validate(l.sections...); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// Changed returns the settings that differ between old and next, like
+// "server.max_page_size".
+func Changed(old, next *Config) []string {
+	var paths []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.in(old).Interface(), f.in(next).Interface()) {
+			paths = append(paths, f.path)
+		}
+	}
+	return paths
+}
+
+// WatchSIGHUP reloads the config on every SIGHUP until ctx is done and
+// hands each reloaded config to apply. A config that fails to load,
+// validate or apply is logged and the current one is kept.
+func (l *Loader) WatchSIGHUP(ctx context.Context, cur *Config, apply func(*Config) error) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+		slog.Info("SIGHUP received, reloading config")
+		next, err := l.Reload(cur)
+		if err == nil {
+			err = apply(next)
+		}
+		if err != nil {
+			slog.Error("config reload failed, keeping current config", "err", err)
+			continue
+		}
+		cur = next
+	}
+}
+
+// ----- Fields -----
+
+// field is one leaf setting of Config.
+type field struct {
+	path   string // file key, e.g. "websocket.poll.wait"
+	flag   string
+	usage  string
+	reload bool
+	index  []int
+}
+
+var fields = collectFields(reflect.TypeFor[Config](), "", nil)
+
+func collectFields(t reflect.Type, prefix string, index []int) []field {
+	var out []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		path := sf.Tag.Get("yaml")
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		idx := append(slices.Clone(index), i)
+		if sf.Type.Kind() == reflect.Struct {
+			out = append(out, collectFields(sf.Type, path, idx)...)
+			continue
+		}
+		out = append(out, field{
+			path:   path,
+			flag:   sf.Tag.Get("flag"),
+			usage:  sf.Tag.Get("usage"),
+			reload: sf.Tag.Get("reload") == "true",
+			index:  idx,
+		})
+	}
+	return out
+}
+
+func (f field) section() string {
+	s, _, _ := strings.Cut(f.path, ".")
+	return s
+}
+
+func (f field) env() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(f.path, ".", "_"))
+}
+
+func (f field) in(c *Config) reflect.Value {
+	return reflect.ValueOf(c).Elem().FieldByIndex(f.index)
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// set parses s into v the way flags and environment variables spell
+// values: durations like "30s" and lists comma separated.
+func set(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+func format(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// flagValue records a flag as given. It is applied after the file and the
+// environment, so flags always win, and again on every reload.
+type flagValue struct {
+	field  field
+	def    string
+	raw    string
+	isSet  bool
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.def
+}
+
+func (v *flagValue) Set(s string) error {
+	// Parse into a scratch value so bad input fails at the flag.
+	if err := set(reflect.New(v.field.in(Default()).Type()).Elem(), s); err != nil {
+		return err
+	}
+	v.raw, v.isSet = s, true
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
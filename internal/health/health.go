@@ -0,0 +1,170 @@
+// Package health answers liveness and readiness probes. A Checker runs the
+// readiness checks of a role's dependencies for /readyz and keeps the
+// grpc.health.v1 service of the server up to date.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// checkTimeout bounds a single round of checks.
+const checkTimeout = 2 * time.Second
+
+var errShuttingDown = errors.New("shutting down")
+
+// Check reports why a dependency can't serve, or nil when it can.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker is the set of readiness checks of one process.
+type Checker struct {
+	mu       sync.Mutex
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+func New() *Checker {
+	return &Checker{}
+}
+
+// Include adds the checks of other, such as those of a server running in
+// the same process as its gateways.
+func (c *Checker) Include(other *Checker) {
+	other.mu.Lock()
+	checks := append([]namedCheck(nil), other.checks...)
+	other.mu.Unlock()
+	c.mu.Lock()
+	c.checks = append(c.checks, checks...)
+	c.mu.Unlock()
+}
+
+// Add registers a readiness check under name.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	c.checks = append(c.checks, namedCheck{name, check})
+	c.mu.Unlock()
+}
+
+// Drain makes the process report not ready from now on, so load balancers
+// stop sending it traffic while it shuts down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Run runs every check concurrently and returns the failures by name.
+func (c *Checker) Run(ctx context.Context) map[string]error {
+	c.mu.Lock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Go(func() { errs[i] = nc.check(ctx) })
+	}
+	wg.Wait()
+
+	failed := make(map[string]error)
+	if c.draining.Load() {
+		failed["process"] = errShuttingDown
+	}
+	for i, err := range errs {
+		if err != nil {
+			failed[checks[i].name] = err
+		}
+	}
+	return failed
+}
+
+// Watch runs the checks every interval until ctx is done and calls update
+// with whether they all passed.
+func (c *Checker) Watch(ctx context.Context, interval time.Duration, update func(ready bool)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		update(len(c.Run(ctx)) == 0)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ----- HTTP -----
+
+type report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Live answers /healthz: the process is up and serving HTTP.
+func Live(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, report{Status: "ok"})
+}
+
+// Ready answers /readyz with the result of every check: 200 when they all
+// pass, 503 naming the failures otherwise.
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	failed := c.Run(r.Context())
+	rep := report{Status: "ok", Checks: make(map[string]string)}
+	c.mu.Lock()
+	for _, nc := range c.checks {
+		rep.Checks[nc.name] = "ok"
+	}
+	c.mu.Unlock()
+	for name, err := range failed {
+		rep.Checks[name] = err.Error()
+	}
+	code := http.StatusOK
+	if len(failed) > 0 {
+		rep.Status = "unavailable"
+		code = http.StatusServiceUnavailable
+	}
+	writeReport(w, code, rep)
+}
+
+func writeReport(w http.ResponseWriter, code int, rep report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(rep)
+}
+
+// ----- Checks -----
+
+// Conn checks a gRPC client connection. An idle or connecting connection
+// gets until the check times out to become ready.
+func Conn(conn *grpc.ClientConn) Check {
+	return func(ctx context.Context) error {
+		conn.Connect()
+		for {
+			state := conn.GetState()
+			switch state {
+			case connectivity.Ready:
+				return nil
+			case connectivity.TransientFailure, connectivity.Shutdown:
+				return fmt.Errorf("backend connection is %s", state)
+			}
+			if !conn.WaitForStateChange(ctx, state) {
+				return fmt.Errorf("backend connection is %s", state)
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Package logging sets up the structured log of every gochat role. Log
+// lines pick up the request id, stream id, user and room that were attached
+// to their context with With, and the trace id of the active span.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/qinyul/go-chat/internal/config"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Attribute keys shared by every component.
+const (
+	RequestID = "request_id"
+	StreamID  = "stream_id"
+	UserID    = "user_id"
+	RoomID    = "room_id"
+)
+
+// requestIDHeader carries the request id over HTTP and gRPC metadata.
+const requestIDHeader = "x-request-id"
+
+var (
+	level   slog.LevelVar
+	content atomic.Bool
+)
+
+// Setup makes a logger configured by cfg writing to w the default for
+// both slog and the log package.
+func Setup(w io.Writer, cfg config.LogConfig) {
+	opts := &slog.HandlerOptions{Level: &level}
+	var h slog.Handler
+	if cfg.Format == "json" {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(contextHandler{h}))
+	Apply(cfg)
+}
+
+// Apply changes the level and content logging at runtime.
+func Apply(cfg config.LogConfig) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(cfg.Level)); err == nil {
+		level.Set(l)
+	}
+	content.Store(cfg.Content)
+}
+
+// Content is message text. It is logged as its length only, unless
+// content logging is on and the debug level enabled.
+type Content string
+
+func (c Content) LogValue() slog.Value {
+	if content.Load() && level.Level() <= slog.LevelDebug {
+		return slog.StringValue(string(c))
+	}
+	return slog.StringValue(fmt.Sprintf("[redacted, %d bytes]", len(c)))
+}
+
+// ----- Context attributes -----
+
+type attrsKey struct{}
+
+// With returns ctx with attrs added to every line logged with it. args are
+// key-value pairs as for slog.Info; empty values are skipped.
+func With(ctx context.Context, args ...any) context.Context {
+	prev, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	attrs := append([]slog.Attr(nil), prev...)
+	for _, a := range argsToAttrs(args) {
+		if a.Value.Kind() == slog.KindString && a.Value.String() == "" {
+			continue
+		}
+		attrs = append(attrs, a)
+	}
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+func argsToAttrs(args []any) []slog.Attr {
+	var r slog.Record
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
+
+// RequestIDFrom returns the request id attached to ctx, if any.
+func RequestIDFrom(ctx context.Context) string {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	for i := len(attrs) - 1; i >= 0; i-- {
+		if attrs[i].Key == RequestID {
+			return attrs[i].Value.String()
+		}
+	}
+	return ""
+}
+
+// contextHandler adds the context's attributes and trace id to records.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// ----- HTTP -----
+
+// Middleware gives every request an id, taken from X-Request-Id when the
+// caller sent one, and logs the request when it is done.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := With(r.Context(), RequestID, id)
+
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		code := ww.Status()
+		if code == 0 && r.Header.Get("Upgrade") != "" {
+			code = http.StatusSwitchingProtocols // hijacked by the WebSocket upgrade
+		} else if code == 0 {
+			code = http.StatusOK
+		}
+		route := r.URL.Path
+		if rc := chi.RouteContext(ctx); rc != nil && rc.RoutePattern() != "" {
+			route = rc.RoutePattern()
+		}
+		lvl := slog.LevelInfo
+		if route == "/healthz" || route == "/readyz" {
+			lvl = slog.LevelDebug // probes would drown everything else
+		}
+		slog.Log(ctx, lvl, "http request",
+			"method", r.Method,
+			"route", route,
+			"status", code,
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start),
+			"remote", r.RemoteAddr)
+	})
+}
+
+// ----- gRPC -----
+
+// incoming attaches the caller's request id, or a new one, to ctx.
+func incoming(ctx context.Context) context.Context {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDHeader); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	return With(ctx, RequestID, id)
+}
+
+func logRPC(ctx context.Context, method string, start time.Time, err error) {
+	lvl := slog.LevelDebug
+	if err != nil {
+		lvl = slog.LevelWarn
+	}
+	slog.Log(ctx, lvl, "rpc", "method", method, "code", status.Code(err).String(), "duration", time.Since(start))
+}
+
+// UnaryServerInterceptor attaches a request id and logs each call: at the
+// debug level when it succeeds, as a warning when it fails.
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx = incoming(ctx)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logRPC(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// StreamServerInterceptor does the same for streams.
+func StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := incoming(ss.Context())
+	start := time.Now()
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	logRPC(ctx, info.FullMethod, start, err)
+	return err
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
+
+// UnaryClientInterceptor and StreamClientInterceptor pass the request id
+// of ctx on to the server.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+}
+
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoing(ctx), desc, cc, method, opts...)
+}
+
+func outgoing(ctx context.Context) context.Context {
+	if id := RequestIDFrom(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, requestIDHeader, id)
+	}
+	return ctx
+}
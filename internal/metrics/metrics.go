@@ -0,0 +1,198 @@
+// Package metrics holds the Prometheus collectors of every gochat role and
+// the gRPC and HTTP instrumentation that feeds them. All of them register
+// with the default registry, so all-in-one serves one /metrics for the
+// server and both gateways.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "gochat"
+
+// ----- Server -----
+
+var (
+	RPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Time spent handling gRPC calls, by method and status code. Streams are observed when they end.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	ActiveStreams = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_streams",
+		Help:      "ChatService.Stream calls currently open on this node.",
+	})
+
+	RoomSubscribers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "room_subscribers",
+		Help:      "Streams on this node subscribed to a room.",
+	}, []string{"room"})
+
+	FanOutDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "fanout_duration_seconds",
+		Help:      "Time to deliver one event to every local stream.",
+		Buckets:   []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+	})
+
+	// OutboundDropped counts events that never reached a client: "stream"
+	// for a failed send to a server stream or one too slow to keep up,
+	// "broker" for envelopes the in-process broker had no room to queue,
+	// "poll" for events pushed out of a long-poll buffer before they were
+	// collected.
+	OutboundDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbound_dropped_total",
+		Help:      "Events dropped on their way to a client, by queue.",
+	}, []string{"queue"})
+
+	// RateLimited counts calls and events turned away by a limit: "user",
+	// "room", "slow_mode" on the server, "ip" at the gateways.
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Calls and events rejected by a rate limit, by limit.",
+	}, []string{"limit"})
+
+	// ModerationDecisions counts what each moderation filter decided about
+	// the messages it did not allow as they were: "reject", "flag",
+	// "rewrite", or "error" when the filter failed and was skipped.
+	ModerationDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moderation_decisions_total",
+		Help:      "Messages rejected, flagged or rewritten by moderation, by filter and action.",
+	}, []string{"filter", "action"})
+
+	// UserRestrictions counts the mutes and shadow restrictions spam
+	// detection applied; kind is "mute" or "shadow".
+	UserRestrictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "user_restrictions_total",
+		Help:      "Users restricted by spam detection, by kind.",
+	}, []string{"kind"})
+
+	RetentionRemoved = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retention_removed_total",
+		Help:      "Messages tombstoned by retention on the rooms this node owns.",
+	})
+)
+
+// ----- WebSocket gateway -----
+
+var (
+	WSConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_connections",
+		Help:      "Open WebSocket connections.",
+	})
+
+	// WSMessages and WSBytes count data frames; direction is "in" or
+	// "out".
+	WSMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_messages_total",
+		Help:      "WebSocket data frames, by direction.",
+	}, []string{"direction"})
+
+	WSBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_bytes_total",
+		Help:      "WebSocket payload bytes, by direction.",
+	}, []string{"direction"})
+
+	HandshakeRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_handshake_rejections_total",
+		Help:      "Refused WebSocket and long-poll handshakes, by reason.",
+	}, []string{"reason"})
+)
+
+// ----- HTTP -----
+
+var httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "Time spent serving gateway HTTP requests, by route, method and status code. WebSocket requests are observed when the socket closes.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"route", "method", "code"})
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware observes every request of a chi router by its route pattern,
+// which keeps path parameters out of the labels.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := "unmatched"
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			route = rc.RoutePattern()
+		}
+		httpDuration.WithLabelValues(route, r.Method, strconv.Itoa(rec.code)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder remembers the status code. It forwards Hijack and Flush,
+// which the WebSocket upgrade and long polls need.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) Flush() {
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.code = http.StatusSwitchingProtocols
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// ----- gRPC -----
+
+// UnaryServerInterceptor observes RPCDuration for unary calls.
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	RPCDuration.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// StreamServerInterceptor observes RPCDuration for streams.
+func StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	RPCDuration.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return err
+}
@@ -0,0 +1,197 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/config"
+)
+
+// Builtin returns the filters cfg turns on, cheapest first.
+func Builtin(cfg *config.ModerationConfig) []Filter {
+	var filters []Filter
+	if cfg.MaxLength > 0 {
+		filters = append(filters, lengthFilter{max: cfg.MaxLength})
+	}
+	if len(cfg.BannedWords) > 0 {
+		filters = append(filters, newBannedWords(cfg.BannedWords, cfg.BannedWordsAction))
+	}
+	if len(cfg.LinkAllow) > 0 || len(cfg.LinkDeny) > 0 {
+		filters = append(filters, linkFilter{allow: cfg.LinkAllow, deny: cfg.LinkDeny})
+	}
+	if cfg.MaxRepeat > 0 {
+		filters = append(filters, repeatFilter{max: cfg.MaxRepeat})
+	}
+	if cfg.MaxCaps > 0 {
+		filters = append(filters, capsFilter{max: cfg.MaxCaps, minLetters: cfg.CapsMinLetters})
+	}
+	return filters
+}
+
+// ----- Length -----
+
+// lengthFilter rejects messages longer than max characters.
+type lengthFilter struct {
+	max int
+}
+
+func (lengthFilter) Name() string { return "length" }
+
+func (f lengthFilter) Check(ctx context.Context, msg *chatv1.ChatMessage) (Decision, error) {
+	if n := utf8.RuneCountInString(msg.Text); n > f.max {
+		return Decision{Action: Reject, Reason: fmt.Sprintf("message is %d characters, the limit is %d", n, f.max)}, nil
+	}
+	return Decision{}, nil
+}
+
+// ----- Banned words -----
+
+// bannedWords masks, flags or rejects messages containing any of a list of
+// words, matched whole and ignoring case.
+type bannedWords struct {
+	re     *regexp.Regexp
+	action string
+}
+
+func newBannedWords(words []string, action string) bannedWords {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	return bannedWords{
+		re:     regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`),
+		action: action,
+	}
+}
+
+func (bannedWords) Name() string { return "banned_words" }
+
+func (f bannedWords) Check(ctx context.Context, msg *chatv1.ChatMessage) (Decision, error) {
+	if !f.re.MatchString(msg.Text) {
+		return Decision{}, nil
+	}
+	switch f.action {
+	case "flag":
+		return Decision{Action: Flag, Reason: "contains a banned word"}, nil
+	case "reject":
+		return Decision{Action: Reject, Reason: "contains a banned word"}, nil
+	}
+	masked := f.re.ReplaceAllStringFunc(msg.Text, func(w string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(w))
+	})
+	return Decision{Action: Rewrite, Text: masked}, nil
+}
+
+// ----- Links -----
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// HasLink reports whether text contains a link the links filter would
+// check.
+func HasLink(text string) bool {
+	return linkPattern.MatchString(text)
+}
+
+// linkFilter rejects links to denied hosts and, when there is an allow
+// list, to any host not on it. A host matches itself and its subdomains.
+type linkFilter struct {
+	allow []string
+	deny  []string
+}
+
+func (linkFilter) Name() string { return "links" }
+
+func (f linkFilter) Check(ctx context.Context, msg *chatv1.ChatMessage) (Decision, error) {
+	for _, link := range linkPattern.FindAllString(msg.Text, -1) {
+		if !strings.Contains(link, "://") {
+			link = "http://" + link
+		}
+		u, err := url.Parse(link)
+		if err != nil || u.Hostname() == "" {
+			return Decision{Action: Reject, Reason: "contains a malformed link"}, nil
+		}
+		host := strings.ToLower(u.Hostname())
+		if matchHost(host, f.deny) || (len(f.allow) > 0 && !matchHost(host, f.allow)) {
+			return Decision{Action: Reject, Reason: fmt.Sprintf("links to %s are not allowed", host)}, nil
+		}
+	}
+	return Decision{}, nil
+}
+
+func matchHost(host string, list []string) bool {
+	for _, h := range list {
+		h = strings.ToLower(h)
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// ----- Repetition -----
+
+// repeatFilter squeezes runs of one character longer than max down to max,
+// so "noooooooooooo" loses its tail.
+type repeatFilter struct {
+	max int
+}
+
+func (repeatFilter) Name() string { return "repetition" }
+
+func (f repeatFilter) Check(ctx context.Context, msg *chatv1.ChatMessage) (Decision, error) {
+	var b strings.Builder
+	var last rune
+	run, squeezed := 0, false
+	for _, r := range msg.Text {
+		if r == last {
+			run++
+		} else {
+			last, run = r, 1
+		}
+		if run > f.max {
+			squeezed = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	if !squeezed {
+		return Decision{}, nil
+	}
+	return Decision{Action: Rewrite, Text: b.String()}, nil
+}
+
+// ----- Caps -----
+
+// capsFilter flags shouting: messages with at least minLetters letters,
+// more than max of them upper case.
+type capsFilter struct {
+	max        float64
+	minLetters int
+}
+
+func (capsFilter) Name() string { return "caps" }
+
+func (f capsFilter) Check(ctx context.Context, msg *chatv1.ChatMessage) (Decision, error) {
+	var letters, upper int
+	for _, r := range msg.Text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters == 0 || letters < f.minLetters {
+		return Decision{}, nil
+	}
+	if ratio := float64(upper) / float64(letters); ratio > f.max {
+		return Decision{Action: Flag, Reason: fmt.Sprintf("%.0f%% of the letters are upper case", ratio*100)}, nil
+	}
+	return Decision{}, nil
+}
@@ -0,0 +1,145 @@
+// Package moderation runs every chat message through a chain of filters
+// before the server stores it. A filter allows a message, rejects it, flags
+// it for a moderator or rewrites its text. The built-in filters come from
+// config; plugins are any other Filter added to the Pipeline.
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/metrics"
+)
+
+// Action is what a filter decided about a message.
+type Action int
+
+const (
+	// Allow lets the message through as it is.
+	Allow Action = iota
+	// Flag lets the message through and queues it for review.
+	Flag
+	// Rewrite lets the message through with Decision.Text as its text.
+	Rewrite
+	// Reject refuses the message; later filters don't see it.
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Allow:
+		return "allow"
+	case Flag:
+		return "flag"
+	case Rewrite:
+		return "rewrite"
+	case Reject:
+		return "reject"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Decision is a filter's verdict on one message.
+type Decision struct {
+	Action Action
+	// Reason is shown to moderators for Flag and to the sender for Reject.
+	Reason string
+	// Text replaces the message text with Rewrite.
+	Text string
+}
+
+// Filter inspects a message. Filters run in order and each sees the text
+// as rewritten by the ones before it. A filter that returns an error is
+// logged and skipped, so a broken plugin doesn't stop the chat.
+type Filter interface {
+	Name() string
+	Check(ctx context.Context, msg *chatv1.ChatMessage) (Decision, error)
+}
+
+// Rejected is the error Run returns for a refused message.
+type Rejected struct {
+	Filter string
+	Reason string
+}
+
+func (r *Rejected) Error() string {
+	return fmt.Sprintf("message rejected by %s: %s", r.Filter, r.Reason)
+}
+
+// Verdict is what the filters made of a message they let through.
+type Verdict struct {
+	Flags []*chatv1.ModerationFlag
+	// OriginalText is the text as sent, when a filter rewrote it.
+	OriginalText string
+}
+
+// Pipeline is the filter chain: the built-in filters, then the plugins in
+// the order they were added.
+type Pipeline struct {
+	builtin atomic.Pointer[[]Filter]
+
+	mu      sync.RWMutex
+	plugins []Filter
+}
+
+func NewPipeline(cfg *config.ModerationConfig) *Pipeline {
+	p := &Pipeline{}
+	p.Configure(cfg)
+	return p
+}
+
+// Configure replaces the built-in filters with the ones cfg describes.
+// Plugins are kept.
+func (p *Pipeline) Configure(cfg *config.ModerationConfig) {
+	filters := Builtin(cfg)
+	p.builtin.Store(&filters)
+}
+
+// Use adds a plugin at the end of the chain.
+func (p *Pipeline) Use(f Filter) {
+	p.mu.Lock()
+	p.plugins = append(p.plugins, f)
+	p.mu.Unlock()
+}
+
+// Run passes msg through every filter, rewriting msg.Text in place. It
+// returns a *Rejected error when a filter refuses the message.
+func (p *Pipeline) Run(ctx context.Context, msg *chatv1.ChatMessage) (Verdict, error) {
+	filters := *p.builtin.Load()
+	p.mu.RLock()
+	filters = append(filters[:len(filters):len(filters)], p.plugins...)
+	p.mu.RUnlock()
+
+	var v Verdict
+	original := msg.Text
+	for _, f := range filters {
+		d, err := f.Check(ctx, msg)
+		if err != nil {
+			metrics.ModerationDecisions.WithLabelValues(f.Name(), "error").Inc()
+			slog.WarnContext(ctx, "moderation filter failed, skipping it", "filter", f.Name(), logging.RoomID, msg.RoomId, "err", err)
+			continue
+		}
+		if d.Action != Allow {
+			metrics.ModerationDecisions.WithLabelValues(f.Name(), d.Action.String()).Inc()
+		}
+		switch d.Action {
+		case Flag:
+			v.Flags = append(v.Flags, &chatv1.ModerationFlag{Filter: f.Name(), Reason: d.Reason})
+		case Rewrite:
+			msg.Text = d.Text
+		case Reject:
+			msg.Text = original
+			return Verdict{}, &Rejected{Filter: f.Name(), Reason: d.Reason}
+		}
+	}
+	if msg.Text != original {
+		v.OriginalText = original
+	}
+	return v, nil
+}
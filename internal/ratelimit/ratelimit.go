@@ -0,0 +1,206 @@
+// Package ratelimit throttles clients with token buckets, one per key
+// (user, room, client IP), and enforces per-member cooldowns for slow-mode
+// rooms.
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qinyul/go-chat/internal/metrics"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Limit is a bucket refilling Rate tokens per second up to Burst. A zero
+// Rate means no limit.
+type Limit struct {
+	Rate  float64
+	Burst float64
+}
+
+// sweepEvery is how often idle buckets are forgotten.
+const sweepEvery = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter holds a token bucket per key, all with the same Limit. Buckets
+// start full.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     Limit
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func New(limit Limit) *Limiter {
+	return &Limiter{limit: limit, buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// SetLimit changes the limit of every bucket from now on.
+func (l *Limiter) SetLimit(limit Limit) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+}
+
+// Allow takes cost tokens from key's bucket. When there aren't enough it
+// takes none and returns how long until there will be.
+func (l *Limiter) Allow(key string, cost float64) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit.Rate <= 0 {
+		return true, 0
+	}
+	now := time.Now()
+	l.sweep(now)
+
+	b := l.refill(key, now)
+	if b.tokens >= cost {
+		b.tokens -= cost
+		return true, 0
+	}
+	wait := (cost - b.tokens) / l.limit.Rate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// Refund gives back tokens taken by Allow, for a call that a later limit
+// rejected.
+func (l *Limiter) Refund(key string, cost float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = min(b.tokens+cost, l.limit.Burst)
+	}
+}
+
+func (l *Limiter) refill(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.limit.Burst, last: now}
+		l.buckets[key] = b
+		return b
+	}
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate, l.limit.Burst)
+	b.last = now
+	return b
+}
+
+// sweep drops buckets that have refilled: they behave like new ones.
+// l.mu must be held.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepEvery {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate >= l.limit.Burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// ----- Cooldowns -----
+
+// Cooldown lets each key act once per interval, as slow mode does for the
+// members of a room. The interval is given per call, so rooms can differ.
+type Cooldown struct {
+	mu        sync.Mutex
+	until     map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewCooldown() *Cooldown {
+	return &Cooldown{until: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+// Allow starts key's cooldown of interval, unless one is running, in which
+// case it returns how long is left.
+func (c *Cooldown) Allow(key string, interval time.Duration) (bool, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if now.Sub(c.lastSweep) >= sweepEvery {
+		c.lastSweep = now
+		for k, t := range c.until {
+			if now.After(t) {
+				delete(c.until, k)
+			}
+		}
+	}
+	if t, ok := c.until[key]; ok && now.Before(t) {
+		return false, t.Sub(now)
+	}
+	c.until[key] = now.Add(interval)
+	return true, 0
+}
+
+// ----- gRPC -----
+
+// RetryAfterKey is the trailer telling a rate limited caller how many
+// seconds to wait. The status carries the exact delay as RetryInfo.
+const RetryAfterKey = "retry-after"
+
+// RetryAfter formats d for the Retry-After header and retry-after
+// metadata: whole seconds, rounded up.
+func RetryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Error is ResourceExhausted with the delay as RetryInfo.
+func Error(wait time.Duration, msg string) error {
+	st := status.New(codes.ResourceExhausted, msg)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// RetryDelay returns the RetryInfo delay of a rate limited error.
+func RetryDelay(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			return info.RetryDelay.AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// ----- HTTP -----
+
+// ClientIP is the address the request came from. Headers set by proxies
+// are not trusted.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Middleware answers 429 Too Many Requests, with Retry-After, to client IPs
+// over their limit.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := l.Allow(ClientIP(r), 1); !ok {
+			metrics.RateLimited.WithLabelValues("ip").Inc()
+			w.Header().Set("Retry-After", RetryAfter(wait))
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,254 @@
+// Package restgateway exposes ChatService as a small JSON REST API.
+package restgateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Server struct {
+	grpcClient chatv1.ChatServiceClient
+	// tokenAuth authenticates searches and room memberships; nil when
+	// rest.api_tokens_file is unset.
+	tokenAuth atomic.Pointer[auth.TokenAuth]
+	// requestTimeout bounds each backend call; reloaded on SIGHUP.
+	requestTimeout atomic.Int64
+	// ips limits the requests of each client IP.
+	ips *ratelimit.Limiter
+}
+
+// New returns a REST gateway that calls the ChatService on conn.
+func New(cfg *config.Config, conn grpc.ClientConnInterface) (*Server, error) {
+	s := &Server{
+		grpcClient: chatv1.NewChatServiceClient(conn),
+		ips:        ratelimit.New(ratelimit.Limit{Rate: cfg.RateLimit.IPRate, Burst: cfg.RateLimit.IPBurst}),
+	}
+	s.requestTimeout.Store(int64(cfg.Backend.RequestTimeout))
+	if err := s.loadTokens(cfg.REST.APITokensFile); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Mount registers the REST routes on r.
+func (s *Server) Mount(r chi.Router) {
+	r = r.With(s.ips.Middleware)
+	r.Post("/messages", s.handleSendMessage)
+	r.Get("/messages", s.handleGetMessages)
+	r.Get("/search", s.handleSearchMessages)
+	r.Put("/rooms/{room_id}/membership", s.handleJoinRoom)
+	r.Delete("/rooms/{room_id}/membership", s.handleLeaveRoom)
+}
+
+// Reload applies the reloadable settings of cfg.
+func (s *Server) Reload(cfg *config.Config) error {
+	if err := s.loadTokens(cfg.REST.APITokensFile); err != nil {
+		return err
+	}
+	s.requestTimeout.Store(int64(cfg.Backend.RequestTimeout))
+	s.ips.SetLimit(ratelimit.Limit{Rate: cfg.RateLimit.IPRate, Burst: cfg.RateLimit.IPBurst})
+	return nil
+}
+
+func (s *Server) loadTokens(path string) error {
+	var tokenAuth *auth.TokenAuth
+	if path != "" {
+		var err error
+		if tokenAuth, err = auth.LoadTokenAuth(path); err != nil {
+			return fmt.Errorf("failed to load API tokens: %w", err)
+		}
+	}
+	s.tokenAuth.Store(tokenAuth)
+	return nil
+}
+
+// POST /messages → forwards to RPC SendMessage
+func (s *Server) handleSendMessage(w http.ResponseWriter, r *http.Request) {
+	var req chatv1.SendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.requestTimeout.Load()))
+	defer cancel()
+
+	slog.DebugContext(ctx, "sending message", logging.RoomID, req.GetMessage().GetRoomId(), logging.UserID, req.GetMessage().GetSenderId(),
+		"text", logging.Content(req.GetMessage().GetText()))
+
+	resp, err := s.grpcClient.SendMessage(ctx, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// GET /messages?room_id=abc&limit=20 → forwards to RPC
+func (s *Server) handleGetMessages(w http.ResponseWriter, r *http.Request) {
+	roomID := r.URL.Query().Get("room_id")
+	limitStr := r.URL.Query().Get("limit")
+
+	var limit int32 = 20
+	if limitStr != "" {
+		fmt.Sscan(limitStr, &limit)
+	}
+
+	req := &chatv1.GetMessageRequest{
+		RoomId: roomID,
+		Limit:  limit,
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.requestTimeout.Load()))
+	defer cancel()
+
+	resp, err := s.grpcClient.Getmessages(ctx, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// GET /search?q=text&room_id=a&room_id=b&sender_id=s&since=<RFC 3339>&until=<RFC 3339>&limit=20&page_token=t
+// → forwards to RPC SearchMessages for the bearer token's user
+func (s *Server) handleSearchMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.authenticate(w, r, "search")
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	req := &chatv1.SearchMessagesRequest{
+		Query:     params.Get("q"),
+		RoomIds:   params["room_id"],
+		SenderIds: params["sender_id"],
+		PageToken: params.Get("page_token"),
+		Limit:     20,
+	}
+	if limit := params.Get("limit"); limit != "" {
+		fmt.Sscan(limit, &req.Limit)
+	}
+	for _, t := range []struct {
+		name string
+		dst  **timestamppb.Timestamp
+	}{{"since", &req.Since}, {"until", &req.Until}} {
+		if v := params.Get(t.name); v != "" {
+			at, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, t.name+": "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			*t.dst = timestamppb.New(at)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.requestTimeout.Load()))
+	defer cancel()
+
+	resp, err := s.grpcClient.SearchMessages(auth.WithUser(ctx, userID), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// PUT /rooms/{room_id}/membership → forwards to RPC JoinRoom for the
+// bearer token's user
+func (s *Server) handleJoinRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.authenticate(w, r, "room membership")
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.requestTimeout.Load()))
+	defer cancel()
+	_, err := s.grpcClient.JoinRoom(auth.WithUser(ctx, userID), &chatv1.JoinRoomRequest{RoomId: chi.URLParam(r, "room_id")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /rooms/{room_id}/membership → forwards to RPC LeaveRoom for the
+// bearer token's user
+func (s *Server) handleLeaveRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.authenticate(w, r, "room membership")
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.requestTimeout.Load()))
+	defer cancel()
+	_, err := s.grpcClient.LeaveRoom(auth.WithUser(ctx, userID), &chatv1.LeaveRoomRequest{RoomId: chi.URLParam(r, "room_id")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authenticate returns the user of the request's API token, or answers
+// the request itself when there is none. what names the feature in the
+// answer when no tokens are configured.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request, what string) (string, bool) {
+	tokenAuth := s.tokenAuth.Load()
+	if tokenAuth == nil {
+		http.Error(w, what+" is not configured", http.StatusNotFound)
+		return "", false
+	}
+	userID, ok := tokenAuth.Authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+// writeError answers with the HTTP status matching the gRPC code of err,
+// and Retry-After when the call was rate limited.
+func writeError(w http.ResponseWriter, err error) {
+	if wait, ok := ratelimit.RetryDelay(err); ok {
+		w.Header().Set("Retry-After", ratelimit.RetryAfter(wait))
+	}
+	code := http.StatusInternalServerError
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		code = http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		code = http.StatusPreconditionFailed
+	case codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		code = http.StatusGatewayTimeout
+	}
+	http.Error(w, err.Error(), code)
+}
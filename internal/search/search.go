@@ -0,0 +1,277 @@
+// Package search is an in-memory inverted index over chat messages. Text
+// is split into lowercase words of letters and digits; a query matches the
+// messages holding every one of its words, where a word ending in * matches
+// any word it starts.
+package search
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+)
+
+// Query selects messages. Zero fields don't filter.
+type Query struct {
+	// Text holds the words every match must contain.
+	Text string
+	// Rooms limits the search to these rooms.
+	Rooms []string
+	// Senders limits the search to messages from these senders.
+	Senders []string
+	// Since and Until bound created_at: Since included, Until excluded.
+	Since, Until time.Time
+	// After resumes a search past the last message of a previous page.
+	After *Cursor
+	// Limit caps the number of messages returned.
+	Limit int
+}
+
+// Cursor is a position in search order: newest first, then by room and
+// latest sequence first.
+type Cursor struct {
+	CreatedAt time.Time
+	RoomID    string
+	Sequence  uint64
+}
+
+// CursorOf is the position of msg.
+func CursorOf(msg *chatv1.ChatMessage) Cursor {
+	return Cursor{CreatedAt: msg.CreatedAt.AsTime(), RoomID: msg.RoomId, Sequence: msg.Sequence}
+}
+
+// Compare orders c before d when c comes first in search order.
+func (c Cursor) Compare(d Cursor) int {
+	if n := d.CreatedAt.Compare(c.CreatedAt); n != 0 {
+		return n
+	}
+	if n := cmp.Compare(c.RoomID, d.RoomID); n != 0 {
+		return n
+	}
+	return cmp.Compare(d.Sequence, c.Sequence)
+}
+
+// key identifies a message: ids are unique within a room, and unlike
+// sequences don't change when history is imported ahead of it.
+type key struct {
+	room, id string
+}
+
+type doc struct {
+	msg   *chatv1.ChatMessage
+	words []string
+}
+
+// Index is safe for concurrent use. It keeps the messages it is given, so
+// they must not be changed afterwards.
+type Index struct {
+	mu    sync.RWMutex
+	docs  map[key]*doc
+	words map[string]map[key]struct{} // word → messages containing it
+	rooms map[string]map[key]struct{} // room → its messages
+}
+
+func New() *Index {
+	return &Index{
+		docs:  make(map[key]*doc),
+		words: make(map[string]map[key]struct{}),
+		rooms: make(map[string]map[key]struct{}),
+	}
+}
+
+// Put indexes msg, replacing the message of the same room and id.
+// Tombstones are removed instead.
+func (x *Index) Put(msg *chatv1.ChatMessage) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.put(msg)
+}
+
+func (x *Index) put(msg *chatv1.ChatMessage) {
+	k := key{msg.RoomId, msg.Id}
+	x.remove(k)
+	if msg.Tombstone {
+		return
+	}
+	d := &doc{msg: msg, words: words(msg.Text)}
+	x.docs[k] = d
+	for _, w := range d.words {
+		add(x.words, w, k)
+	}
+	add(x.rooms, k.room, k)
+}
+
+// Delete removes the message of a room with the given id.
+func (x *Index) Delete(roomID, id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(key{roomID, id})
+}
+
+// SetRoom replaces everything indexed for a room with msgs.
+func (x *Index) SetRoom(roomID string, msgs []*chatv1.ChatMessage) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for k := range x.rooms[roomID] {
+		x.remove(k)
+	}
+	for _, msg := range msgs {
+		x.put(msg)
+	}
+}
+
+func (x *Index) remove(k key) {
+	d, ok := x.docs[k]
+	if !ok {
+		return
+	}
+	delete(x.docs, k)
+	for _, w := range d.words {
+		drop(x.words, w, k)
+	}
+	drop(x.rooms, k.room, k)
+}
+
+// Search returns the messages matching q in search order, at most q.Limit
+// of them when it is positive.
+func (x *Index) Search(q Query) []*chatv1.ChatMessage {
+	want := terms(q.Text)
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	rooms := q.Rooms
+	if len(rooms) == 0 {
+		for room := range x.rooms {
+			rooms = append(rooms, room)
+		}
+	}
+	allowed := make(map[string]bool, len(rooms))
+	for _, room := range rooms {
+		allowed[room] = true
+	}
+	senders := make(map[string]bool, len(q.Senders))
+	for _, s := range q.Senders {
+		senders[s] = true
+	}
+
+	var out []*chatv1.ChatMessage
+	x.candidates(want, allowed, func(k key) {
+		msg := x.docs[k].msg
+		if !allowed[k.room] || len(senders) > 0 && !senders[msg.SenderId] {
+			return
+		}
+		at := msg.CreatedAt.AsTime()
+		if !q.Since.IsZero() && at.Before(q.Since) || !q.Until.IsZero() && !at.Before(q.Until) {
+			return
+		}
+		if q.After != nil && CursorOf(msg).Compare(*q.After) <= 0 {
+			return
+		}
+		out = append(out, msg)
+	})
+	slices.SortFunc(out, func(a, b *chatv1.ChatMessage) int { return CursorOf(a).Compare(CursorOf(b)) })
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out
+}
+
+// candidates calls fn with each message holding every term, or with every
+// message of the allowed rooms when there are no terms.
+func (x *Index) candidates(terms []string, allowed map[string]bool, fn func(key)) {
+	if len(terms) == 0 {
+		for room := range allowed {
+			for k := range x.rooms[room] {
+				fn(k)
+			}
+		}
+		return
+	}
+	sets := make([]map[key]struct{}, 0, len(terms))
+	for _, t := range terms {
+		set := x.matching(t)
+		if len(set) == 0 {
+			return
+		}
+		sets = append(sets, set)
+	}
+	slices.SortFunc(sets, func(a, b map[key]struct{}) int { return cmp.Compare(len(a), len(b)) })
+next:
+	for k := range sets[0] {
+		for _, set := range sets[1:] {
+			if _, ok := set[k]; !ok {
+				continue next
+			}
+		}
+		fn(k)
+	}
+}
+
+// matching returns the messages holding term, or for a prefix term any
+// word it starts.
+func (x *Index) matching(term string) map[key]struct{} {
+	prefix, ok := strings.CutSuffix(term, "*")
+	if !ok {
+		return x.words[term]
+	}
+	set := make(map[key]struct{})
+	for w, docs := range x.words {
+		if strings.HasPrefix(w, prefix) {
+			for k := range docs {
+				set[k] = struct{}{}
+			}
+		}
+	}
+	return set
+}
+
+// words splits text into its distinct lowercase words.
+func words(text string) []string {
+	ws := tokens(text)
+	slices.Sort(ws)
+	return slices.Compact(ws)
+}
+
+// tokens splits text into lowercase words of letters and digits, in order.
+func tokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// terms splits a query into words the way words splits text. A word
+// written with a trailing * keeps it, to match as a prefix: of
+// "release-not*" only "not" is.
+func terms(query string) []string {
+	var out []string
+	for _, f := range strings.Fields(query) {
+		ts := tokens(f)
+		if len(ts) > 0 && strings.HasSuffix(f, "*") {
+			ts[len(ts)-1] += "*"
+		}
+		for _, t := range ts {
+			if !slices.Contains(out, t) {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+func add(m map[string]map[key]struct{}, name string, k key) {
+	if m[name] == nil {
+		m[name] = make(map[key]struct{})
+	}
+	m[name][k] = struct{}{}
+}
+
+func drop(m map[string]map[key]struct{}, name string, k key) {
+	delete(m[name], k)
+	if len(m[name]) == 0 {
+		delete(m, name)
+	}
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+)
+
+func TestTerms(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"deploy", []string{"deploy"}},
+		{"Release notes*", []string{"release", "notes*"}},
+		{"zeta-alp*", []string{"zeta", "alp*"}},
+		{"b a a", []string{"b", "a"}},
+		{"go go*", []string{"go", "go*"}},
+		{"-*", nil},
+	} {
+		if got := terms(tc.query); !slices.Equal(got, tc.want) {
+			t.Errorf("terms(%q) = %q, want %q", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestIndexFollowsChangedMessages(t *testing.T) {
+	msg := func(id, text string) *chatv1.ChatMessage {
+		return &chatv1.ChatMessage{Id: id, RoomId: "dev", Text: text}
+	}
+	for _, tc := range []struct {
+		name   string
+		change func(x *Index)
+		query  string
+		want   []string
+	}{
+		{"unchanged", func(x *Index) {}, "deploy", []string{"m1"}},
+		{"edited away", func(x *Index) { x.Put(msg("m1", "rollback friday")) }, "deploy", nil},
+		{"edited in", func(x *Index) { x.Put(msg("m1", "rollback friday")) }, "rollback", []string{"m1"}},
+		{"edited twice", func(x *Index) { x.Put(msg("m2", "deploy later")); x.Put(msg("m2", "deploy now")) }, "deploy", []string{"m1", "m2"}},
+		{"deleted", func(x *Index) { x.Delete("dev", "m1") }, "deploy", nil},
+		{"tombstoned", func(x *Index) { x.Put(&chatv1.ChatMessage{Id: "m1", RoomId: "dev", Tombstone: true}) }, "deploy", nil},
+		{"deleted elsewhere", func(x *Index) { x.Delete("ops", "m1") }, "deploy", []string{"m1"}},
+	} {
+		x := New()
+		x.Put(msg("m1", "deploy friday"))
+		x.Put(msg("m2", "lunch"))
+		tc.change(x)
+		var got []string
+		for _, m := range x.Search(Query{Text: tc.query}) {
+			got = append(got, m.Id)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: search %q = %q, want %q", tc.name, tc.query, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/audit"
+	"github.com/qinyul/go-chat/internal/certs"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/spam"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ActorKey is the metadata a node forwarding an admin call names the
+// operator in, as the node first reached found them. It is honoured only
+// on calls a peer forwarded; everyone else's client certificate names the
+// actor.
+const ActorKey = "x-gochat-actor"
+
+// AdminServer implements AdminService. Calls about a room go to its owner,
+// which keeps the room's settings and moderation queue; calls about a user
+// go to the user's home node.
+type AdminServer struct {
+	chatv1.UnimplementedAdminServiceServer
+	chat *ChatServer
+	keys *keyDirectory
+}
+
+func (a *AdminServer) GetRoomSettings(ctx context.Context, req *chatv1.GetRoomSettingsRequest) (*chatv1.GetRoomSettingsResponse, error) {
+	if req.GetRoomId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id is required")
+	}
+	client, err := a.ownerClient(ctx, req.RoomId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.GetRoomSettings(a.forwardContext(ctx), req)
+	}
+	settings, err := a.chat.store.RoomSettings(ctx, req.RoomId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "read room settings: %v", err)
+	}
+	return &chatv1.GetRoomSettingsResponse{Settings: settings}, nil
+}
+
+func (a *AdminServer) UpdateRoomSettings(ctx context.Context, req *chatv1.UpdateRoomSettingsRequest) (*chatv1.UpdateRoomSettingsResponse, error) {
+	settings := req.GetSettings()
+	if settings.GetRoomId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "settings.room_id is required")
+	}
+	if settings.SlowMode != nil && (!settings.SlowMode.IsValid() || settings.SlowMode.AsDuration() < 0) {
+		return nil, status.Error(codes.InvalidArgument, "settings.slow_mode must be a positive duration")
+	}
+	if age := settings.GetRetention().GetMaxAge(); age != nil && (!age.IsValid() || age.AsDuration() < 0) {
+		return nil, status.Error(codes.InvalidArgument, "settings.retention.max_age must be a positive duration")
+	}
+	client, err := a.ownerClient(ctx, settings.RoomId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.UpdateRoomSettings(a.forwardContext(ctx), req)
+	}
+	if err := a.chat.store.SetRoomSettings(ctx, settings); err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "store room settings: %v", err)
+	}
+	slog.InfoContext(ctx, "room settings updated", logging.RoomID, settings.RoomId, "slow_mode", settings.GetSlowMode().AsDuration(), "e2e", settings.E2E,
+		"retention", retentionLabel(settings.Retention), "legal_hold", settings.LegalHold)
+	a.chat.audit(ctx, &chatv1.AuditEvent{
+		Actor:  actor(ctx),
+		Action: auditRoomSettings,
+		RoomId: settings.RoomId,
+		Details: map[string]string{
+			"slow_mode":  settings.GetSlowMode().AsDuration().String(),
+			"e2e":        strconv.FormatBool(settings.E2E),
+			"retention":  retentionLabel(settings.Retention),
+			"legal_hold": strconv.FormatBool(settings.LegalHold),
+		},
+	})
+	return &chatv1.UpdateRoomSettingsResponse{Settings: settings}, nil
+}
+
+// ListFlaggedMessages reads the review queue of a room from its owner. For
+// every room it asks each node for the rooms it owns and merges them.
+func (a *AdminServer) ListFlaggedMessages(ctx context.Context, req *chatv1.ListFlaggedMessagesRequest) (*chatv1.ListFlaggedMessagesResponse, error) {
+	limit := int(req.GetLimit())
+	if req.GetRoomId() != "" {
+		client, err := a.ownerClient(ctx, req.RoomId)
+		if err != nil {
+			return nil, err
+		}
+		if client != nil {
+			return client.ListFlaggedMessages(a.forwardContext(ctx), req)
+		}
+		return &chatv1.ListFlaggedMessagesResponse{Messages: a.chat.flagged.list(req.RoomId, limit)}, nil
+	}
+
+	flagged := a.chat.flagged.list("", limit)
+	if isForwarded(ctx) {
+		return &chatv1.ListFlaggedMessagesResponse{Messages: flagged}, nil
+	}
+	for _, n := range a.chat.cluster.Members() {
+		if a.chat.cluster.IsSelf(n) {
+			continue
+		}
+		client, err := a.chat.cluster.adminClient(n)
+		if err == nil {
+			var resp *chatv1.ListFlaggedMessagesResponse
+			resp, err = client.ListFlaggedMessages(a.forwardContext(ctx), req)
+			flagged = append(flagged, resp.GetMessages()...)
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "node %s unreachable: %v", n.ID, err)
+		}
+	}
+	return &chatv1.ListFlaggedMessagesResponse{Messages: latestFlagged(flagged, limit)}, nil
+}
+
+// ListUserRestrictions reads a user's restrictions from their home node.
+// For every user it asks each node and merges the answers.
+func (a *AdminServer) ListUserRestrictions(ctx context.Context, req *chatv1.ListUserRestrictionsRequest) (*chatv1.ListUserRestrictionsResponse, error) {
+	if req.GetUserId() != "" {
+		client, err := a.homeClient(ctx, req.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if client != nil {
+			return client.ListUserRestrictions(a.forwardContext(ctx), req)
+		}
+		return &chatv1.ListUserRestrictionsResponse{Restrictions: a.chat.spam.Restrictions(req.UserId, req.IncludeInactive)}, nil
+	}
+
+	restrictions := a.chat.spam.Restrictions("", req.GetIncludeInactive())
+	if isForwarded(ctx) {
+		return &chatv1.ListUserRestrictionsResponse{Restrictions: restrictions}, nil
+	}
+	for _, n := range a.chat.cluster.Members() {
+		if a.chat.cluster.IsSelf(n) {
+			continue
+		}
+		client, err := a.chat.cluster.adminClient(n)
+		if err == nil {
+			var resp *chatv1.ListUserRestrictionsResponse
+			resp, err = client.ListUserRestrictions(a.forwardContext(ctx), req)
+			restrictions = append(restrictions, resp.GetRestrictions()...)
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "node %s unreachable: %v", n.ID, err)
+		}
+	}
+	spam.Sort(restrictions)
+	return &chatv1.ListUserRestrictionsResponse{Restrictions: restrictions}, nil
+}
+
+func (a *AdminServer) LiftUserRestriction(ctx context.Context, req *chatv1.LiftUserRestrictionRequest) (*chatv1.LiftUserRestrictionResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	client, err := a.homeClient(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.LiftUserRestriction(a.forwardContext(ctx), req)
+	}
+	r := a.chat.spam.Lift(req.UserId, actor(ctx))
+	if r != nil {
+		slog.InfoContext(ctx, "user restriction lifted", logging.UserID, req.UserId, "kind", restrictionLabel(r.Kind), "actor", r.LiftedBy)
+		a.chat.audit(ctx, &chatv1.AuditEvent{
+			Actor:   r.LiftedBy,
+			Action:  auditRestrictionLift,
+			UserId:  req.UserId,
+			Details: map[string]string{"restriction_id": r.Id, "kind": restrictionLabel(r.Kind)},
+		})
+	}
+	return &chatv1.LiftUserRestrictionResponse{Restriction: r}, nil
+}
+
+// ListAuditEvents reads the audit log of every node, each checking its own
+// hash chain, and merges the events that match.
+func (a *AdminServer) ListAuditEvents(ctx context.Context, req *chatv1.ListAuditEventsRequest) (*chatv1.ListAuditEventsResponse, error) {
+	filter := audit.Filter{Actor: req.GetActor(), RoomID: req.GetRoomId(), Limit: int(req.GetLimit())}
+	if req.GetSince() != nil {
+		filter.Since = req.Since.AsTime()
+	}
+	if req.GetUntil() != nil {
+		filter.Until = req.Until.AsTime()
+	}
+	events, chain, err := a.chat.auditLog.Read(filter)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "read audit log: %v", err)
+	}
+	resp := &chatv1.ListAuditEventsResponse{Events: events, Chains: []*chatv1.AuditChain{chain}}
+	if isForwarded(ctx) {
+		return resp, nil
+	}
+	for _, n := range a.chat.cluster.Members() {
+		if a.chat.cluster.IsSelf(n) {
+			continue
+		}
+		client, err := a.chat.cluster.adminClient(n)
+		if err == nil {
+			var nodeResp *chatv1.ListAuditEventsResponse
+			nodeResp, err = client.ListAuditEvents(a.forwardContext(ctx), req)
+			resp.Events = append(resp.Events, nodeResp.GetEvents()...)
+			resp.Chains = append(resp.Chains, nodeResp.GetChains()...)
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "node %s unreachable: %v", n.ID, err)
+		}
+	}
+	slices.SortStableFunc(resp.Events, func(x, y *chatv1.AuditEvent) int {
+		return x.Time.AsTime().Compare(y.Time.AsTime())
+	})
+	if filter.Limit > 0 && len(resp.Events) > filter.Limit {
+		resp.Events = resp.Events[len(resp.Events)-filter.Limit:]
+	}
+	return resp, nil
+}
+
+// ResetSigningKeys revokes every signing key of a user on their home node.
+func (a *AdminServer) ResetSigningKeys(ctx context.Context, req *chatv1.ResetSigningKeysRequest) (*chatv1.ResetSigningKeysResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	client, err := a.homeClient(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.ResetSigningKeys(a.forwardContext(ctx), req)
+	}
+	revoked, err := a.chat.signingKeys.reset(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if len(revoked) > 0 {
+		ids := make([]string, len(revoked))
+		for i, k := range revoked {
+			ids[i] = k.KeyId
+		}
+		slog.InfoContext(ctx, "signing keys reset", logging.UserID, req.UserId, "revoked", ids, "actor", actor(ctx))
+		a.chat.audit(ctx, &chatv1.AuditEvent{
+			Actor:   actor(ctx),
+			Action:  auditSigningKeysReset,
+			UserId:  req.UserId,
+			Details: map[string]string{"revoked": strings.Join(ids, ",")},
+		})
+	}
+	return &chatv1.ResetSigningKeysResponse{Revoked: revoked}, nil
+}
+
+// ownerClient returns a client for the room's owner, or nil when this node
+// owns the room or the call was already forwarded to it.
+func (a *AdminServer) ownerClient(ctx context.Context, roomID string) (chatv1.AdminServiceClient, error) {
+	owner := a.chat.cluster.Owner(roomID)
+	if a.chat.cluster.IsSelf(owner) || isForwarded(ctx) {
+		return nil, nil
+	}
+	client, err := a.chat.cluster.adminClient(owner)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "room owner %s unreachable: %v", owner.ID, err)
+	}
+	return client, nil
+}
+
+// homeClient returns a client for the user's home node, or nil when this
+// node is home to the user or the call was already forwarded to it.
+func (a *AdminServer) homeClient(ctx context.Context, userID string) (chatv1.AdminServiceClient, error) {
+	home := a.chat.homeNode(userID)
+	if a.chat.cluster.IsSelf(home) || isForwarded(ctx) {
+		return nil, nil
+	}
+	client, err := a.chat.cluster.adminClient(home)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "home node %s of user %s unreachable: %v", home.ID, userID, err)
+	}
+	return client, nil
+}
+
+// forwardContext tags a call forwarded to another node, keeping the actor
+// it was made by.
+func (a *AdminServer) forwardContext(ctx context.Context) context.Context {
+	return a.chat.cluster.forwardContext(metadata.AppendToOutgoingContext(ctx, ActorKey, actor(ctx)))
+}
+
+// actor names who made an admin call: on a call a peer forwarded, the
+// ActorKey metadata; otherwise the first name in the verified client
+// certificate, else "admin".
+func actor(ctx context.Context) string {
+	if isForwarded(ctx) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md.Get(ActorKey); len(v) > 0 && v[0] != "" {
+			return v[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			if names := certs.PeerNames(info.State.PeerCertificates[0]); len(names) > 0 {
+				return names[0]
+			}
+		}
+	}
+	return "admin"
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/logging"
+)
+
+// Audit actions, and the actors of what the node does on its own.
+const (
+	auditRoomSettings     = "room.settings.update"
+	auditUserRestrict     = "user.restrict"
+	auditRestrictionLift  = "user.restriction.lift"
+	auditConfigReload     = "config.reload"
+	auditSigningKeysReset = "user.signing_keys.reset"
+	auditUserErase        = "user.erase"
+	auditRoomImport       = "room.import"
+	auditRetention        = "room.retention.compact"
+	auditActorSpam        = "spam"
+	auditActorSighup      = "sighup"
+	auditActorRetention   = "retention"
+)
+
+// audit records an action in the node's audit log. The action has
+// already happened, so a failure to record it is logged, not returned.
+func (s *ChatServer) audit(ctx context.Context, e *chatv1.AuditEvent) {
+	if err := s.auditLog.Record(e); err != nil {
+		slog.ErrorContext(ctx, "failed to record audit event", "action", e.Action, "actor", e.Actor,
+			logging.RoomID, e.RoomId, logging.UserID, e.UserId, "err", err)
+	}
+}
+
+// auditRestriction records a restriction spam detection applied.
+func (s *ChatServer) auditRestriction(ctx context.Context, r *chatv1.UserRestriction, roomID string) {
+	s.audit(ctx, &chatv1.AuditEvent{
+		Actor:  auditActorSpam,
+		Action: auditUserRestrict,
+		RoomId: roomID,
+		UserId: r.UserId,
+		Details: map[string]string{
+			"restriction_id": r.Id,
+			"kind":           restrictionLabel(r.Kind),
+			"signals":        strings.Join(r.Signals, "; "),
+			"until":          r.ExpiresAt.AsTime().UTC().Format(time.RFC3339),
+		},
+	})
+}
+
+// auditReload records the settings a reload changed, if any.
+func (s *ChatServer) auditReload(old, next *config.Config) {
+	changed := config.Changed(old, next)
+	if len(changed) == 0 {
+		return
+	}
+	s.audit(context.Background(), &chatv1.AuditEvent{
+		Actor:   auditActorSighup,
+		Action:  auditConfigReload,
+		Details: map[string]string{"settings": strings.Join(changed, ",")},
+	})
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/metrics"
+	"github.com/qinyul/go-chat/internal/tracing"
+	"google.golang.org/protobuf/proto"
+)
+
+// Envelope is what nodes exchange over the Broker: a stream event tagged
+// with the node that published it and a unique id for de-duplication.
+type Envelope struct {
+	NodeID  string
+	EventID string
+	// SkipStream is the id of the stream the event came from; it is not
+	// echoed back to its sender.
+	SkipStream string
+	Event      *chatv1.StreamEvent
+}
+
+// Broker fans stream events out between server nodes. broadcast publishes
+// every event that reaches this node from a client, and every node
+// subscribes so its own clients see events published elsewhere.
+type Broker interface {
+	Publish(ctx context.Context, env *Envelope) error
+	// Subscribe calls handler for every envelope published by any node,
+	// this one included, until ctx is done.
+	Subscribe(ctx context.Context, handler func(*Envelope)) error
+	// Ready reports why events can't be published, or nil when they can.
+	Ready(ctx context.Context) error
+	Close() error
+}
+
+var errBrokerClosed = errors.New("broker closed")
+
+// MemoryBroker is the in-process Broker. A single node uses it as a no-op
+// bus; several ChatServers in one process can share one to behave like a
+// cluster. Each subscriber gets its envelopes from its own queue, so a
+// slow handler holds up neither publishers nor other subscribers; once
+// its queue is full it misses envelopes, as it would off a real broker.
+type MemoryBroker struct {
+	mu     sync.RWMutex
+	closed bool
+	nextID int
+	queues map[int]chan *Envelope
+}
+
+// memoryBrokerQueue is how many envelopes wait for a slow subscriber.
+const memoryBrokerQueue = 1024
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{queues: make(map[int]chan *Envelope)}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, env *Envelope) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return errBrokerClosed
+	}
+	for _, q := range b.queues {
+		// Each subscriber gets its own copy, as it would off the wire.
+		select {
+		case q <- &Envelope{
+			NodeID:     env.NodeID,
+			EventID:    env.EventID,
+			SkipStream: env.SkipStream,
+			Event:      proto.Clone(env.Event).(*chatv1.StreamEvent),
+		}:
+		default:
+			metrics.OutboundDropped.WithLabelValues("broker").Inc()
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, handler func(*Envelope)) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return errBrokerClosed
+	}
+	id := b.nextID
+	b.nextID++
+	q := make(chan *Envelope, memoryBrokerQueue)
+	b.queues[id] = q
+	b.mu.Unlock()
+
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			delete(b.queues, id)
+			b.mu.Unlock()
+		}()
+		for {
+			select {
+			case env := <-q:
+				handler(env)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *MemoryBroker) Ready(ctx context.Context) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return errBrokerClosed
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.queues = make(map[int]chan *Envelope)
+	return nil
+}
+
+// dedupCache remembers the last size event ids so an envelope delivered
+// twice (broker redelivery, reconnect replay) is only fanned out once.
+type dedupCache struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+	ring []string
+	next int
+}
+
+func newDedupCache(size int) *dedupCache {
+	return &dedupCache{
+		seen: make(map[string]struct{}, size),
+		ring: make([]string, size),
+	}
+}
+
+// firstSeen records id and reports whether it was new.
+func (d *dedupCache) firstSeen(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.seen[id]; ok {
+		return false
+	}
+	if old := d.ring[d.next]; old != "" {
+		delete(d.seen, old)
+	}
+	d.ring[d.next] = id
+	d.next = (d.next + 1) % len(d.ring)
+	d.seen[id] = struct{}{}
+	return true
+}
+
+// deliverRemote is the Broker subscription handler. Events this node
+// published were already delivered locally by broadcast and are skipped.
+func (s *ChatServer) deliverRemote(env *Envelope) {
+	if env.NodeID == s.nodeID {
+		return
+	}
+	if !s.seen.firstSeen(env.EventID) {
+		slog.Debug("dropping duplicate event", "event_id", env.EventID, "node", env.NodeID)
+		return
+	}
+	ctx, span := tracing.StartEvent(context.Background(), "broker.deliver", env.Event)
+	defer span.End()
+	s.fanOut(ctx, env.Event, env.SkipStream)
+}
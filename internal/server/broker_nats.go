@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	headerNodeID  = "Gochat-Node"
+	headerEventID = "Nats-Msg-Id"
+	headerSkip    = "Gochat-Skip-Stream"
+)
+
+// NATSBroker fans events out over a NATS subject. The event travels as
+// serialized protobuf in the message body; node and event ids ride in
+// headers.
+type NATSBroker struct {
+	nc      *nats.Conn
+	subject string
+}
+
+func NewNATSBroker(url, subject, nodeID string) (*NATSBroker, error) {
+	nc, err := nats.Connect(url,
+		nats.Name("gochat-"+nodeID),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			slog.Warn("nats disconnected", "err", err)
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			slog.Info("nats reconnected", "url", nc.ConnectedUrl())
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSBroker{nc: nc, subject: subject}, nil
+}
+
+func (b *NATSBroker) Publish(ctx context.Context, env *Envelope) error {
+	data, err := proto.Marshal(env.Event)
+	if err != nil {
+		return err
+	}
+	msg := nats.NewMsg(b.subject)
+	msg.Header.Set(headerNodeID, env.NodeID)
+	msg.Header.Set(headerEventID, env.EventID)
+	msg.Header.Set(headerSkip, env.SkipStream)
+	msg.Data = data
+	return b.nc.PublishMsg(msg)
+}
+
+func (b *NATSBroker) Subscribe(ctx context.Context, handler func(*Envelope)) error {
+	sub, err := b.nc.Subscribe(b.subject, func(msg *nats.Msg) {
+		evt := &chatv1.StreamEvent{}
+		if err := proto.Unmarshal(msg.Data, evt); err != nil {
+			slog.Warn("dropping malformed broker message", "err", err)
+			return
+		}
+		handler(&Envelope{
+			NodeID:     msg.Header.Get(headerNodeID),
+			EventID:    msg.Header.Get(headerEventID),
+			SkipStream: msg.Header.Get(headerSkip),
+			Event:      evt,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		sub.Unsubscribe()
+	}()
+	return nil
+}
+
+func (b *NATSBroker) Ready(ctx context.Context) error {
+	if !b.nc.IsConnected() {
+		return fmt.Errorf("nats is %s", b.nc.Status())
+	}
+	return nil
+}
+
+func (b *NATSBroker) Close() error {
+	return b.nc.Drain()
+}
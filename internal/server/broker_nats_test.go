@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+)
+
+func runNATS(t *testing.T, port int) *natsserver.Server {
+	t.Helper()
+	opts := natstest.DefaultTestOptions
+	opts.Port = port
+	ns := natstest.RunServer(&opts)
+	t.Cleanup(ns.Shutdown)
+	return ns
+}
+
+func newTestNATSBroker(t *testing.T, url, nodeID string) *NATSBroker {
+	t.Helper()
+	b, err := NewNATSBroker(url, "gochat.test", nodeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestNATSBrokerDeliversAcrossNodes(t *testing.T) {
+	ns := runNATS(t, -1)
+	a := startNode(t, "a", newTestNATSBroker(t, ns.ClientURL(), "a"), nil, nil)
+	b := startNode(t, "b", newTestNATSBroker(t, ns.ClientURL(), "b"), nil, nil)
+	sa, sb := a.openStream(t), b.openStream(t)
+
+	sa.send(t, "alice", "lobby", "hello from a")
+	if msg := sb.waitMessage(t, "hello from a"); msg.SenderId != "alice" {
+		t.Errorf("sender_id = %q, want alice", msg.SenderId)
+	}
+	sb.send(t, "bob", "lobby", "hello from b")
+	sa.waitMessage(t, "hello from b")
+}
+
+func TestNATSBrokerReconnects(t *testing.T) {
+	ns := runNATS(t, -1)
+	port := ns.Addr().(*net.TCPAddr).Port
+	ba := newTestNATSBroker(t, ns.ClientURL(), "a")
+	bb := newTestNATSBroker(t, ns.ClientURL(), "b")
+	a := startNode(t, "a", ba, nil, nil)
+	b := startNode(t, "b", bb, nil, nil)
+	sa, sb := a.openStream(t), b.openStream(t)
+
+	sa.send(t, "alice", "lobby", "before restart")
+	sb.waitMessage(t, "before restart")
+
+	ns.Shutdown()
+	eventually(t, "brokers to notice the outage", func() bool {
+		return ba.Ready(context.Background()) != nil && bb.Ready(context.Background()) != nil
+	})
+	runNATS(t, port)
+	// The client waits a couple of seconds between reconnect attempts.
+	waitFor(t, "brokers to reconnect", 10*time.Second, func() bool {
+		return ba.Ready(context.Background()) == nil && bb.Ready(context.Background()) == nil
+	})
+
+	sa.send(t, "alice", "lobby", "after restart")
+	sb.waitMessage(t, "after restart")
+	sb.send(t, "bob", "lobby", "reply after restart")
+	sa.waitMessage(t, "reply after restart")
+}
@@ -0,0 +1,743 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/audit"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/metrics"
+	"github.com/qinyul/go-chat/internal/moderation"
+	"github.com/qinyul/go-chat/internal/ratelimit"
+	"github.com/qinyul/go-chat/internal/spam"
+	"github.com/qinyul/go-chat/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ChatServer implements ChatServiceServer
+type ChatServer struct {
+	chatv1.UnimplementedChatServiceServer
+
+	mu      sync.Mutex
+	clients map[string]*streamConn // stream id → stream
+	// rooms holds, per stream id, the rooms the stream joined by starting
+	// a room stream or posting to it. It feeds the room_subscribers metric.
+	rooms map[string]map[string]struct{}
+	store MessageStore
+
+	// settings holds the reloadable server config.
+	settings atomic.Pointer[config.ServerConfig]
+
+	// nodeID identifies this process on the broker.
+	nodeID  string
+	broker  Broker
+	seen    *dedupCache
+	cluster *Cluster
+	// slowMode holds the cooldowns of members of slow-mode rooms this
+	// node owns.
+	slowMode *ratelimit.Cooldown
+	// moderation filters the messages of rooms this node owns; flagged
+	// holds the ones it flagged until a moderator looks at them.
+	moderation *moderation.Pipeline
+	flagged    *flagQueue
+	// spam scores the users this node is home to; restricted holds what
+	// other home nodes reported about their users. spamSettings is the
+	// reloadable spam config.
+	spam         *spam.Detector
+	restricted   *restrictionCache
+	spamSettings atomic.Pointer[config.SpamConfig]
+	// auditLog records the admin and moderation actions taken on this node.
+	auditLog *audit.Log
+	// signingKeys are the message signing keys of the users this node is
+	// home to.
+	signingKeys *keyRegistry
+
+	// draining rejects new calls once shutdown has begun; closing tells
+	// live streams to say goodbye and end.
+	draining  atomic.Bool
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewChatServer(cfg *config.ServerConfig, mod *config.ModerationConfig, spamCfg *config.SpamConfig, auditLog *audit.Log, signingKeys *keyRegistry, store MessageStore, broker Broker, cluster *Cluster) *ChatServer {
+	s := &ChatServer{
+		clients:     make(map[string]*streamConn),
+		rooms:       make(map[string]map[string]struct{}),
+		store:       store,
+		nodeID:      cfg.NodeID,
+		broker:      broker,
+		seen:        newDedupCache(4096),
+		cluster:     cluster,
+		slowMode:    ratelimit.NewCooldown(),
+		moderation:  moderation.NewPipeline(mod),
+		flagged:     newFlagQueue(mod.ReviewQueue),
+		spam:        spam.New(spamCfg),
+		restricted:  newRestrictionCache(),
+		auditLog:    auditLog,
+		signingKeys: signingKeys,
+		closing:     make(chan struct{}),
+	}
+	s.settings.Store(cfg)
+	s.spamSettings.Store(spamCfg)
+	return s
+}
+
+var errShuttingDown = status.Error(codes.Unavailable, "server is shutting down")
+
+// Shutdown stops accepting new calls and tells every live stream the
+// server is going away. Streams end once their goodbye is sent; in-flight
+// unary calls are left to finish.
+func (s *ChatServer) Shutdown() {
+	s.closeOnce.Do(func() {
+		s.draining.Store(true)
+		close(s.closing)
+	})
+}
+
+func (s *ChatServer) SendMessage(ctx context.Context, req *chatv1.SendMessageRequest) (*chatv1.SendmessageResponse, error) {
+	if req == nil || req.Message == nil {
+		return nil, status.Error(codes.InvalidArgument, "message is required")
+	}
+	if s.draining.Load() {
+		return nil, errShuttingDown
+	}
+
+	msg := req.Message
+
+	if msg.Id == "" {
+		msg.Id = uuid.NewString()
+	}
+
+	if msg.CreatedAt == nil {
+		msg.CreatedAt = timestamppb.Now()
+	}
+
+	if msg.RoomId == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id is required")
+	}
+
+	if msg.SenderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "sender_id is required")
+	}
+
+	// The room's owner sequences and stores its messages.
+	if owner := s.cluster.Owner(msg.RoomId); !s.cluster.IsSelf(owner) && !isForwarded(ctx) {
+		client, err := s.cluster.chatClient(owner)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "room owner %s unreachable: %v", owner.ID, err)
+		}
+		slog.DebugContext(ctx, "forwarding message to room owner", logging.RoomID, msg.RoomId, "owner", owner.ID)
+		return client.SendMessage(s.cluster.forwardContext(ctx), req)
+	}
+
+	if _, err := s.commitMessage(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "message sent", logging.RoomID, msg.RoomId, logging.UserID, msg.SenderId,
+		"message_id", msg.Id, "sequence", msg.Sequence, "text", logging.Content(msg.Text))
+	// Return the full message
+	return &chatv1.SendmessageResponse{
+		Message: msg,
+	}, nil
+}
+
+// Getmessages returns the latest messages of a room, oldest first
+func (s *ChatServer) Getmessages(ctx context.Context, req *chatv1.GetMessageRequest) (*chatv1.GetmessagesResponse, error) {
+	if req.GetRoomId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id is required")
+	}
+
+	if owner := s.cluster.Owner(req.RoomId); !s.cluster.IsSelf(owner) && !isForwarded(ctx) {
+		client, err := s.cluster.chatClient(owner)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "room owner %s unreachable: %v", owner.ID, err)
+		}
+		return client.Getmessages(s.cluster.forwardContext(ctx), req)
+	}
+
+	limit := int(req.Limit)
+	if maxLimit := s.settings.Load().MaxPageSize; limit <= 0 || limit > maxLimit {
+		limit = maxLimit
+	}
+	if req.BeforeSequence == 0 {
+		msgs, err := s.store.List(ctx, req.RoomId, limit)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list messages: %v", err)
+		}
+		return &chatv1.GetmessagesResponse{Message: msgs}, nil
+	}
+	msgs, err := s.store.List(ctx, req.RoomId, 0)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list messages: %v", err)
+	}
+	end, _ := slices.BinarySearchFunc(msgs, req.BeforeSequence, func(m *chatv1.ChatMessage, seq uint64) int {
+		return cmp.Compare(m.Sequence, seq)
+	})
+	return &chatv1.GetmessagesResponse{Message: msgs[max(0, end-limit):end]}, nil
+}
+
+// JoinRoom makes the calling user a member of a room until they leave it.
+func (s *ChatServer) JoinRoom(ctx context.Context, req *chatv1.JoinRoomRequest) (*chatv1.JoinRoomResponse, error) {
+	userID, ok := auth.UserFrom(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "joining a room needs an authenticated user")
+	}
+	if req.GetRoomId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id is required")
+	}
+	if err := s.setMember(ctx, req.RoomId, userID, true); err != nil {
+		return nil, err
+	}
+	return &chatv1.JoinRoomResponse{}, nil
+}
+
+// LeaveRoom ends the calling user's membership of a room.
+func (s *ChatServer) LeaveRoom(ctx context.Context, req *chatv1.LeaveRoomRequest) (*chatv1.LeaveRoomResponse, error) {
+	userID, ok := auth.UserFrom(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "leaving a room needs an authenticated user")
+	}
+	if req.GetRoomId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id is required")
+	}
+	if err := s.setMember(ctx, req.RoomId, userID, false); err != nil {
+		return nil, err
+	}
+	return &chatv1.LeaveRoomResponse{}, nil
+}
+
+// setMember records userID joining or leaving a room on the room's owner,
+// which keeps its members with its history.
+func (s *ChatServer) setMember(ctx context.Context, roomID, userID string, member bool) error {
+	if owner := s.cluster.Owner(roomID); !s.cluster.IsSelf(owner) && !isForwarded(ctx) {
+		client, err := s.cluster.chatClient(owner)
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "room owner %s unreachable: %v", owner.ID, err)
+		}
+		fctx := auth.WithUser(s.cluster.forwardContext(ctx), userID)
+		if member {
+			_, err = client.JoinRoom(fctx, &chatv1.JoinRoomRequest{RoomId: roomID})
+		} else {
+			_, err = client.LeaveRoom(fctx, &chatv1.LeaveRoomRequest{RoomId: roomID})
+		}
+		return err
+	}
+	if err := s.store.SetRoomMember(ctx, roomID, userID, member); err != nil {
+		return status.Errorf(codes.Internal, "set room member: %v", err)
+	}
+	slog.InfoContext(ctx, "room membership changed", logging.RoomID, roomID, logging.UserID, userID, "member", member)
+	return nil
+}
+
+// ChatStream handles bidirectional streaming
+func (s *ChatServer) Stream(stream chatv1.ChatService_StreamServer) error {
+	if s.draining.Load() {
+		return errShuttingDown
+	}
+
+	// Register client
+	streamID := uuid.NewString()
+	ctx, cancel := context.WithCancel(logging.With(context.Background(),
+		logging.RequestID, logging.RequestIDFrom(stream.Context()), logging.StreamID, streamID))
+	defer cancel()
+	conn := newStreamConn(s.settings.Load().StreamBuffer)
+	conn.user, _ = auth.UserFrom(stream.Context())
+	replyRateLimited(stream.Context(), conn.push)
+	go conn.writeLoop(stream)
+	s.mu.Lock()
+	s.clients[streamID] = conn
+	s.mu.Unlock()
+	metrics.ActiveStreams.Inc()
+	slog.InfoContext(ctx, "stream opened")
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, streamID)
+		s.mu.Unlock()
+		s.leaveRooms(streamID)
+		metrics.ActiveStreams.Dec()
+		// gRPC forbids sending once the handler has returned.
+		conn.stop(nil)
+		<-conn.done
+	}()
+
+	incoming := make(chan *chatv1.StreamEvent)
+	errs := make(chan error)
+
+	// --- Recv goroutine ---
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				slog.InfoContext(ctx, "stream closed by client")
+				errs <- nil
+				return
+			}
+			if err != nil {
+				st, ok := status.FromError(err)
+				if ok {
+					switch st.Code() {
+					case codes.Canceled, codes.Unavailable:
+						slog.InfoContext(ctx, "stream closed", "reason", st.Message())
+						errs <- nil
+						return
+					}
+				}
+				slog.ErrorContext(ctx, "stream receive failed", "err", err)
+				errs <- err
+				return
+			}
+
+			select {
+			case incoming <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case evt := <-incoming:
+			userID, roomID := eventParties(evt)
+			ectx := logging.With(ctx, logging.UserID, userID, logging.RoomID, roomID)
+			switch payload := evt.Payload.(type) {
+			case *chatv1.StreamEvent_Message:
+				slog.DebugContext(ectx, "stream message", "text", logging.Content(payload.Message.Text))
+			case *chatv1.StreamEvent_Typing:
+				slog.DebugContext(ectx, "stream typing", "is_typing", payload.Typing.IsTyping)
+			case *chatv1.StreamEvent_Presence:
+				slog.DebugContext(ectx, "stream presence", "online", payload.Presence.Online)
+			case *chatv1.StreamEvent_Control:
+				slog.DebugContext(ectx, "stream control", "action", payload.Control.Action)
+			default:
+				slog.WarnContext(ectx, "unknown stream event payload", "payload", fmt.Sprintf("%T", payload))
+			}
+			s.trackRooms(streamID, evt)
+			// Starting a room's stream joins it for good; stopping only
+			// ends the stream.
+			if ctl := evt.GetControl(); conn.user != "" && ctl.GetAction() == chatv1.ControlAction_CONTROL_ACTION_START_STREAM && ctl.GetRoomId() != "" {
+				jctx, cancel := context.WithTimeout(ectx, s.settings.Load().ForwardTimeout)
+				if err := s.setMember(jctx, ctl.RoomId, conn.user, true); err != nil {
+					slog.WarnContext(ectx, "failed to join room", "err", err)
+				}
+				cancel()
+			}
+			ectx, span := tracing.StartEvent(ectx, "ChatService.Stream/event", evt)
+			if msg := evt.GetMessage(); msg != nil && msg.RoomId != "" {
+				s.routeMessage(ectx, evt, streamID)
+			} else {
+				s.broadcast(ectx, evt, streamID)
+			}
+			span.End()
+		case err := <-errs:
+			return err
+		case <-conn.stopped:
+			return conn.err
+		case <-ctx.Done():
+			return nil
+		case <-s.closing:
+			slog.InfoContext(ctx, "server shutting down, closing stream")
+			goingAway := &chatv1.StreamEvent{
+				Type: chatv1.EventType_EVENT_TYPE_CONTROL,
+				Payload: &chatv1.StreamEvent_Control{Control: &chatv1.ControlEvent{
+					Action: chatv1.ControlAction_CONTROL_ACTION_GOING_AWAY,
+					Reason: "server going away",
+				}},
+			}
+			if !conn.push(goingAway) {
+				slog.WarnContext(ctx, "failed to send going away", "err", conn.err)
+			}
+			// Let the writer send what is queued, the goodbye last.
+			conn.flush()
+			<-conn.done
+			return nil
+		}
+
+	}
+}
+
+// errSlowStream ends the stream of a client that let more than
+// server.stream_buffer events queue up.
+var errSlowStream = status.Error(codes.ResourceExhausted, "stream fell behind; reconnect and page through Getmessages for what was missed")
+
+// streamConn is a Stream as the rest of the node sees it. gRPC allows one
+// sender per stream, so events are queued with push and sent by writeLoop
+// alone; nobody waits on a slow client.
+type streamConn struct {
+	// user is who the gateway opened the stream for, or "".
+	user string
+	out  chan *chatv1.StreamEvent
+	// flushing asks writeLoop to send what is queued and end; stopped
+	// ends it at once, err says why. done is closed when writeLoop ends.
+	flushing  chan struct{}
+	stopped   chan struct{}
+	done      chan struct{}
+	flushOnce sync.Once
+	stopOnce  sync.Once
+	err       error
+}
+
+func newStreamConn(buffer int) *streamConn {
+	return &streamConn{
+		out:      make(chan *chatv1.StreamEvent, buffer),
+		flushing: make(chan struct{}),
+		stopped:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// push queues evt without blocking. A client whose queue is full is
+// stopped with errSlowStream. It reports whether evt was queued; an event
+// that wasn't is counted as dropped here, and nowhere else.
+func (c *streamConn) push(evt *chatv1.StreamEvent) bool {
+	select {
+	case <-c.stopped:
+		metrics.OutboundDropped.WithLabelValues("stream").Inc()
+		return false
+	default:
+	}
+	select {
+	case c.out <- evt:
+		return true
+	default:
+		c.stop(errSlowStream)
+		metrics.OutboundDropped.WithLabelValues("stream").Inc()
+		return false
+	}
+}
+
+// stop ends the stream's writer, recording err as the reason.
+func (c *streamConn) stop(err error) {
+	c.stopOnce.Do(func() {
+		c.err = err
+		close(c.stopped)
+	})
+}
+
+// flush ends the stream's writer once it has sent what is queued.
+func (c *streamConn) flush() {
+	c.flushOnce.Do(func() { close(c.flushing) })
+}
+
+func (c *streamConn) writeLoop(stream chatv1.ChatService_StreamServer) {
+	defer close(c.done)
+	send := func(evt *chatv1.StreamEvent) bool {
+		if err := stream.Send(evt); err != nil {
+			metrics.OutboundDropped.WithLabelValues("stream").Inc()
+			c.stop(err)
+			return false
+		}
+		return true
+	}
+	for {
+		select {
+		case evt := <-c.out:
+			if !send(evt) {
+				return
+			}
+		case <-c.stopped:
+			return
+		case <-c.flushing:
+			for {
+				select {
+				case evt := <-c.out:
+					if !send(evt) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// commitMessage fills in id and timestamp if absent, runs the message
+// through moderation and spam detection and stores it, which assigns its
+// room sequence, unless it breaks the room's encryption setting,
+// moderation refuses it, its signature doesn't verify, its sender is muted
+// or slow mode holds it back. It marks whether the signature verified and
+// reports whether the message should be broadcast:
+// a shadow-restricted sender's message is neither stored nor broadcast,
+// but looks sent to them. Only the room's owner calls it.
+func (s *ChatServer) commitMessage(ctx context.Context, msg *chatv1.ChatMessage) (bool, error) {
+	settings, err := s.store.RoomSettings(ctx, msg.RoomId)
+	if err != nil {
+		return false, status.Errorf(codes.Internal, "read room settings: %v", err)
+	}
+	if err := checkEncryption(msg, settings); err != nil {
+		return false, err
+	}
+	verdict, err := s.moderate(ctx, msg)
+	if err != nil {
+		return false, err
+	}
+	// The sender's home node verifies what the sender signed.
+	sent := msg
+	if verdict.OriginalText != "" {
+		sent = proto.Clone(msg).(*chatv1.ChatMessage)
+		sent.Text = verdict.OriginalText
+	}
+	check, err := s.checkSender(ctx, sent)
+	if err != nil {
+		return false, err
+	}
+	// A message moderation rewrote is no longer the one signed.
+	msg.Verified = check.verified && verdict.OriginalText == ""
+	switch r := check.restriction; r.GetKind() {
+	case chatv1.RestrictionKind_RESTRICTION_KIND_MUTE:
+		return false, mutedError(r)
+	case chatv1.RestrictionKind_RESTRICTION_KIND_SHADOW:
+		slog.DebugContext(ctx, "message of shadow-restricted user dropped", logging.RoomID, msg.RoomId, logging.UserID, msg.SenderId)
+		return false, nil
+	}
+	if err := s.checkSlowMode(msg, settings); err != nil {
+		return false, err
+	}
+	if msg.Id == "" {
+		msg.Id = uuid.NewString()
+	}
+	if msg.CreatedAt == nil {
+		msg.CreatedAt = timestamppb.Now()
+	}
+
+	ctx, span := tracing.Start(ctx, "store.Append", attribute.String("chat.room_id", msg.RoomId), attribute.String("chat.message_id", msg.Id))
+	defer span.End()
+	if _, err := s.store.Append(ctx, msg); err != nil {
+		tracing.Fail(span, err)
+		slog.ErrorContext(ctx, "failed to store message", logging.RoomID, msg.RoomId, "message_id", msg.Id, "err", err)
+		if _, ok := status.FromError(err); ok {
+			return false, err
+		}
+		return false, status.Errorf(codes.Internal, "store message: %v", err)
+	}
+	s.queueFlagged(ctx, msg, verdict)
+	return true, nil
+}
+
+// routeMessage sequences a stream message locally when this node owns the
+// room, otherwise hands it to the owner, which broadcasts it to every node.
+func (s *ChatServer) routeMessage(ctx context.Context, evt *chatv1.StreamEvent, streamID string) {
+	msg := evt.GetMessage()
+	owner := s.cluster.Owner(msg.RoomId)
+	if s.cluster.IsSelf(owner) {
+		visible, err := s.commitMessage(ctx, msg)
+		if err != nil {
+			s.notifyRejected(streamID, msg.RoomId, err)
+			return
+		}
+		if visible {
+			s.broadcast(ctx, evt, streamID)
+		}
+		return
+	}
+
+	client, err := s.cluster.clusterClient(owner)
+	if err != nil {
+		slog.ErrorContext(ctx, "room owner unreachable", "owner", owner.ID, "err", err)
+		return
+	}
+	fctx, cancel := context.WithTimeout(s.cluster.forwardContext(ctx), s.settings.Load().ForwardTimeout)
+	defer cancel()
+	_, err = client.ForwardEvent(fctx, &chatv1.ForwardEventRequest{
+		Event:          evt,
+		OriginNodeId:   s.nodeID,
+		OriginStreamId: streamID,
+	})
+	if err != nil && !s.notifyRejected(streamID, msg.RoomId, err) {
+		trace.SpanFromContext(ctx).RecordError(err)
+		slog.ErrorContext(ctx, "failed to forward message to room owner", "owner", owner.ID, "err", err)
+	}
+}
+
+// rebalance hands every room this node stores but no longer owns to its
+// new owner.
+func (s *ChatServer) rebalance() error {
+	ctx := context.Background()
+	rooms, err := s.store.Rooms(ctx)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, roomID := range rooms {
+		owner := s.cluster.Owner(roomID)
+		if s.cluster.IsSelf(owner) {
+			continue
+		}
+		msgs, err := s.store.List(ctx, roomID, 0)
+		var settings *chatv1.RoomSettings
+		if err == nil {
+			settings, err = s.store.RoomSettings(ctx, roomID)
+		}
+		var members []string
+		if err == nil {
+			members, err = s.store.RoomMembers(ctx, roomID)
+		}
+		var client chatv1.ClusterServiceClient
+		if err == nil {
+			client, err = s.cluster.clusterClient(owner)
+		}
+		if err == nil {
+			ctx, cancel := context.WithTimeout(s.cluster.forwardContext(context.Background()), 10*time.Second)
+			_, err = client.TransferRoom(ctx, &chatv1.TransferRoomRequest{
+				RoomId:     roomID,
+				FromNodeId: s.nodeID,
+				Messages:   msgs,
+				Settings:   settings,
+				Members:    members,
+			})
+			cancel()
+		}
+		if err != nil {
+			slog.Error("failed to hand over room", logging.RoomID, roomID, "owner", owner.ID, "err", err)
+			failed++
+			continue
+		}
+
+		// Anything that arrived while the transfer was in flight stays
+		// behind and goes out on the next pass.
+		if err := s.store.Drop(ctx, roomID, len(msgs)); err != nil {
+			slog.Error("failed to drop handed over room", logging.RoomID, roomID, "err", err)
+		}
+		slog.Info("handed over room", logging.RoomID, roomID, "owner", owner.ID, "messages", len(msgs))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d rooms not handed over", failed)
+	}
+	return nil
+}
+
+// eventParties returns the user and room an event is about, if any.
+func eventParties(evt *chatv1.StreamEvent) (userID, roomID string) {
+	switch p := evt.Payload.(type) {
+	case *chatv1.StreamEvent_Message:
+		return p.Message.SenderId, p.Message.RoomId
+	case *chatv1.StreamEvent_Typing:
+		return p.Typing.UserId, p.Typing.RoomId
+	case *chatv1.StreamEvent_Presence:
+		return p.Presence.UserId, ""
+	case *chatv1.StreamEvent_Control:
+		return "", p.Control.RoomId
+	}
+	return "", ""
+}
+
+// trackRooms records the room evt joins or leaves for streamID.
+func (s *ChatServer) trackRooms(streamID string, evt *chatv1.StreamEvent) {
+	var room string
+	join := true
+	switch p := evt.Payload.(type) {
+	case *chatv1.StreamEvent_Message:
+		room = p.Message.RoomId
+	case *chatv1.StreamEvent_Typing:
+		room = p.Typing.RoomId
+	case *chatv1.StreamEvent_Control:
+		room = p.Control.RoomId
+		switch p.Control.Action {
+		case chatv1.ControlAction_CONTROL_ACTION_START_STREAM:
+		case chatv1.ControlAction_CONTROL_ACTION_STOP_STREAM:
+			join = false
+		default:
+			return
+		}
+	}
+	if room == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	joined := s.rooms[streamID]
+	_, member := joined[room]
+	switch {
+	case join && !member:
+		if joined == nil {
+			joined = make(map[string]struct{})
+			s.rooms[streamID] = joined
+		}
+		joined[room] = struct{}{}
+		metrics.RoomSubscribers.WithLabelValues(room).Inc()
+	case !join && member:
+		delete(joined, room)
+		s.roomLeft(room)
+	}
+}
+
+// leaveRooms forgets the rooms of a stream that ended.
+func (s *ChatServer) leaveRooms(streamID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	joined := s.rooms[streamID]
+	delete(s.rooms, streamID)
+	for room := range joined {
+		s.roomLeft(room)
+	}
+}
+
+// roomLeft drops the room's series once nobody on this node is in it, so
+// rooms don't pile up in the metrics. s.mu must be held.
+func (s *ChatServer) roomLeft(room string) {
+	for _, joined := range s.rooms {
+		if _, ok := joined[room]; ok {
+			metrics.RoomSubscribers.WithLabelValues(room).Dec()
+			return
+		}
+	}
+	metrics.RoomSubscribers.DeleteLabelValues(room)
+}
+
+// broadcast sends event to all local clients except the sender stream and
+// publishes it to the broker for the other nodes
+func (s *ChatServer) broadcast(ctx context.Context, event *chatv1.StreamEvent, senderID string) {
+	tracing.Inject(ctx, event)
+	s.fanOut(ctx, event, senderID)
+
+	env := &Envelope{NodeID: s.nodeID, EventID: uuid.NewString(), SkipStream: senderID, Event: event}
+	if err := s.broker.Publish(context.Background(), env); err != nil {
+		slog.ErrorContext(ctx, "failed to publish event to broker", "err", err)
+	}
+}
+
+// fanOut sends event to the clients connected to this node, skipping the
+// stream with id skipID
+func (s *ChatServer) fanOut(ctx context.Context, event *chatv1.StreamEvent, skipID string) {
+	s.mu.Lock()
+	clientsCopy := make([]*streamConn, 0, len(s.clients))
+	for id, c := range s.clients {
+		if id == skipID {
+			continue
+		}
+		clientsCopy = append(clientsCopy, c)
+	}
+	s.mu.Unlock()
+
+	_, span := tracing.Start(ctx, "fanOut", attribute.Int("chat.recipients", len(clientsCopy)))
+	defer span.End()
+	start := time.Now()
+	defer func() { metrics.FanOutDuration.Observe(time.Since(start).Seconds()) }()
+	for _, client := range clientsCopy {
+		if !client.push(event) {
+			slog.WarnContext(ctx, "failed to queue event, dropping stream", "err", client.err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/metrics"
+)
+
+func TestPushCountsEachDropOnce(t *testing.T) {
+	dropped := metrics.OutboundDropped.WithLabelValues("stream")
+	before := testutil.ToFloat64(dropped)
+	c := newStreamConn(1)
+	evt := &chatv1.StreamEvent{}
+	if !c.push(evt) {
+		t.Fatal("first event not queued")
+	}
+	// The queue is full: the stream is stopped and the event dropped.
+	if c.push(evt) {
+		t.Fatal("event queued past the buffer")
+	}
+	if c.err != errSlowStream {
+		t.Errorf("stopped with %v, want errSlowStream", c.err)
+	}
+	// Later events to the stopped stream are dropped too.
+	if c.push(evt) {
+		t.Fatal("event queued on a stopped stream")
+	}
+	if got := testutil.ToFloat64(dropped) - before; got != 2 {
+		t.Errorf("counted %v drops, want 2", got)
+	}
+}
@@ -0,0 +1,424 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// forwardedByKey marks a request one node forwarded to another, so the
+// receiver handles it locally instead of forwarding it again.
+const forwardedByKey = "x-gochat-forwarded-by"
+
+// Cluster tracks membership and decides which node owns each room. The
+// owner sequences and stores a room's messages; other nodes forward to it.
+type Cluster struct {
+	self  Node
+	creds credentials.TransportCredentials
+	// dialer, when set, connects to other nodes in place of the network.
+	dialer func(ctx context.Context, addr string) (net.Conn, error)
+
+	mu      sync.RWMutex
+	members []Node
+	ring    *HashRing
+	conns   map[string]*grpc.ClientConn // node id → connection
+	// leader, when set, names the one node that owns every room and is
+	// home to every user; see pinOwner.
+	leader func() (Node, bool)
+}
+
+// NewCluster starts as a cluster of one; SetMembers adds the peers.
+func NewCluster(self Node, creds credentials.TransportCredentials) *Cluster {
+	return &Cluster{
+		self:    self,
+		creds:   creds,
+		members: []Node{self},
+		ring:    NewHashRing([]Node{self}),
+		conns:   make(map[string]*grpc.ClientConn),
+	}
+}
+
+// Owner returns the node that owns roomID.
+func (c *Cluster) Owner(roomID string) Node {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.leader != nil {
+		if n, ok := c.leader(); ok {
+			return n
+		}
+		return c.self
+	}
+	if n, ok := c.ring.Owner(roomID); ok {
+		return n
+	}
+	return c.self
+}
+
+// pinOwner makes the node leader returns the owner of every room and the
+// home of every user, in place of the ring. The Raft store pins the Raft
+// leader: every replica holds every room, but slow mode, spam scores and
+// the key directory must live on one node. While leader knows none, this
+// node owns everything.
+func (c *Cluster) pinOwner(leader func() (Node, bool)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.leader = leader
+}
+
+// Members returns every node of the cluster, this one included. With a
+// pinned owner that is this node and the owner.
+func (c *Cluster) Members() []Node {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.leader != nil {
+		if n, ok := c.leader(); ok && n.ID != c.self.ID {
+			nodes := []Node{c.self, n}
+			slices.SortFunc(nodes, func(a, b Node) int { return strings.Compare(a.ID, b.ID) })
+			return nodes
+		}
+	}
+	return slices.Clone(c.members)
+}
+
+func (c *Cluster) IsSelf(n Node) bool {
+	return n.ID == c.self.ID
+}
+
+// SetMembers replaces the membership list and rebuilds the ring. It
+// reports whether anything changed. A list that leaves this node out means
+// it is being removed: it owns no rooms and hands its history over. An
+// empty list means a cluster of one.
+func (c *Cluster) SetMembers(nodes []Node) bool {
+	if len(nodes) == 0 {
+		nodes = []Node{c.self}
+	}
+	nodes = slices.Clone(nodes)
+	slices.SortFunc(nodes, func(a, b Node) int { return strings.Compare(a.ID, b.ID) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if slices.Equal(nodes, c.members) {
+		return false
+	}
+
+	for id, conn := range c.conns {
+		i := slices.IndexFunc(nodes, func(n Node) bool { return n.ID == id })
+		if i < 0 || nodes[i].Addr != c.addrOf(id) {
+			conn.Close()
+			delete(c.conns, id)
+		}
+	}
+	c.members = nodes
+	c.ring = NewHashRing(nodes)
+	slog.Info("cluster membership changed", "nodes", len(nodes))
+	return true
+}
+
+// addrOf must be called with c.mu held.
+func (c *Cluster) addrOf(id string) string {
+	for _, n := range c.members {
+		if n.ID == id {
+			return n.Addr
+		}
+	}
+	return ""
+}
+
+func (c *Cluster) conn(n Node) (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if conn, ok := c.conns[n.ID]; ok {
+		return conn, nil
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(c.creds),
+		grpc.WithStatsHandler(tracing.ClientHandler()),
+		grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(logging.StreamClientInterceptor),
+	}
+	if c.dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(c.dialer))
+	}
+	conn, err := grpc.NewClient("dns:///"+n.Addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.conns[n.ID] = conn
+	return conn, nil
+}
+
+func (c *Cluster) chatClient(n Node) (chatv1.ChatServiceClient, error) {
+	conn, err := c.conn(n)
+	if err != nil {
+		return nil, err
+	}
+	return chatv1.NewChatServiceClient(conn), nil
+}
+
+func (c *Cluster) clusterClient(n Node) (chatv1.ClusterServiceClient, error) {
+	conn, err := c.conn(n)
+	if err != nil {
+		return nil, err
+	}
+	return chatv1.NewClusterServiceClient(conn), nil
+}
+
+func (c *Cluster) adminClient(n Node) (chatv1.AdminServiceClient, error) {
+	conn, err := c.conn(n)
+	if err != nil {
+		return nil, err
+	}
+	return chatv1.NewAdminServiceClient(conn), nil
+}
+
+func (c *Cluster) keyClient(n Node) (chatv1.KeyServiceClient, error) {
+	conn, err := c.conn(n)
+	if err != nil {
+		return nil, err
+	}
+	return chatv1.NewKeyServiceClient(conn), nil
+}
+
+// forwardContext tags an outgoing call as forwarded by this node.
+func (c *Cluster) forwardContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, forwardedByKey, c.self.ID)
+}
+
+func isForwarded(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return len(md.Get(forwardedByKey)) > 0
+}
+
+func (c *Cluster) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, conn := range c.conns {
+		conn.Close()
+		delete(c.conns, id)
+	}
+}
+
+// ----- Static membership -----
+
+// LoadPeersFile reads the membership list: one "<node_id> <address>" pair
+// per line; blank lines and lines starting with # are ignored.
+func LoadPeersFile(path string) ([]Node, error) {
+	lines, err := readFields(path, 2, `"<node_id> <address>"`)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]Node, 0, len(lines))
+	for _, f := range lines {
+		nodes = append(nodes, Node{ID: f[0], Addr: f[1]})
+	}
+	return nodes, nil
+}
+
+// readFields reads a whitespace separated file where every non-blank,
+// non-comment line has exactly n fields.
+func readFields(path string, n int, want string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines [][]string
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != n {
+			return nil, fmt.Errorf("%s:%d: want %s", path, line, want)
+		}
+		lines = append(lines, fields)
+	}
+	return lines, sc.Err()
+}
+
+// WatchPeersFile re-reads the peers file every interval and applies
+// membership changes, calling onChange after each one. onChange is retried
+// on the next tick while it keeps failing.
+func (c *Cluster) WatchPeersFile(ctx context.Context, path string, interval time.Duration, onChange func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	pending := false
+	for {
+		nodes, err := LoadPeersFile(path)
+		if err != nil {
+			slog.Error("failed to load peers file", "err", err)
+		} else if c.SetMembers(nodes) {
+			pending = true
+		}
+		if pending {
+			if err := onChange(); err != nil {
+				slog.Warn("rebalance incomplete, retrying", "err", err)
+			} else {
+				pending = false
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ----- ClusterService -----
+
+// ClusterServer implements ClusterServiceServer for the nodes' internal
+// traffic.
+type ClusterServer struct {
+	chatv1.UnimplementedClusterServiceServer
+	chat *ChatServer
+}
+
+// ForwardEvent sequences and stores a message for a room this node owns,
+// then broadcasts it to every node.
+func (cs *ClusterServer) ForwardEvent(ctx context.Context, req *chatv1.ForwardEventRequest) (*chatv1.ForwardEventResponse, error) {
+	msg := req.GetEvent().GetMessage()
+	if msg == nil || msg.RoomId == "" {
+		return nil, status.Error(codes.InvalidArgument, "event must carry a message with a room_id")
+	}
+	slog.DebugContext(ctx, "event forwarded by node", logging.RoomID, msg.RoomId, "origin", req.OriginNodeId)
+
+	visible, err := cs.chat.commitMessage(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if visible {
+		cs.chat.broadcast(ctx, req.Event, req.OriginStreamId)
+	}
+	return &chatv1.ForwardEventResponse{Event: req.Event}, nil
+}
+
+// TransferRoom accepts a room's history from its previous owner, or from
+// a follower of the replicated store importing it.
+func (cs *ClusterServer) TransferRoom(ctx context.Context, req *chatv1.TransferRoomRequest) (*chatv1.TransferRoomResponse, error) {
+	if req.RoomId == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id is required")
+	}
+	n, err := cs.chat.store.Import(ctx, req.RoomId, req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	if req.Settings != nil {
+		if err := cs.chat.store.SetRoomSettings(ctx, req.Settings); err != nil {
+			return nil, err
+		}
+	}
+	for _, userID := range req.Members {
+		if err := cs.chat.store.SetRoomMember(ctx, req.RoomId, userID, true); err != nil {
+			return nil, err
+		}
+	}
+	slog.InfoContext(ctx, "took over room", logging.RoomID, req.RoomId, "from", req.FromNodeId, "messages", n)
+	return &chatv1.TransferRoomResponse{Accepted: int32(n)}, nil
+}
+
+// AppendMessage commits a message forwarded by a follower of the
+// replicated store.
+func (cs *ClusterServer) AppendMessage(ctx context.Context, req *chatv1.AppendMessageRequest) (*chatv1.AppendMessageResponse, error) {
+	if req.GetMessage().GetRoomId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "message with a room_id is required")
+	}
+	msg, err := cs.chat.store.Append(ctx, req.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &chatv1.AppendMessageResponse{Message: msg}, nil
+}
+
+// SetRoomSettings commits room settings forwarded by a follower of the
+// replicated store.
+func (cs *ClusterServer) SetRoomSettings(ctx context.Context, req *chatv1.SetRoomSettingsRequest) (*chatv1.SetRoomSettingsResponse, error) {
+	if req.GetSettings().GetRoomId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "settings with a room_id are required")
+	}
+	if err := cs.chat.store.SetRoomSettings(ctx, req.Settings); err != nil {
+		return nil, err
+	}
+	return &chatv1.SetRoomSettingsResponse{}, nil
+}
+
+// EraseSender erases a sender's messages on the replicated store's leader.
+func (cs *ClusterServer) EraseSender(ctx context.Context, req *chatv1.EraseSenderRequest) (*chatv1.EraseSenderResponse, error) {
+	if req.GetRoomId() == "" || req.GetSenderId() == "" || req.GetAlias() == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id, sender_id and alias are required")
+	}
+	n, err := cs.chat.store.EraseSender(ctx, req.RoomId, req.SenderId, req.Alias, req.DeleteContent)
+	if err != nil {
+		return nil, err
+	}
+	return &chatv1.EraseSenderResponse{Erased: int32(n)}, nil
+}
+
+// Tombstone removes expired messages on the replicated store's leader.
+func (cs *ClusterServer) Tombstone(ctx context.Context, req *chatv1.TombstoneRequest) (*chatv1.TombstoneResponse, error) {
+	if req.GetRoomId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id is required")
+	}
+	n, err := cs.chat.store.Tombstone(ctx, req.RoomId, req.Sequences)
+	if err != nil {
+		return nil, err
+	}
+	return &chatv1.TombstoneResponse{Tombstoned: int32(n)}, nil
+}
+
+// Drop removes a room's oldest messages on the replicated store's leader.
+func (cs *ClusterServer) Drop(ctx context.Context, req *chatv1.DropRequest) (*chatv1.DropResponse, error) {
+	if req.GetRoomId() == "" || req.GetCount() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "room_id and a count of at least 0 are required")
+	}
+	if err := cs.chat.store.Drop(ctx, req.RoomId, int(req.Count)); err != nil {
+		return nil, err
+	}
+	return &chatv1.DropResponse{}, nil
+}
+
+// SetRoomMember commits a membership forwarded by a follower of the
+// replicated store.
+func (cs *ClusterServer) SetRoomMember(ctx context.Context, req *chatv1.SetRoomMemberRequest) (*chatv1.SetRoomMemberResponse, error) {
+	if req.GetRoomId() == "" || req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id and user_id are required")
+	}
+	if err := cs.chat.store.SetRoomMember(ctx, req.RoomId, req.UserId, req.Member); err != nil {
+		return nil, err
+	}
+	return &chatv1.SetRoomMemberResponse{}, nil
+}
+
+// CheckSender verifies and scores a message for a room owner on the node
+// that is home to its sender.
+func (cs *ClusterServer) CheckSender(ctx context.Context, req *chatv1.CheckSenderRequest) (*chatv1.CheckSenderResponse, error) {
+	if req.GetMessage().GetSenderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "message with a sender_id is required")
+	}
+	check, err := cs.chat.checkHomeSender(ctx, req.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &chatv1.CheckSenderResponse{Restriction: check.restriction, Verified: check.verified}, nil
+}
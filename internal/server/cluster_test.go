@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+)
+
+// roomOwnedBy returns a room the cluster of n assigns to owner, other than
+// except.
+func roomOwnedBy(t *testing.T, n *testNode, owner *testNode, except ...string) string {
+	t.Helper()
+	for i := range 1000 {
+		room := fmt.Sprintf("room-%d", i)
+		if n.chat.cluster.Owner(room).ID == owner.node.ID && !slices.Contains(except, room) {
+			return room
+		}
+	}
+	t.Fatalf("no room owned by %s", owner.node.ID)
+	return ""
+}
+
+func TestClusterRoutesMessagesToTheOwner(t *testing.T) {
+	nodes := startCluster(t, "a", "b", "c")
+	a, b, c := nodes[0], nodes[1], nodes[2]
+	room := roomOwnedBy(t, a, b)
+
+	sender := a.openStream(t)
+	var listeners []*testStream
+	for _, n := range nodes {
+		listeners = append(listeners, n.openStream(t))
+	}
+
+	// A stream message to a room a doesn't own goes through its owner.
+	sender.send(t, "alice", room, "over the stream")
+	for i, s := range listeners {
+		if msg := s.waitMessage(t, "over the stream"); msg.Sequence != 1 {
+			t.Errorf("node %s: sequence = %d, want 1", nodes[i].node.ID, msg.Sequence)
+		}
+	}
+
+	// So does SendMessage, which the owner sequences after it.
+	resp, err := c.client.SendMessage(context.Background(), &chatv1.SendMessageRequest{
+		Message: &chatv1.ChatMessage{RoomId: room, SenderId: "carol", Text: "over SendMessage"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message.GetSequence() != 2 {
+		t.Errorf("SendMessage sequence = %d, want 2", resp.Message.GetSequence())
+	}
+
+	// Only the owner stores the room; the others read it from there.
+	for _, n := range nodes {
+		msgs, err := n.chat.store.List(context.Background(), room, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[bool]int{true: 2, false: 0}[n == b]; len(msgs) != want {
+			t.Errorf("node %s stores %d messages, want %d", n.node.ID, len(msgs), want)
+		}
+		got, err := n.client.Getmessages(context.Background(), &chatv1.GetMessageRequest{RoomId: room})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Message) != 2 {
+			t.Errorf("Getmessages on %s returned %d messages, want 2", n.node.ID, len(got.Message))
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExportRoom streams a room's history from its owner.
+func (a *AdminServer) ExportRoom(req *chatv1.ExportRoomRequest, stream chatv1.AdminService_ExportRoomServer) error {
+	if req.GetRoomId() == "" {
+		return status.Error(codes.InvalidArgument, "room_id is required")
+	}
+	ctx := stream.Context()
+	client, err := a.ownerClient(ctx, req.RoomId)
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		msgs, err := client.ExportRoom(a.forwardContext(ctx), req)
+		if err != nil {
+			return err
+		}
+		return relayStream(msgs.Recv, stream.Send)
+	}
+	msgs, err := a.chat.store.List(ctx, req.RoomId, 0)
+	if err != nil {
+		return status.Errorf(codes.Internal, "list messages: %v", err)
+	}
+	// Tombstones hold nothing to import again.
+	msgs = slices.DeleteFunc(msgs, func(m *chatv1.ChatMessage) bool { return m.Tombstone })
+	for _, m := range msgs {
+		if err := stream.Send(m); err != nil {
+			return err
+		}
+	}
+	slog.InfoContext(ctx, "room exported", logging.RoomID, req.RoomId, "messages", len(msgs), "actor", actor(ctx))
+	return nil
+}
+
+// ImportRoom collects a room's imported history on its owner and stores it
+// in one go, ahead of the messages the room already holds. Imported
+// messages skip moderation, spam detection and signature checks, and are
+// not broadcast.
+func (a *AdminServer) ImportRoom(stream chatv1.AdminService_ImportRoomServer) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "no history sent")
+	}
+	if err != nil {
+		return err
+	}
+	roomID := first.RoomId
+	if roomID == "" {
+		return status.Error(codes.InvalidArgument, "the first request must name the room_id")
+	}
+	client, err := a.ownerClient(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		up, err := client.ImportRoom(a.forwardContext(ctx))
+		if err == nil {
+			if err = up.Send(first); err == nil {
+				err = relayStream(stream.Recv, up.Send)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := up.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		return stream.SendAndClose(resp)
+	}
+
+	history := first.Messages
+	err = relayStream(stream.Recv, func(req *chatv1.ImportRoomRequest) error {
+		if req.RoomId != "" && req.RoomId != roomID {
+			return status.Errorf(codes.InvalidArgument, "one import carries one room: got %s after %s", req.RoomId, roomID)
+		}
+		history = append(history, req.Messages...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return status.Error(codes.InvalidArgument, "no messages to import")
+	}
+	if err := prepareImport(roomID, history); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	n, err := a.chat.store.Import(ctx, roomID, history)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Errorf(codes.Internal, "import history: %v", err)
+	}
+	slog.InfoContext(ctx, "room history imported", logging.RoomID, roomID, "messages", n, "actor", actor(ctx))
+	a.chat.audit(ctx, &chatv1.AuditEvent{
+		Actor:   actor(ctx),
+		Action:  auditRoomImport,
+		RoomId:  roomID,
+		Details: map[string]string{"messages": strconv.Itoa(n)},
+	})
+	return stream.SendAndClose(&chatv1.ImportRoomResponse{RoomId: roomID, Imported: uint64(n)})
+}
+
+// prepareImport checks imported history and numbers it in the order
+// given. Messages without an id get one; the verified mark of another
+// server is not taken on trust.
+func prepareImport(roomID string, history []*chatv1.ChatMessage) error {
+	ids := make(map[string]struct{}, len(history))
+	for i, m := range history {
+		switch {
+		case m.RoomId == "":
+			m.RoomId = roomID
+		case m.RoomId != roomID:
+			return fmt.Errorf("message %d belongs to room %s, not %s", i+1, m.RoomId, roomID)
+		}
+		if m.SenderId == "" {
+			return fmt.Errorf("message %d has no sender_id", i+1)
+		}
+		if m.CreatedAt == nil {
+			return fmt.Errorf("message %d has no created_at", i+1)
+		}
+		if m.Id == "" {
+			m.Id = uuid.NewString()
+		}
+		if _, dup := ids[m.Id]; dup {
+			return fmt.Errorf("message id %s appears more than once", m.Id)
+		}
+		ids[m.Id] = struct{}{}
+		m.Sequence = uint64(i + 1)
+		m.Verified = false
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Bounds of what the key directory keeps per user.
+const (
+	maxOneTimePreKeys = 200
+	maxKeyEnvelopes   = 1000
+	maxEnvelopeSize   = 16 << 10
+)
+
+// KeyServer implements KeyService. A user's bundle and envelope mailbox
+// live on the user's home node, which every call about the user goes to.
+type KeyServer struct {
+	chatv1.UnimplementedKeyServiceServer
+	chat *ChatServer
+	keys *keyDirectory
+}
+
+func (k *KeyServer) PublishKeyBundle(ctx context.Context, req *chatv1.PublishKeyBundleRequest) (*chatv1.PublishKeyBundleResponse, error) {
+	b := req.GetBundle()
+	if err := validateBundle(b); err != nil {
+		return nil, err
+	}
+	if err := callerIs(ctx, b.UserId); err != nil {
+		return nil, err
+	}
+	client, err := k.homeClient(ctx, b.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.PublishKeyBundle(k.chat.cluster.forwardContext(ctx), req)
+	}
+	left, replaced := k.keys.publish(b)
+	if replaced {
+		slog.WarnContext(ctx, "identity key changed", logging.UserID, b.UserId)
+	}
+	slog.InfoContext(ctx, "key bundle published", logging.UserID, b.UserId, "one_time_prekeys", left)
+	return &chatv1.PublishKeyBundleResponse{OneTimePrekeys: uint32(left)}, nil
+}
+
+func (k *KeyServer) GetKeyBundle(ctx context.Context, req *chatv1.GetKeyBundleRequest) (*chatv1.GetKeyBundleResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	client, err := k.homeClient(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.GetKeyBundle(k.chat.cluster.forwardContext(ctx), req)
+	}
+	b := k.keys.claim(req.UserId, !req.WithoutOneTimePrekey)
+	if b == nil {
+		return nil, status.Errorf(codes.NotFound, "user %s has not published keys", req.UserId)
+	}
+	return &chatv1.GetKeyBundleResponse{Bundle: b}, nil
+}
+
+// SendKeyEnvelopes leaves each envelope on its recipient's home node,
+// forwarding the ones for users homed elsewhere in one call per node.
+func (k *KeyServer) SendKeyEnvelopes(ctx context.Context, req *chatv1.SendKeyEnvelopesRequest) (*chatv1.SendKeyEnvelopesResponse, error) {
+	remote := make(map[string][]*chatv1.KeyEnvelope)
+	nodes := make(map[string]Node)
+	for _, e := range req.GetEnvelopes() {
+		switch {
+		case e.SenderId == "" || e.RecipientId == "":
+			return nil, status.Error(codes.InvalidArgument, "envelopes need a sender_id and a recipient_id")
+		case len(e.Ciphertext) == 0 || len(e.IdentityKey) == 0 || len(e.EphemeralKey) == 0:
+			return nil, status.Error(codes.InvalidArgument, "envelopes need an identity_key, an ephemeral_key and a ciphertext")
+		case proto.Size(e) > maxEnvelopeSize:
+			return nil, status.Errorf(codes.InvalidArgument, "envelope for %s is larger than %d bytes", e.RecipientId, maxEnvelopeSize)
+		}
+		if err := callerIs(ctx, e.SenderId); err != nil {
+			return nil, err
+		}
+		if e.SentAt == nil {
+			e.SentAt = timestamppb.Now()
+		}
+		home := k.chat.homeNode(e.RecipientId)
+		if k.chat.cluster.IsSelf(home) || isForwarded(ctx) {
+			k.keys.deliver(e)
+			continue
+		}
+		remote[home.ID] = append(remote[home.ID], e)
+		nodes[home.ID] = home
+	}
+	for id, envelopes := range remote {
+		client, err := k.chat.cluster.keyClient(nodes[id])
+		if err == nil {
+			_, err = client.SendKeyEnvelopes(k.chat.cluster.forwardContext(ctx), &chatv1.SendKeyEnvelopesRequest{Envelopes: envelopes})
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "home node %s unreachable: %v", id, err)
+		}
+	}
+	return &chatv1.SendKeyEnvelopesResponse{}, nil
+}
+
+func (k *KeyServer) FetchKeyEnvelopes(ctx context.Context, req *chatv1.FetchKeyEnvelopesRequest) (*chatv1.FetchKeyEnvelopesResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if err := callerIs(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+	client, err := k.homeClient(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.FetchKeyEnvelopes(k.chat.cluster.forwardContext(ctx), req)
+	}
+	return &chatv1.FetchKeyEnvelopesResponse{Envelopes: k.keys.fetch(req.UserId)}, nil
+}
+
+// callerIs checks that the gateway authenticated userID as the caller. A
+// forwarded call was checked on the node it came in on.
+func callerIs(ctx context.Context, userID string) error {
+	if isForwarded(ctx) {
+		return nil
+	}
+	user, ok := auth.UserFrom(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "key calls need an authenticated user")
+	}
+	if user != userID {
+		return status.Errorf(codes.PermissionDenied, "authenticated as %s, not %s", user, userID)
+	}
+	return nil
+}
+
+// homeClient returns a client for the user's home node, or nil when this
+// node is home to the user or the call was already forwarded to it.
+func (k *KeyServer) homeClient(ctx context.Context, userID string) (chatv1.KeyServiceClient, error) {
+	home := k.chat.homeNode(userID)
+	if k.chat.cluster.IsSelf(home) || isForwarded(ctx) {
+		return nil, nil
+	}
+	client, err := k.chat.cluster.keyClient(home)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "home node %s of user %s unreachable: %v", home.ID, userID, err)
+	}
+	return client, nil
+}
+
+// validateBundle checks a bundle's key sizes and that its signed prekey is
+// signed by its signing key. The server can't tell whose keys they are;
+// members compare identity key fingerprints for that.
+func validateBundle(b *chatv1.KeyBundle) error {
+	spk := b.GetSignedPrekey()
+	switch {
+	case b.GetUserId() == "":
+		return status.Error(codes.InvalidArgument, "bundle.user_id is required")
+	case len(b.IdentityKey) != 32:
+		return status.Error(codes.InvalidArgument, "bundle.identity_key must be a 32 byte X25519 key")
+	case len(b.SigningKey) != ed25519.PublicKeySize:
+		return status.Error(codes.InvalidArgument, "bundle.signing_key must be a 32 byte Ed25519 key")
+	case spk == nil || len(spk.PublicKey) != 32:
+		return status.Error(codes.InvalidArgument, "bundle.signed_prekey must hold a 32 byte X25519 key")
+	case !ed25519.Verify(b.SigningKey, spk.PublicKey, spk.Signature):
+		return status.Error(codes.InvalidArgument, "bundle.signed_prekey is not signed by bundle.signing_key")
+	}
+	for _, pk := range b.OneTimePrekeys {
+		if pk.Id == 0 || len(pk.PublicKey) != 32 {
+			return status.Error(codes.InvalidArgument, "bundle.one_time_prekeys need an id and a 32 byte X25519 key")
+		}
+	}
+	return nil
+}
+
+// ----- Encrypted rooms -----
+
+// checkEncryption holds a message to its room's setting: ciphertext and no
+// text in end-to-end encrypted rooms, plain text everywhere else.
+func checkEncryption(msg *chatv1.ChatMessage, settings *chatv1.RoomSettings) error {
+	enc := msg.GetEncrypted()
+	if !settings.GetE2E() {
+		if enc != nil {
+			return rejectError(codes.InvalidArgument, reasonE2E,
+				fmt.Sprintf("room %s is not end-to-end encrypted: send text", msg.RoomId), nil)
+		}
+		return nil
+	}
+	if msg.Text != "" || len(enc.GetCiphertext()) == 0 || enc.GetKeyId() == "" {
+		return rejectError(codes.InvalidArgument, reasonE2E,
+			fmt.Sprintf("room %s is end-to-end encrypted: messages must carry ciphertext and a key id, not text", msg.RoomId), nil)
+	}
+	return nil
+}
+
+// ----- Key directory -----
+
+// keyDirectory holds the bundles and envelope mailboxes of the users this
+// node is home to. It lives in memory only: after a restart clients
+// publish their keys again and resend what their peers miss.
+type keyDirectory struct {
+	mu        sync.Mutex
+	bundles   map[string]*chatv1.KeyBundle
+	mailboxes map[string][]*chatv1.KeyEnvelope
+}
+
+func newKeyDirectory() *keyDirectory {
+	return &keyDirectory{
+		bundles:   make(map[string]*chatv1.KeyBundle),
+		mailboxes: make(map[string][]*chatv1.KeyEnvelope),
+	}
+}
+
+// publish stores b, keeping the one-time prekeys already published under
+// the same identity, and returns how many one-time prekeys are left and
+// whether b replaced another identity.
+func (d *keyDirectory) publish(b *chatv1.KeyBundle) (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	next := proto.Clone(b).(*chatv1.KeyBundle)
+	prev, ok := d.bundles[b.UserId]
+	replaced := ok && !slices.Equal(prev.IdentityKey, b.IdentityKey)
+	if ok && !replaced {
+		next.OneTimePrekeys = prev.OneTimePrekeys
+		for _, pk := range b.OneTimePrekeys {
+			if !slices.ContainsFunc(next.OneTimePrekeys, func(have *chatv1.PreKey) bool { return have.Id == pk.Id }) {
+				next.OneTimePrekeys = append(next.OneTimePrekeys, pk)
+			}
+		}
+	}
+	if n := len(next.OneTimePrekeys); n > maxOneTimePreKeys {
+		next.OneTimePrekeys = next.OneTimePrekeys[n-maxOneTimePreKeys:]
+	}
+	d.bundles[b.UserId] = next
+	return len(next.OneTimePrekeys), replaced
+}
+
+// claim returns userID's bundle, with its oldest one-time prekey, which is
+// removed, when oneTime is set. It returns nil when the user has published
+// nothing.
+func (d *keyDirectory) claim(userID string, oneTime bool) *chatv1.KeyBundle {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b, ok := d.bundles[userID]
+	if !ok {
+		return nil
+	}
+	out := &chatv1.KeyBundle{
+		UserId:       b.UserId,
+		IdentityKey:  b.IdentityKey,
+		SigningKey:   b.SigningKey,
+		SignedPrekey: b.SignedPrekey,
+	}
+	if oneTime && len(b.OneTimePrekeys) > 0 {
+		out.OneTimePrekeys = b.OneTimePrekeys[:1]
+		b.OneTimePrekeys = slices.Clone(b.OneTimePrekeys[1:])
+	}
+	return proto.Clone(out).(*chatv1.KeyBundle)
+}
+
+// deliver leaves e in its recipient's mailbox; a full mailbox drops its
+// oldest envelope.
+func (d *keyDirectory) deliver(e *chatv1.KeyEnvelope) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	box := append(d.mailboxes[e.RecipientId], e)
+	if len(box) > maxKeyEnvelopes {
+		box = slices.Clone(box[len(box)-maxKeyEnvelopes:])
+	}
+	d.mailboxes[e.RecipientId] = box
+}
+
+// fetch empties userID's mailbox.
+func (d *keyDirectory) fetch(userID string) []*chatv1.KeyEnvelope {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	box := d.mailboxes[userID]
+	delete(d.mailboxes, userID)
+	return box
+}
+
+// forget drops userID's bundle and mailbox.
+func (d *keyDirectory) forget(userID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.bundles, userID)
+	delete(d.mailboxes, userID)
+}
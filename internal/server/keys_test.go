@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qinyul/go-chat/e2ee"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestKeyCallsActForTheAuthenticatedUser(t *testing.T) {
+	n := startNode(t, "a", NewMemoryBroker(), nil, nil)
+	keys := &KeyServer{chat: n.chat, keys: newKeyDirectory()}
+	as := func(user string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(auth.UserKey, user))
+	}
+	envelope := &chatv1.KeyEnvelope{SenderId: "alice", RecipientId: "bob", IdentityKey: []byte{1}, EphemeralKey: []byte{2}, Ciphertext: []byte{3}}
+
+	_, err := keys.FetchKeyEnvelopes(context.Background(), &chatv1.FetchKeyEnvelopesRequest{UserId: "bob"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("fetch without a user: err = %v, want Unauthenticated", err)
+	}
+	_, err = keys.FetchKeyEnvelopes(as("mallory"), &chatv1.FetchKeyEnvelopesRequest{UserId: "bob"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("mallory fetching bob's envelopes: err = %v, want PermissionDenied", err)
+	}
+	_, err = keys.SendKeyEnvelopes(as("mallory"), &chatv1.SendKeyEnvelopesRequest{Envelopes: []*chatv1.KeyEnvelope{envelope}})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("mallory sending as alice: err = %v, want PermissionDenied", err)
+	}
+
+	if _, err := keys.SendKeyEnvelopes(as("alice"), &chatv1.SendKeyEnvelopesRequest{Envelopes: []*chatv1.KeyEnvelope{envelope}}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := keys.FetchKeyEnvelopes(as("bob"), &chatv1.FetchKeyEnvelopesRequest{UserId: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Envelopes) != 1 {
+		t.Errorf("bob fetched %d envelopes, want 1", len(resp.Envelopes))
+	}
+}
+
+func TestKeyDirectoryAcrossTheRaftGroup(t *testing.T) {
+	g := startRaftGroup(t, "a", "b", "c")
+	g.settle(t)
+	user := func(n *testNode, userID string) (*e2ee.Client, context.Context) {
+		t.Helper()
+		ctx := auth.WithUser(context.Background(), userID)
+		c, err := e2ee.New(userID, n.keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Publish(ctx, 4); err != nil {
+			t.Fatal(err)
+		}
+		return c, ctx
+	}
+	alice, aliceCtx := user(g.nodes[0], "alice")
+	bob, bobCtx := user(g.nodes[1], "bob")
+
+	// Bob's envelope, left through b, is in the mailbox alice reads
+	// through a.
+	msg, err := bob.Encrypt(bobCtx, "room", []string{"alice", "bob"}, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := alice.Decrypt(aliceCtx, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "hi" {
+		t.Errorf("alice decrypted %q, want hi", text)
+	}
+}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/moderation"
+	"github.com/qinyul/go-chat/internal/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrorInfo reasons of messages refused for what they say, how they say it
+// or who sent them, so the sender's node can tell them apart after a forward.
+const (
+	errorDomain      = "gochat"
+	reasonModeration = "MODERATION_REJECTED"
+	reasonMuted      = "USER_MUTED"
+	reasonE2E        = "E2E_MISMATCH"
+	reasonSignature  = "BAD_SIGNATURE"
+	reasonUnchecked  = "SENDER_UNCHECKED"
+)
+
+// rejectError is a status carrying an ErrorInfo with reason.
+func rejectError(code codes.Code, reason, msg string, metadata map[string]string) error {
+	st := status.New(code, msg)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain, Metadata: metadata}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// configureModeration applies the moderation settings of a (re)loaded
+// config.
+func (s *ChatServer) configureModeration(cfg *config.ModerationConfig) {
+	s.moderation.Configure(cfg)
+	s.flagged.resize(cfg.ReviewQueue)
+}
+
+// moderate runs msg through the moderation filters, which may rewrite its
+// text. Only the room's owner moderates, as it commits.
+func (s *ChatServer) moderate(ctx context.Context, msg *chatv1.ChatMessage) (moderation.Verdict, error) {
+	v, err := s.moderation.Run(ctx, msg)
+	var rejected *moderation.Rejected
+	if errors.As(err, &rejected) {
+		slog.InfoContext(ctx, "message rejected by moderation", logging.RoomID, msg.RoomId, logging.UserID, msg.SenderId,
+			"filter", rejected.Filter, "reason", rejected.Reason)
+		return v, rejectError(codes.InvalidArgument, reasonModeration, rejected.Error(),
+			map[string]string{"filter": rejected.Filter, "reason": rejected.Reason})
+	}
+	return v, err
+}
+
+// queueFlagged puts a stored message that filters flagged up for review.
+func (s *ChatServer) queueFlagged(ctx context.Context, msg *chatv1.ChatMessage, v moderation.Verdict) {
+	if len(v.Flags) == 0 {
+		return
+	}
+	s.flagged.add(&chatv1.FlaggedMessage{
+		Message:      proto.Clone(msg).(*chatv1.ChatMessage),
+		Flags:        v.Flags,
+		FlaggedAt:    timestamppb.Now(),
+		OriginalText: v.OriginalText,
+	})
+	slog.InfoContext(ctx, "message flagged for review", logging.RoomID, msg.RoomId, logging.UserID, msg.SenderId,
+		"message_id", msg.Id, "flags", len(v.Flags))
+}
+
+// rejectionEvent tells a stream why its last event went nowhere: it was
+// rate limited or refused for what it says, how it is signed or who sent
+// it. Other errors give nil.
+func rejectionEvent(roomID string, err error) *chatv1.StreamEvent {
+	if _, ok := ratelimit.RetryDelay(err); ok {
+		return rateLimitedEvent(roomID, err)
+	}
+	if !isRejection(err) {
+		return nil
+	}
+	return &chatv1.StreamEvent{
+		Type: chatv1.EventType_EVENT_TYPE_CONTROL,
+		Payload: &chatv1.StreamEvent_Control{Control: &chatv1.ControlEvent{
+			Action: chatv1.ControlAction_CONTROL_ACTION_REJECTED,
+			RoomId: roomID,
+			Reason: status.Convert(err).Message(),
+		}},
+	}
+}
+
+// isRejection reports whether err refuses a message, as made by
+// rejectError.
+func isRejection(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.Domain == errorDomain {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyRejected tells the stream streamID that its message was rate
+// limited or refused, and reports whether err was one of those. Other
+// errors are left to the caller.
+func (s *ChatServer) notifyRejected(streamID, roomID string, err error) bool {
+	evt := rejectionEvent(roomID, err)
+	if evt == nil {
+		return false
+	}
+	s.mu.Lock()
+	target := s.clients[streamID]
+	s.mu.Unlock()
+	if target != nil {
+		target.push(evt)
+	}
+	return true
+}
+
+// ----- Review queue -----
+
+// flagQueue holds the flagged messages of the rooms this node owns, up to
+// max per room; the oldest go first. It lives in memory only.
+type flagQueue struct {
+	mu    sync.Mutex
+	max   int
+	rooms map[string][]*chatv1.FlaggedMessage
+}
+
+func newFlagQueue(max int) *flagQueue {
+	return &flagQueue{max: max, rooms: make(map[string][]*chatv1.FlaggedMessage)}
+}
+
+func (q *flagQueue) resize(max int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.max = max
+	for room, flagged := range q.rooms {
+		if len(flagged) > max {
+			q.rooms[room] = slices.Clone(flagged[len(flagged)-max:])
+		}
+	}
+}
+
+func (q *flagQueue) add(fm *chatv1.FlaggedMessage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	room := fm.Message.RoomId
+	flagged := append(q.rooms[room], fm)
+	if len(flagged) > q.max {
+		flagged = slices.Clone(flagged[len(flagged)-q.max:])
+	}
+	q.rooms[room] = flagged
+}
+
+// erase replaces the flagged messages of senderID in a room with their
+// erased form and, when deleteContent is set, drops their original text.
+func (q *flagQueue) erase(roomID, senderID, alias string, deleteContent bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, fm := range q.rooms[roomID] {
+		if fm.Message.SenderId != senderID {
+			continue
+		}
+		erased := proto.Clone(fm).(*chatv1.FlaggedMessage)
+		erased.Message = eraseMessage(fm.Message, alias, deleteContent)
+		if deleteContent {
+			erased.OriginalText = ""
+		}
+		q.rooms[roomID][i] = erased
+	}
+}
+
+// expire drops the flagged messages of a room that retention removed.
+func (q *flagQueue) expire(roomID string, sequences []uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rooms[roomID] = slices.DeleteFunc(q.rooms[roomID], func(fm *chatv1.FlaggedMessage) bool {
+		_, found := slices.BinarySearch(sequences, fm.Message.Sequence)
+		return found
+	})
+}
+
+// list returns the latest limit flagged messages of a room, or of every
+// room when roomID is empty, oldest first. A limit <= 0 returns them all.
+func (q *flagQueue) list(roomID string, limit int) []*chatv1.FlaggedMessage {
+	q.mu.Lock()
+	var out []*chatv1.FlaggedMessage
+	if roomID != "" {
+		out = slices.Clone(q.rooms[roomID])
+	} else {
+		for _, flagged := range q.rooms {
+			out = append(out, flagged...)
+		}
+	}
+	q.mu.Unlock()
+	return latestFlagged(out, limit)
+}
+
+// latestFlagged sorts flagged messages oldest first and keeps the latest
+// limit of them.
+func latestFlagged(flagged []*chatv1.FlaggedMessage, limit int) []*chatv1.FlaggedMessage {
+	slices.SortStableFunc(flagged, func(a, b *chatv1.FlaggedMessage) int {
+		return cmp.Compare(a.FlaggedAt.AsTime().UnixNano(), b.FlaggedAt.AsTime().UnixNano())
+	})
+	if limit > 0 && len(flagged) > limit {
+		flagged = flagged[len(flagged)-limit:]
+	}
+	return flagged
+}
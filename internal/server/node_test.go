@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/audit"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testNode is a chat node served over an in-process listener.
+type testNode struct {
+	node   Node
+	chat   *ChatServer
+	client chatv1.ChatServiceClient
+	keys   chatv1.KeyServiceClient
+	lis    *bufconn.Listener
+}
+
+// startNode serves a ChatServer with the default config on broker, cluster
+// and store until the test ends. A nil cluster is a cluster of one, a nil
+// store a MemoryStore.
+func startNode(t *testing.T, id string, broker Broker, cluster *Cluster, store MessageStore) *testNode {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Server.NodeID = id
+	if cluster == nil {
+		cluster = NewCluster(Node{ID: id, Addr: id}, insecure.NewCredentials())
+	}
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	auditLog, err := audit.Open("", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := openKeyRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs, ok := store.(*RaftStore); ok {
+		keys = rs.signingKeys()
+	}
+	chat := NewChatServer(&cfg.Server, &cfg.Moderation, &cfg.Spam, auditLog, keys, store, broker, cluster)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := broker.Subscribe(ctx, chat.deliverRemote); err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.StatsHandler(tracing.ServerHandler()))
+	chatv1.RegisterChatServiceServer(srv, chat)
+	chatv1.RegisterClusterServiceServer(srv, &ClusterServer{chat: chat})
+	chatv1.RegisterKeyServiceServer(srv, &KeyServer{chat: chat, keys: newKeyDirectory()})
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///"+id,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(tracing.ClientHandler()),
+	)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		chat.Shutdown()
+		srv.Stop()
+		cancel()
+		broker.Close()
+		cluster.Close()
+	})
+	return &testNode{node: cluster.self, chat: chat, client: chatv1.NewChatServiceClient(conn), keys: chatv1.NewKeyServiceClient(conn), lis: lis}
+}
+
+// startCluster starts a node per id, sharing a memory broker and knowing
+// each other as cluster members. Nodes dial each other in process.
+func startCluster(t *testing.T, ids ...string) []*testNode {
+	t.Helper()
+	nodes := startNodes(t, ids, func(id string, c *Cluster) MessageStore { return nil })
+	var members []Node
+	for _, n := range nodes {
+		members = append(members, n.node)
+	}
+	for _, n := range nodes {
+		n.chat.cluster.SetMembers(members)
+	}
+	return nodes
+}
+
+// testAddr is the cluster address of the i-th node of startNodes. The dns
+// resolver passes IP addresses through untouched.
+func testAddr(i int) string {
+	return fmt.Sprintf("127.0.0.1:%d", 1000+i)
+}
+
+// startNodes starts a node per id on a shared memory broker, each with the
+// store newStore returns for it. The nodes can dial each other in process
+// at testAddr, but each starts as a cluster of one.
+func startNodes(t *testing.T, ids []string, newStore func(id string, c *Cluster) MessageStore) []*testNode {
+	t.Helper()
+	broker := NewMemoryBroker()
+	var mu sync.Mutex
+	listeners := make(map[string]*bufconn.Listener)
+	dial := func(ctx context.Context, addr string) (net.Conn, error) {
+		mu.Lock()
+		lis, ok := listeners[addr]
+		mu.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("no node at %s", addr)
+		}
+		return lis.DialContext(ctx)
+	}
+	nodes := make([]*testNode, len(ids))
+	for i, id := range ids {
+		cluster := NewCluster(Node{ID: id, Addr: testAddr(i)}, insecure.NewCredentials())
+		cluster.dialer = dial
+		nodes[i] = startNode(t, id, sharedBroker{broker}, cluster, newStore(id, cluster))
+		mu.Lock()
+		listeners[testAddr(i)] = nodes[i].lis
+		mu.Unlock()
+	}
+	t.Cleanup(func() { broker.Close() })
+	return nodes
+}
+
+// sharedBroker leaves closing the broker to whoever shares it.
+type sharedBroker struct {
+	Broker
+}
+
+func (sharedBroker) Close() error { return nil }
+
+// testStream is a client stream with its events pumped into a channel.
+type testStream struct {
+	stream chatv1.ChatService_StreamClient
+	events chan *chatv1.StreamEvent
+}
+
+// openStream opens a stream on n and waits for n to count it among its
+// clients, so nothing broadcast afterwards misses it.
+func (n *testNode) openStream(t *testing.T) *testStream {
+	t.Helper()
+	return n.openStreamAs(t, "")
+}
+
+// openStreamAs opens a stream the way a gateway does for userID.
+func (n *testNode) openStreamAs(t *testing.T, userID string) *testStream {
+	t.Helper()
+	ctx, cancel := context.WithCancel(auth.WithUser(context.Background(), userID))
+	t.Cleanup(cancel)
+	n.chat.mu.Lock()
+	before := len(n.chat.clients)
+	n.chat.mu.Unlock()
+	stream, err := n.client.Stream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testStream{stream: stream, events: make(chan *chatv1.StreamEvent, 64)}
+	go func() {
+		defer close(s.events)
+		for {
+			evt, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			s.events <- evt
+		}
+	}()
+	eventually(t, "stream registered", func() bool {
+		n.chat.mu.Lock()
+		defer n.chat.mu.Unlock()
+		return len(n.chat.clients) > before
+	})
+	return s
+}
+
+// send posts text to a room as user.
+func (s *testStream) send(t *testing.T, user, room, text string) {
+	t.Helper()
+	err := s.stream.Send(&chatv1.StreamEvent{
+		Type: chatv1.EventType_EVENT_TYPE_MESSAGE,
+		Payload: &chatv1.StreamEvent_Message{Message: &chatv1.ChatMessage{
+			RoomId:   room,
+			SenderId: user,
+			Text:     text,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// join starts streaming room.
+func (s *testStream) join(t *testing.T, room string) {
+	t.Helper()
+	err := s.stream.Send(&chatv1.StreamEvent{
+		Type: chatv1.EventType_EVENT_TYPE_CONTROL,
+		Payload: &chatv1.StreamEvent_Control{Control: &chatv1.ControlEvent{
+			Action: chatv1.ControlAction_CONTROL_ACTION_START_STREAM,
+			RoomId: room,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitMessage returns the first message with the given text the stream
+// receives.
+func (s *testStream) waitMessage(t *testing.T, text string) *chatv1.ChatMessage {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case evt, ok := <-s.events:
+			if !ok {
+				t.Fatalf("stream ended waiting for %q", text)
+			}
+			if msg := evt.GetMessage(); msg.GetText() == text {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %q", text)
+		}
+	}
+}
+
+// eventually fails the test unless cond holds within five seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	waitFor(t, what, 5*time.Second, cond)
+}
+
+// waitFor fails the test unless cond holds within d.
+func waitFor(t *testing.T, what string, d time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(d)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
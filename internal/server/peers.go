@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/certs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// peerAuth tells the other nodes from everyone else by their client
+// certificate: a peer presents one carrying a name in tls.peer_clients.
+// Only peers may call ClusterService, and the forwarded mark and actor
+// are dropped from everyone else's calls, so no client can skip the
+// owner's checks or name another operator by claiming a node sent it.
+type peerAuth struct {
+	names atomic.Pointer[[]string]
+}
+
+func newPeerAuth(names []string) *peerAuth {
+	p := &peerAuth{}
+	p.reload(names)
+	return p
+}
+
+func (p *peerAuth) reload(names []string) {
+	p.names.Store(&names)
+}
+
+// isPeer reports whether the caller presented a verified client
+// certificate of another node.
+func (p *peerAuth) isPeer(ctx context.Context) bool {
+	allowed := *p.names.Load()
+	if len(allowed) == 0 {
+		return false
+	}
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	info, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 {
+		return false
+	}
+	return slices.ContainsFunc(certs.PeerNames(info.State.PeerCertificates[0]), func(n string) bool {
+		return slices.Contains(allowed, n)
+	})
+}
+
+// admit refuses ClusterService to callers that aren't peers and strips
+// the forwarded mark and actor from their calls.
+func (p *peerAuth) admit(ctx context.Context, method string) (context.Context, error) {
+	if p.isPeer(ctx) {
+		return ctx, nil
+	}
+	if strings.HasPrefix(method, "/"+chatv1.ClusterService_ServiceDesc.ServiceName+"/") {
+		slog.WarnContext(ctx, "rejected cluster call from a client that is not a peer", "method", method)
+		return nil, status.Error(codes.PermissionDenied, "only cluster nodes may call ClusterService")
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(forwardedByKey)) == 0 && len(md.Get(ActorKey)) == 0 {
+		return ctx, nil
+	}
+	md = md.Copy()
+	md.Delete(forwardedByKey)
+	md.Delete(ActorKey)
+	return metadata.NewIncomingContext(ctx, md), nil
+}
+
+func (p *peerAuth) UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := p.admit(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (p *peerAuth) StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := p.admit(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &admittedStream{ServerStream: ss, ctx: ctx})
+}
+
+// admittedStream carries the context admit returned.
+type admittedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *admittedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAdmitDropsTheActorOfClientsThatAreNotPeers(t *testing.T) {
+	p := newPeerAuth([]string{"node"})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ActorKey, "alice", forwardedByKey, "a"))
+	ctx, err := p.admit(ctx, "/chat.v1.AdminService/LiftUserRestriction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isForwarded(ctx) {
+		t.Error("forwarded mark kept for a client that is not a peer")
+	}
+	if got := actor(ctx); got != "admin" {
+		t.Errorf("actor = %q, want admin", got)
+	}
+
+	// Without admit, the actor metadata alone isn't believed either.
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(ActorKey, "alice"))
+	if got := actor(ctx); got != "admin" {
+		t.Errorf("actor of an unforwarded call = %q, want admin", got)
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/metrics"
+	"github.com/qinyul/go-chat/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// rateLimits throttles what clients send with a token bucket per user and
+// one per room. Calls forwarded between nodes were charged where they came
+// in.
+type rateLimits struct {
+	users *ratelimit.Limiter
+	rooms *ratelimit.Limiter
+	cfg   atomic.Pointer[config.RateLimitConfig]
+}
+
+func newRateLimits(cfg *config.RateLimitConfig) *rateLimits {
+	l := &rateLimits{
+		users: ratelimit.New(ratelimit.Limit{}),
+		rooms: ratelimit.New(ratelimit.Limit{}),
+	}
+	l.reload(cfg)
+	return l
+}
+
+func (l *rateLimits) reload(cfg *config.RateLimitConfig) {
+	l.cfg.Store(cfg)
+	l.users.SetLimit(ratelimit.Limit{Rate: cfg.UserRate, Burst: cfg.UserBurst})
+	l.rooms.SetLimit(ratelimit.Limit{Rate: cfg.RoomRate, Burst: cfg.RoomBurst})
+}
+
+// cost is what evt takes from the buckets. Control events are free.
+func (l *rateLimits) cost(evt *chatv1.StreamEvent) float64 {
+	cfg := l.cfg.Load()
+	switch evt.Payload.(type) {
+	case *chatv1.StreamEvent_Message:
+		return 1
+	case *chatv1.StreamEvent_Typing:
+		return cfg.TypingCost
+	case *chatv1.StreamEvent_Presence:
+		return cfg.PresenceCost
+	}
+	return 0
+}
+
+// allow charges cost to the user and the room. When either is out of
+// tokens it charges neither and returns an error to hand the caller.
+func (l *rateLimits) allow(userID, roomID string, cost float64) error {
+	if cost == 0 {
+		return nil
+	}
+	if userID != "" {
+		if ok, wait := l.users.Allow(userID, cost); !ok {
+			metrics.RateLimited.WithLabelValues("user").Inc()
+			return ratelimit.Error(wait, fmt.Sprintf("user %s is sending too fast", userID))
+		}
+	}
+	if roomID != "" {
+		if ok, wait := l.rooms.Allow(roomID, cost); !ok {
+			l.users.Refund(userID, cost)
+			metrics.RateLimited.WithLabelValues("room").Inc()
+			return ratelimit.Error(wait, fmt.Sprintf("room %s is too busy", roomID))
+		}
+	}
+	return nil
+}
+
+// rateLimitedEvent tells a stream its last event was dropped.
+func rateLimitedEvent(roomID string, err error) *chatv1.StreamEvent {
+	wait, _ := ratelimit.RetryDelay(err)
+	return &chatv1.StreamEvent{
+		Type: chatv1.EventType_EVENT_TYPE_CONTROL,
+		Payload: &chatv1.StreamEvent_Control{Control: &chatv1.ControlEvent{
+			Action:     chatv1.ControlAction_CONTROL_ACTION_RATE_LIMITED,
+			RoomId:     roomID,
+			Reason:     status.Convert(err).Message(),
+			RetryAfter: durationpb.New(wait),
+		}},
+	}
+}
+
+// chargedUser is the user a call is charged to: the one the gateway
+// authenticated, else the sender the request claims.
+func chargedUser(ctx context.Context, claimed string) string {
+	if user, ok := auth.UserFrom(ctx); ok {
+		return user
+	}
+	return claimed
+}
+
+// UnaryServerInterceptor charges SendMessage to its sender and room, and
+// sets the retry-after trailer on every rate limited call, slow mode
+// included.
+func (l *rateLimits) UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var err error
+	if send, ok := req.(*chatv1.SendMessageRequest); ok && info.FullMethod == chatv1.ChatService_SendMessage_FullMethodName && !isForwarded(ctx) {
+		err = l.allow(chargedUser(ctx, send.GetMessage().GetSenderId()), send.GetMessage().GetRoomId(), 1)
+	}
+	var resp any
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	if wait, ok := ratelimit.RetryDelay(err); ok {
+		grpc.SetTrailer(ctx, metadata.Pairs(ratelimit.RetryAfterKey, ratelimit.RetryAfter(wait)))
+	}
+	return resp, err
+}
+
+// StreamServerInterceptor charges every event a client sends on Stream.
+// An event over the limit is dropped and the client is sent a
+// CONTROL_ACTION_RATE_LIMITED event instead; the stream stays open.
+func (l *rateLimits) StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if info.FullMethod != chatv1.ChatService_Stream_FullMethodName {
+		return handler(srv, ss)
+	}
+	s := &limitedStream{ServerStream: ss, limits: l}
+	s.user, _ = auth.UserFrom(ss.Context())
+	s.ctx = context.WithValue(ss.Context(), limitedStreamKey{}, s)
+	return handler(srv, s)
+}
+
+// limitedStreamKey carries a Stream call's limitedStream in its context,
+// for replyRateLimited.
+type limitedStreamKey struct{}
+
+// limitedStream drops the events its client sends too fast.
+type limitedStream struct {
+	grpc.ServerStream
+	limits *rateLimits
+	ctx    context.Context
+	// user is who the gateway opened the stream for; events are charged
+	// to the sender they name without one.
+	user string
+	// reply queues the CONTROL_ACTION_RATE_LIMITED event on the stream's
+	// conn, whose writeLoop is the one goroutine sending on the stream.
+	// Until the handler sets it the event is dropped.
+	reply func(*chatv1.StreamEvent) bool
+}
+
+// replyRateLimited has the rate limiter of the Stream call ctx belongs to
+// queue its replies with push. The handler calls it before it starts
+// receiving.
+func replyRateLimited(ctx context.Context, push func(*chatv1.StreamEvent) bool) {
+	if s, ok := ctx.Value(limitedStreamKey{}).(*limitedStream); ok {
+		s.reply = push
+	}
+}
+
+func (s *limitedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *limitedStream) RecvMsg(m any) error {
+	for {
+		if err := s.ServerStream.RecvMsg(m); err != nil {
+			return err
+		}
+		evt, ok := m.(*chatv1.StreamEvent)
+		if !ok {
+			return nil
+		}
+		userID, roomID := eventParties(evt)
+		if s.user != "" {
+			userID = s.user
+		}
+		err := s.limits.allow(userID, roomID, s.limits.cost(evt))
+		if err == nil {
+			return nil
+		}
+		if s.reply != nil {
+			s.reply(rateLimitedEvent(roomID, err))
+		}
+	}
+}
+
+// ----- Slow mode -----
+
+// checkSlowMode lets each member of a slow-mode room post once per the
+// room's interval. Only the room's owner checks, as it commits.
+func (s *ChatServer) checkSlowMode(msg *chatv1.ChatMessage, settings *chatv1.RoomSettings) error {
+	interval := settings.GetSlowMode().AsDuration()
+	if interval <= 0 {
+		return nil
+	}
+	if ok, wait := s.slowMode.Allow(msg.RoomId+"\x00"+msg.SenderId, interval); !ok {
+		metrics.RateLimited.WithLabelValues("slow_mode").Inc()
+		return ratelimit.Error(wait, fmt.Sprintf("room %s is in slow mode: one message per %s", msg.RoomId, interval))
+	}
+	return nil
+}
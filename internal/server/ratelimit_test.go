@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// recvStream is a server stream that receives events and fails any send.
+type recvStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events []*chatv1.StreamEvent
+}
+
+func (s *recvStream) Context() context.Context { return s.ctx }
+
+func (s *recvStream) RecvMsg(m any) error {
+	if len(s.events) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(*chatv1.StreamEvent), s.events[0])
+	s.events = s.events[1:]
+	return nil
+}
+
+func (s *recvStream) SendMsg(any) error {
+	panic("rate limited reply sent outside the stream's writer")
+}
+
+func TestStreamChargesTheAuthenticatedUser(t *testing.T) {
+	cfg := config.Default().RateLimit
+	cfg.UserRate, cfg.UserBurst = 0.001, 2
+	cfg.RoomRate = 0
+	limits := newRateLimits(&cfg)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(auth.UserKey, "mallory"))
+	ss := &recvStream{ctx: ctx}
+	for _, sender := range []string{"a", "b", "c"} {
+		ss.events = append(ss.events, &chatv1.StreamEvent{Payload: &chatv1.StreamEvent_Message{
+			Message: &chatv1.ChatMessage{RoomId: "room", SenderId: sender},
+		}})
+	}
+	var received int
+	var replies []*chatv1.StreamEvent
+	handler := func(_ any, stream grpc.ServerStream) error {
+		replyRateLimited(stream.Context(), func(evt *chatv1.StreamEvent) bool {
+			replies = append(replies, evt)
+			return true
+		})
+		for {
+			if err := stream.RecvMsg(new(chatv1.StreamEvent)); err != nil {
+				return nil
+			}
+			received++
+		}
+	}
+	info := &grpc.StreamServerInfo{FullMethod: chatv1.ChatService_Stream_FullMethodName}
+	if err := limits.StreamServerInterceptor(nil, ss, info, handler); err != nil {
+		t.Fatal(err)
+	}
+
+	if received != 2 {
+		t.Errorf("received %d events, want the 2 of mallory's burst", received)
+	}
+	if len(replies) != 1 || replies[0].GetControl().GetAction() != chatv1.ControlAction_CONTROL_ACTION_RATE_LIMITED {
+		t.Errorf("replies = %v, want one CONTROL_ACTION_RATE_LIMITED", replies)
+	}
+}
+
+func TestSlowModeAcrossTheRaftGroup(t *testing.T) {
+	g := startRaftGroup(t, "a", "b", "c")
+	leader := g.settle(t)
+	ctx := context.Background()
+	settings := &chatv1.RoomSettings{RoomId: "lobby", SlowMode: durationpb.New(time.Hour)}
+	if err := g.stores[leader].SetRoomSettings(ctx, settings); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range g.stores {
+		eventually(t, "slow mode on every replica", func() bool {
+			got, _ := s.RoomSettings(ctx, "lobby")
+			return got.GetSlowMode().AsDuration() == time.Hour
+		})
+	}
+
+	post := func(n *testNode) error {
+		_, err := n.client.SendMessage(ctx, &chatv1.SendMessageRequest{
+			Message: &chatv1.ChatMessage{RoomId: "lobby", SenderId: "alice", Text: "hi"},
+		})
+		return err
+	}
+	if err := post(g.nodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range g.nodes[1:] {
+		if err := post(n); status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("posting again through %s: err = %v, want ResourceExhausted", n.node.ID, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// runRetention removes expired messages from the rooms this node owns
+// every interval until ctx ends.
+func (s *ChatServer) runRetention(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			s.compact(ctx, now)
+		}
+	}
+}
+
+// compact tombstones what retention expired in each room this node owns.
+func (s *ChatServer) compact(ctx context.Context, now time.Time) {
+	// Every replica of a replicated store holds every room; its leader
+	// compacts them for all.
+	if r, ok := s.store.(interface{ leads() bool }); ok && !r.leads() {
+		return
+	}
+	rooms, err := s.ownedRooms(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "retention: failed to list rooms", "err", err)
+		return
+	}
+	for _, roomID := range rooms {
+		report, expired, err := s.retention(ctx, roomID, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "retention: failed to read room", logging.RoomID, roomID, "err", err)
+			continue
+		}
+		if len(expired) == 0 {
+			continue
+		}
+		n, err := s.store.Tombstone(ctx, roomID, expired)
+		if err != nil {
+			slog.ErrorContext(ctx, "retention: failed to remove expired messages", logging.RoomID, roomID, "err", err)
+			continue
+		}
+		s.flagged.expire(roomID, expired)
+		metrics.RetentionRemoved.Add(float64(n))
+		slog.InfoContext(ctx, "expired messages removed", logging.RoomID, roomID, "messages", n,
+			"through", report.Newest.AsTime(), "max_age", report.Policy.GetMaxAge().AsDuration(), "max_messages", report.Policy.GetMaxMessages())
+		s.audit(ctx, &chatv1.AuditEvent{
+			Actor:  auditActorRetention,
+			Action: auditRetention,
+			RoomId: roomID,
+			Details: map[string]string{
+				"messages":       strconv.Itoa(n),
+				"first_sequence": strconv.FormatUint(report.FirstSequence, 10),
+				"last_sequence":  strconv.FormatUint(report.LastSequence, 10),
+			},
+		})
+	}
+}
+
+// retention works out which messages of a room have expired at now and
+// reports on them.
+func (s *ChatServer) retention(ctx context.Context, roomID string, now time.Time) (*chatv1.RoomRetentionReport, []uint64, error) {
+	settings, err := s.store.RoomSettings(ctx, roomID)
+	if err != nil {
+		return nil, nil, err
+	}
+	msgs, err := s.store.List(ctx, roomID, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	policy := settings.Retention
+	if policy == nil {
+		policy = defaultRetention(&s.settings.Load().Retention)
+	}
+	report := &chatv1.RoomRetentionReport{RoomId: roomID, Policy: policy, LegalHold: settings.LegalHold}
+	live := slices.DeleteFunc(msgs, func(m *chatv1.ChatMessage) bool { return m.Tombstone })
+	report.Messages = uint64(len(live))
+	if settings.LegalHold {
+		return report, nil, nil
+	}
+
+	// Count first: the oldest messages past max_messages; then age.
+	over := 0
+	if max := policy.MaxMessages; max > 0 && uint64(len(live)) > max {
+		over = len(live) - int(max)
+	}
+	var cutoff time.Time
+	if age := policy.GetMaxAge().AsDuration(); age > 0 {
+		cutoff = now.Add(-age)
+	}
+	var expired []uint64
+	for i, m := range live {
+		if i >= over && (cutoff.IsZero() || !m.CreatedAt.AsTime().Before(cutoff)) {
+			continue
+		}
+		expired = append(expired, m.Sequence)
+		if report.Oldest == nil || m.CreatedAt.AsTime().Before(report.Oldest.AsTime()) {
+			report.Oldest = m.CreatedAt
+		}
+		if report.Newest == nil || m.CreatedAt.AsTime().After(report.Newest.AsTime()) {
+			report.Newest = m.CreatedAt
+		}
+	}
+	if len(expired) > 0 {
+		report.Expired = uint64(len(expired))
+		report.FirstSequence, report.LastSequence = expired[0], expired[len(expired)-1]
+	}
+	return report, expired, nil
+}
+
+// defaultRetention is the policy of rooms without their own.
+func defaultRetention(cfg *config.RetentionConfig) *chatv1.RetentionPolicy {
+	p := &chatv1.RetentionPolicy{MaxMessages: uint64(cfg.MaxMessages)}
+	if cfg.MaxAge > 0 {
+		p.MaxAge = durationpb.New(cfg.MaxAge)
+	}
+	return p
+}
+
+// retentionLabel describes a room's own policy for logs and the audit log.
+func retentionLabel(p *chatv1.RetentionPolicy) string {
+	if p == nil {
+		return "default"
+	}
+	return fmt.Sprintf("max_age=%s max_messages=%d", p.GetMaxAge().AsDuration(), p.MaxMessages)
+}
+
+// ownedRooms returns the stored rooms this node owns, sorted. With a
+// replicated store every node holds every room; each is still handled
+// once, by its owner.
+func (s *ChatServer) ownedRooms(ctx context.Context) ([]string, error) {
+	rooms, err := s.store.Rooms(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list rooms: %v", err)
+	}
+	owned := rooms[:0]
+	for _, roomID := range rooms {
+		if s.cluster.IsSelf(s.cluster.Owner(roomID)) {
+			owned = append(owned, roomID)
+		}
+	}
+	slices.Sort(owned)
+	return owned, nil
+}
+
+// RetentionReport reports what retention would remove from a room, on its
+// owner. For every room it asks each node for the rooms it owns.
+func (a *AdminServer) RetentionReport(ctx context.Context, req *chatv1.RetentionReportRequest) (*chatv1.RetentionReportResponse, error) {
+	now := time.Now()
+	if req.GetRoomId() != "" {
+		client, err := a.ownerClient(ctx, req.RoomId)
+		if err != nil {
+			return nil, err
+		}
+		if client != nil {
+			return client.RetentionReport(a.forwardContext(ctx), req)
+		}
+		report, _, err := a.chat.retention(ctx, req.RoomId, now)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "read room: %v", err)
+		}
+		return &chatv1.RetentionReportResponse{Rooms: []*chatv1.RoomRetentionReport{report}}, nil
+	}
+
+	rooms, err := a.chat.ownedRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &chatv1.RetentionReportResponse{}
+	for _, roomID := range rooms {
+		report, _, err := a.chat.retention(ctx, roomID, now)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "read room %s: %v", roomID, err)
+		}
+		resp.Rooms = append(resp.Rooms, report)
+	}
+	if isForwarded(ctx) {
+		return resp, nil
+	}
+	for _, n := range a.chat.cluster.Members() {
+		if a.chat.cluster.IsSelf(n) {
+			continue
+		}
+		client, err := a.chat.cluster.adminClient(n)
+		if err == nil {
+			var nodeResp *chatv1.RetentionReportResponse
+			nodeResp, err = client.RetentionReport(a.forwardContext(ctx), req)
+			resp.Rooms = append(resp.Rooms, nodeResp.GetRooms()...)
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "node %s unreachable: %v", n.ID, err)
+		}
+	}
+	slices.SortFunc(resp.Rooms, func(x, y *chatv1.RoomRetentionReport) int {
+		return strings.Compare(x.RoomId, y.RoomId)
+	})
+	return resp, nil
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/config"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRetentionSelectsExpiredMessages(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	policy := func(maxMessages uint64, maxAge time.Duration) *chatv1.RetentionPolicy {
+		p := &chatv1.RetentionPolicy{MaxMessages: maxMessages}
+		if maxAge > 0 {
+			p.MaxAge = durationpb.New(maxAge)
+		}
+		return p
+	}
+	for _, tc := range []struct {
+		name      string
+		defaults  config.RetentionConfig
+		policy    *chatv1.RetentionPolicy // the room's own; nil for the defaults
+		legalHold bool
+		tombstone []uint64 // removed before retention runs
+		want      []uint64
+	}{
+		{name: "no limits"},
+		{name: "max messages", policy: policy(3, 0), want: []uint64{1, 2}},
+		{name: "max age", policy: policy(0, 150*time.Minute), want: []uint64{1, 2, 3}},
+		// A message exactly max_age old is kept.
+		{name: "max age boundary", policy: policy(0, 3*time.Hour), want: []uint64{1, 2}},
+		{name: "count beyond age", policy: policy(2, 270*time.Minute), want: []uint64{1, 2, 3}},
+		{name: "age beyond count", policy: policy(4, 150*time.Minute), want: []uint64{1, 2, 3}},
+		{name: "legal hold", policy: policy(1, time.Minute), legalHold: true},
+		{name: "server default", defaults: config.RetentionConfig{MaxMessages: 4}, want: []uint64{1}},
+		{name: "room over default", defaults: config.RetentionConfig{MaxMessages: 1, MaxAge: time.Minute}, policy: policy(4, 0), want: []uint64{1}},
+		// Tombstones don't count towards max_messages.
+		{name: "tombstone", policy: policy(3, 0), tombstone: []uint64{2}, want: []uint64{1}},
+	} {
+		ctx := context.Background()
+		store := NewMemoryStore()
+		// Five messages, one an hour, the last an hour before now.
+		for i := range 5 {
+			msg := &chatv1.ChatMessage{Id: fmt.Sprintf("m%d", i+1), RoomId: "dev", SenderId: "alice",
+				CreatedAt: timestamppb.New(now.Add(time.Duration(i-5) * time.Hour))}
+			if _, err := store.Append(ctx, msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := store.Tombstone(ctx, "dev", tc.tombstone); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetRoomSettings(ctx, &chatv1.RoomSettings{RoomId: "dev", Retention: tc.policy, LegalHold: tc.legalHold}); err != nil {
+			t.Fatal(err)
+		}
+		s := &ChatServer{store: store}
+		s.settings.Store(&config.ServerConfig{Retention: tc.defaults})
+
+		report, expired, err := s.retention(ctx, "dev", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(expired, tc.want) {
+			t.Errorf("%s: expired %v, want %v", tc.name, expired, tc.want)
+		}
+		if report.Expired != uint64(len(tc.want)) || report.LegalHold != tc.legalHold {
+			t.Errorf("%s: report says %d expired, legal hold %t; want %d, %t",
+				tc.name, report.Expired, report.LegalHold, len(tc.want), tc.legalHold)
+		}
+		if len(tc.want) > 0 && (report.FirstSequence != tc.want[0] || report.LastSequence != tc.want[len(tc.want)-1]) {
+			t.Errorf("%s: report spans %d to %d, want %d to %d",
+				tc.name, report.FirstSequence, report.LastSequence, tc.want[0], tc.want[len(tc.want)-1])
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+	"strconv"
+)
+
+// Node is a member of the server cluster.
+type Node struct {
+	ID   string
+	Addr string // host:port other nodes dial for gRPC
+}
+
+// virtualNodes is how many points each node gets on the ring. More points
+// spread rooms more evenly and move fewer of them when membership changes.
+const virtualNodes = 128
+
+// HashRing assigns rooms to nodes by consistent hashing, so adding or
+// removing a node only moves the rooms that hashed next to it.
+type HashRing struct {
+	points []uint64
+	owners map[uint64]Node
+}
+
+func NewHashRing(nodes []Node) *HashRing {
+	r := &HashRing{owners: make(map[uint64]Node, len(nodes)*virtualNodes)}
+	for _, n := range nodes {
+		for i := 0; i < virtualNodes; i++ {
+			p := ringHash(n.ID + "#" + strconv.Itoa(i))
+			// On the (astronomically unlikely) collision keep the lower
+			// id so every node builds the same ring.
+			if existing, ok := r.owners[p]; ok && existing.ID < n.ID {
+				continue
+			}
+			r.owners[p] = n
+		}
+	}
+	for p := range r.owners {
+		r.points = append(r.points, p)
+	}
+	slices.Sort(r.points)
+	return r
+}
+
+// Owner returns the node responsible for key. ok is false on an empty ring.
+func (r *HashRing) Owner(key string) (Node, bool) {
+	if len(r.points) == 0 {
+		return Node{}, false
+	}
+	h := ringHash(key)
+	i, _ := slices.BinarySearch(r.points, h)
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]], true
+}
+
+func ringHash(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
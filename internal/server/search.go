@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/search"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SearchMessages searches the rooms the calling user joined. The node the
+// call reached searches those it owns and asks every other node to search
+// theirs, then merges the pages into one.
+func (s *ChatServer) SearchMessages(ctx context.Context, req *chatv1.SearchMessagesRequest) (*chatv1.SearchMessagesResponse, error) {
+	userID, ok := auth.UserFrom(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "searching needs an authenticated user")
+	}
+	q := search.Query{Text: req.Query, Senders: req.SenderIds}
+	if req.GetSince() != nil {
+		q.Since = req.Since.AsTime()
+	}
+	if req.GetUntil() != nil {
+		q.Until = req.Until.AsTime()
+	}
+	if req.PageToken != "" {
+		after, err := decodePageToken(req.PageToken)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		q.After = &after
+	}
+	q.Limit = int(req.Limit)
+	if maxLimit := s.settings.Load().MaxPageSize; q.Limit <= 0 || q.Limit > maxLimit {
+		q.Limit = maxLimit
+	}
+	limit := q.Limit
+	// One more than a page tells whether another follows.
+	q.Limit++
+
+	msgs, err := s.searchLocal(ctx, q, userID, req.RoomIds)
+	if err != nil {
+		return nil, err
+	}
+	more := len(msgs) > limit
+	if !isForwarded(ctx) {
+		fwd := &chatv1.SearchMessagesRequest{
+			Query:     req.Query,
+			RoomIds:   req.RoomIds,
+			SenderIds: req.SenderIds,
+			Since:     req.Since,
+			Until:     req.Until,
+			Limit:     int32(limit),
+			PageToken: req.PageToken,
+		}
+		for _, n := range s.cluster.Members() {
+			if s.cluster.IsSelf(n) {
+				continue
+			}
+			client, err := s.cluster.chatClient(n)
+			if err == nil {
+				var resp *chatv1.SearchMessagesResponse
+				resp, err = client.SearchMessages(auth.WithUser(s.cluster.forwardContext(ctx), userID), fwd)
+				msgs = append(msgs, resp.GetMessages()...)
+				more = more || resp.GetNextPageToken() != ""
+			}
+			if err != nil {
+				return nil, status.Errorf(codes.Unavailable, "node %s unreachable: %v", n.ID, err)
+			}
+		}
+		slices.SortFunc(msgs, func(a, b *chatv1.ChatMessage) int {
+			return search.CursorOf(a).Compare(search.CursorOf(b))
+		})
+	}
+
+	resp := &chatv1.SearchMessagesResponse{Messages: msgs}
+	if len(msgs) > limit {
+		resp.Messages, more = msgs[:limit], true
+	}
+	if more && len(resp.Messages) > 0 {
+		resp.NextPageToken = encodePageToken(search.CursorOf(resp.Messages[len(resp.Messages)-1]))
+	}
+	return resp, nil
+}
+
+// searchLocal runs q on the rooms this node owns that userID joined,
+// limited to roomIDs if any are given.
+func (s *ChatServer) searchLocal(ctx context.Context, q search.Query, userID string, roomIDs []string) ([]*chatv1.ChatMessage, error) {
+	if len(roomIDs) == 0 {
+		var err error
+		if roomIDs, err = s.store.Rooms(ctx); err != nil {
+			return nil, status.Errorf(codes.Internal, "list rooms: %v", err)
+		}
+	}
+	for _, roomID := range roomIDs {
+		if !s.cluster.IsSelf(s.cluster.Owner(roomID)) {
+			continue
+		}
+		members, err := s.store.RoomMembers(ctx, roomID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list members of %s: %v", roomID, err)
+		}
+		if _, found := slices.BinarySearch(members, userID); found {
+			q.Rooms = append(q.Rooms, roomID)
+		}
+	}
+	// No rooms here would mean every room to the index.
+	if len(q.Rooms) == 0 {
+		return nil, nil
+	}
+	msgs, err := s.store.Search(ctx, q)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "search messages: %v", err)
+	}
+	return msgs, nil
+}
+
+// A page token is the search position of the last message of a page:
+// created_at in Unix nanoseconds, sequence and room.
+func encodePageToken(c search.Cursor) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d:%d:%s", c.CreatedAt.UnixNano(), c.Sequence, c.RoomID))
+}
+
+func decodePageToken(token string) (search.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return search.Cursor{}, err
+	}
+	parts := strings.SplitN(string(b), ":", 3)
+	if len(parts) != 3 {
+		return search.Cursor{}, fmt.Errorf("want 3 parts, got %d", len(parts))
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return search.Cursor{}, err
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return search.Cursor{}, err
+	}
+	return search.Cursor{CreatedAt: time.Unix(0, nanos).UTC(), Sequence: seq, RoomID: parts[2]}, nil
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSearchCoversTheRoomsTheCallerJoined(t *testing.T) {
+	nodes := startCluster(t, "a", "b")
+	a, b := nodes[0], nodes[1]
+	onA, onB := roomOwnedBy(t, a, a), roomOwnedBy(t, a, b)
+	other := roomOwnedBy(t, a, a, onA)
+	ctx := context.Background()
+	post := func(sender, room, text string) {
+		t.Helper()
+		_, err := a.client.SendMessage(ctx, &chatv1.SendMessageRequest{
+			Message: &chatv1.ChatMessage{RoomId: room, SenderId: sender, Text: text},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	post("bob", onA, "deploy on a")
+	post("bob", onB, "deploy on b")
+	post("bob", other, "deploy elsewhere")
+	// Posting to a room, even as alice, doesn't make her a member of it.
+	post("alice", other, "deploy by alice")
+
+	// Alice joins the room on a through a stream on b, which she then
+	// closes, and the room on b without one.
+	alice := b.openStreamAs(t, "alice")
+	alice.join(t, onA)
+	eventually(t, "alice joining "+onA, func() bool {
+		members, _ := a.chat.store.RoomMembers(ctx, onA)
+		return slices.Equal(members, []string{"alice"})
+	})
+	if err := alice.stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "alice's stream closing", func() bool {
+		b.chat.mu.Lock()
+		defer b.chat.mu.Unlock()
+		return len(b.chat.clients) == 0
+	})
+	if _, err := a.client.JoinRoom(auth.WithUser(ctx, "alice"), &chatv1.JoinRoomRequest{RoomId: onB}); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(user string, rooms ...string) []string {
+		t.Helper()
+		resp, err := a.client.SearchMessages(auth.WithUser(ctx, user), &chatv1.SearchMessagesRequest{Query: "deploy", RoomIds: rooms})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var texts []string
+		for _, m := range resp.Messages {
+			texts = append(texts, m.Text)
+		}
+		slices.Sort(texts)
+		return texts
+	}
+	if got, want := search("alice"), []string{"deploy on a", "deploy on b"}; !slices.Equal(got, want) {
+		t.Errorf("alice found %q, want %q", got, want)
+	}
+	if got := search("alice", other); len(got) > 0 {
+		t.Errorf("alice found %q in a room she didn't join", got)
+	}
+	if got := search("bob"); len(got) > 0 {
+		t.Errorf("bob, who joined nothing, found %q", got)
+	}
+
+	if _, err := b.client.LeaveRoom(auth.WithUser(ctx, "alice"), &chatv1.LeaveRoomRequest{RoomId: onB}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := search("alice"), []string{"deploy on a"}; !slices.Equal(got, want) {
+		t.Errorf("alice found %q after leaving %s, want %q", got, onB, want)
+	}
+
+	_, err := a.client.SearchMessages(ctx, &chatv1.SearchMessagesRequest{Query: "deploy"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("search without a user: err = %v, want Unauthenticated", err)
+	}
+	_, err = a.client.JoinRoom(ctx, &chatv1.JoinRoomRequest{RoomId: other})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("join without a user: err = %v, want Unauthenticated", err)
+	}
+}
@@ -0,0 +1,328 @@
+// Package server is the chat backend node: ChatService, ClusterService and
+// the broker, store and cluster membership they use.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/vanguard/vanguardgrpc"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/audit"
+	"github.com/qinyul/go-chat/internal/certs"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/health"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/metrics"
+	"github.com/qinyul/go-chat/internal/moderation"
+	"github.com/qinyul/go-chat/internal/tracing"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// healthInterval is how often the grpc.health.v1 status is refreshed.
+const healthInterval = 5 * time.Second
+
+// Server is one chat node: ChatService, AdminService and ClusterService
+// over gRPC and, through the Vanguard transcoder, HTTP, plus the broker,
+// message store and cluster membership behind them.
+type Server struct {
+	cfg        *config.Config
+	chat       *ChatServer
+	grpcServer *grpc.Server
+	httpServer *http.Server
+	broker     Broker
+	store      MessageStore
+	cluster    *Cluster
+	limits     *rateLimits
+	peers      *peerAuth
+	health     *health.Checker
+	// healthServer is the grpc.health.v1 service; it follows health.
+	healthServer *grpchealth.Server
+
+	// allowedClients are the client certificate SANs let through when
+	// mutual TLS is on; empty lets any verified client through.
+	allowedClients atomic.Pointer[[]string]
+	// applied is the config last applied, which a reload is audited
+	// against.
+	applied atomic.Pointer[config.Config]
+
+	stopBackground context.CancelFunc
+	// inProcess serves gateways running in the same process.
+	inProcess *bufconn.Listener
+}
+
+// New sets up the broker, cluster and store from cfg and registers the
+// services. Nothing listens until ListenAndServe.
+func New(cfg *config.Config) (*Server, error) {
+	sc := cfg.Server
+
+	// serverCerts is what this node serves; peerCerts is what it presents
+	// and trusts when dialing other nodes.
+	serverCerts, err := certs.NewWatcher(certs.Files{Cert: cfg.TLS.CertFile, Key: cfg.TLS.KeyFile, CA: cfg.TLS.ClientCAFile})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS cert: %w", err)
+	}
+	peerCerts, err := certs.NewWatcher(certs.Files{Cert: cfg.TLS.ClientCertFile, Key: cfg.TLS.ClientKeyFile, CA: cfg.TLS.CAFile})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS client cert: %w", err)
+	}
+
+	var broker Broker
+	switch sc.Broker.Kind {
+	case "memory":
+		broker = NewMemoryBroker()
+	case "nats":
+		nb, err := NewNATSBroker(sc.Broker.NATSURL, sc.Broker.Subject, sc.NodeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+		}
+		broker = nb
+	}
+
+	peerCreds := credentials.NewTLS(peerCerts.ClientTLS(cfg.TLS.ServerName))
+	cluster := NewCluster(Node{ID: sc.NodeID, Addr: sc.Cluster.AdvertiseAddr}, peerCreds)
+
+	var store MessageStore
+	switch sc.Store.Kind {
+	case "memory":
+		store = NewMemoryStore()
+	case "raft":
+		rc := sc.Store.Raft
+		peers, err := LoadRaftPeersFile(rc.PeersFile)
+		if err == nil {
+			store, err = NewRaftStore(RaftConfig{
+				NodeID:            sc.NodeID,
+				Dir:               rc.Dir,
+				BindAddr:          rc.Addr,
+				Peers:             peers,
+				Bootstrap:         rc.Bootstrap,
+				ApplyTimeout:      rc.ApplyTimeout,
+				SnapshotThreshold: rc.SnapshotThreshold,
+			}, cluster)
+		}
+		if err != nil {
+			broker.Close()
+			return nil, fmt.Errorf("failed to start raft store: %w", err)
+		}
+	}
+
+	auditLog, err := audit.Open(sc.Audit.File, sc.NodeID)
+	if err != nil {
+		broker.Close()
+		store.Close()
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	// The raft store replicates the signing keys in place of the file.
+	var signingKeys *keyRegistry
+	if rs, ok := store.(*RaftStore); ok {
+		signingKeys = rs.signingKeys()
+	} else if signingKeys, err = openKeyRegistry(sc.Signing.KeysFile); err != nil {
+		broker.Close()
+		store.Close()
+		auditLog.Close()
+		return nil, fmt.Errorf("failed to open signing keys: %w", err)
+	}
+
+	limits := newRateLimits(&cfg.RateLimit)
+	peers := newPeerAuth(cfg.TLS.PeerClients)
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(tracing.ServerHandler()),
+		grpc.ChainUnaryInterceptor(peers.UnaryServerInterceptor, logging.UnaryServerInterceptor, metrics.UnaryServerInterceptor, limits.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(peers.StreamServerInterceptor, logging.StreamServerInterceptor, metrics.StreamServerInterceptor, limits.StreamServerInterceptor),
+	)
+	chatSrv := NewChatServer(&sc, &cfg.Moderation, &cfg.Spam, auditLog, signingKeys, store, broker, cluster)
+	chatv1.RegisterClusterServiceServer(grpcServer, &ClusterServer{chat: chatSrv})
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	go serverCerts.Run(bgCtx, cfg.TLS.ReloadInterval)
+	go peerCerts.Run(bgCtx, cfg.TLS.ReloadInterval)
+	if sc.Cluster.PeersFile != "" {
+		go cluster.WatchPeersFile(bgCtx, sc.Cluster.PeersFile, sc.Cluster.Refresh, chatSrv.rebalance)
+	}
+	go chatSrv.runRetention(bgCtx, sc.Retention.Interval)
+	if err := broker.Subscribe(bgCtx, chatSrv.deliverRemote); err != nil {
+		stopBackground()
+		broker.Close()
+		store.Close()
+		auditLog.Close()
+		return nil, fmt.Errorf("failed to subscribe to broker: %w", err)
+	}
+	chatv1.RegisterChatServiceServer(grpcServer, chatSrv)
+	keys := newKeyDirectory()
+	chatv1.RegisterAdminServiceServer(grpcServer, &AdminServer{chat: chatSrv, keys: keys})
+	chatv1.RegisterKeyServiceServer(grpcServer, &KeyServer{chat: chatSrv, keys: keys})
+	reflection.Register(grpcServer)
+	checker := health.New()
+	checker.Add("store", store.Ready)
+	checker.Add("broker", broker.Ready)
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go checker.Watch(bgCtx, healthInterval, func(ready bool) {
+		st := healthpb.HealthCheckResponse_NOT_SERVING
+		if ready {
+			st = healthpb.HealthCheckResponse_SERVING
+		}
+		healthServer.SetServingStatus("", st)
+		healthServer.SetServingStatus(chatv1.ChatService_ServiceDesc.ServiceName, st)
+	})
+
+	transcoder, err := vanguardgrpc.NewTranscoder(grpcServer)
+	if err != nil {
+		stopBackground()
+		broker.Close()
+		store.Close()
+		auditLog.Close()
+		return nil, fmt.Errorf("failed to create Vanguard transcoder: %w", err)
+	}
+
+	s := &Server{
+		cfg:            cfg,
+		chat:           chatSrv,
+		grpcServer:     grpcServer,
+		broker:         broker,
+		store:          store,
+		cluster:        cluster,
+		limits:         limits,
+		peers:          peers,
+		health:         checker,
+		healthServer:   healthServer,
+		stopBackground: stopBackground,
+	}
+	s.allowedClients.Store(&cfg.TLS.AllowedClients)
+	s.applied.Store(cfg)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", s.requireClient(transcoder))
+	// Scrapers and probes still need a client certificate under mutual
+	// TLS, but not one of the allowed names.
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", health.Live)
+	mux.HandleFunc("GET /readyz", checker.Ready)
+
+	s.httpServer = &http.Server{
+		Addr:      sc.Addr,
+		Handler:   mux,
+		TLSConfig: serverCerts.ServerTLS(),
+	}
+	http2.ConfigureServer(s.httpServer, &http2.Server{})
+	return s, nil
+}
+
+// requireClient rejects requests whose client certificate carries none of
+// the allowed SANs. Without mutual TLS, or with no allowed list, every
+// request goes through.
+func (s *Server) requireClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := *s.allowedClients.Load()
+		if len(allowed) > 0 {
+			var names []string
+			if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+				names = certs.PeerNames(r.TLS.PeerCertificates[0])
+			}
+			if !slices.ContainsFunc(names, func(n string) bool { return slices.Contains(allowed, n) }) {
+				slog.Warn("rejected client: certificate names are not in tls.allowed_clients", "remote", r.RemoteAddr, "names", names)
+				http.Error(w, "client certificate not allowed", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServe serves HTTPS / HTTP/2 on the configured address. Like
+// http.Server it returns http.ErrServerClosed after Shutdown.
+func (s *Server) ListenAndServe() error {
+	slog.Info("node listening (HTTPS / HTTP/2)", "node", s.cfg.Server.NodeID, "addr", s.cfg.Server.Addr)
+	// The certificate comes from TLSConfig, which follows the files.
+	return s.httpServer.ListenAndServeTLS("", "")
+}
+
+// DialInProcess returns a connection to this server that stays inside the
+// process: no sockets and no TLS.
+func (s *Server) DialInProcess(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if s.inProcess == nil {
+		s.inProcess = bufconn.Listen(1 << 20)
+		go s.grpcServer.Serve(s.inProcess)
+	}
+	lis := s.inProcess
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(tracing.ClientHandler()),
+		grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(logging.StreamClientInterceptor),
+	)
+	return grpc.NewClient("passthrough:///in-process", opts...)
+}
+
+// Moderation returns the filter chain every message passes through, for
+// programs embedding the server to add their own filters to.
+func (s *Server) Moderation() *moderation.Pipeline {
+	return s.chat.moderation
+}
+
+// Health returns the readiness checks of the node: store and broker.
+// Gateways running in the same process add theirs to it.
+func (s *Server) Health() *health.Checker {
+	return s.health
+}
+
+// Reload applies the reloadable server settings of cfg.
+func (s *Server) Reload(cfg *config.Config) error {
+	s.chat.settings.Store(&cfg.Server)
+	s.allowedClients.Store(&cfg.TLS.AllowedClients)
+	s.peers.reload(cfg.TLS.PeerClients)
+	s.limits.reload(&cfg.RateLimit)
+	s.chat.configureModeration(&cfg.Moderation)
+	s.chat.configureSpam(&cfg.Spam)
+	s.chat.auditReload(s.applied.Swap(cfg), cfg)
+	return nil
+}
+
+// Shutdown says goodbye on live streams and refuses new calls, then stops
+// accepting and gives in-flight calls until ctx is done to finish before
+// closing the broker, flushing the store and leaving the cluster.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+	s.health.Drain()
+	s.healthServer.Shutdown()
+	s.chat.Shutdown()
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		slog.Warn("in-flight calls did not finish, forcing close", "err", err)
+		s.httpServer.Close()
+		errs = append(errs, err)
+	}
+	s.grpcServer.Stop()
+	s.stopBackground()
+
+	if err := s.broker.Close(); err != nil {
+		slog.Error("failed to close broker", "err", err)
+		errs = append(errs, err)
+	}
+	if err := s.store.Close(); err != nil {
+		slog.Error("failed to flush message store", "err", err)
+		errs = append(errs, err)
+	}
+	if err := s.chat.auditLog.Close(); err != nil {
+		slog.Error("failed to close audit log", "err", err)
+		errs = append(errs, err)
+	}
+	s.cluster.Close()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,400 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/msgsign"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RegisterSigningKey adds a message signing key for a user. A user's
+// second and later keys must be endorsed by a key they already have.
+func (k *KeyServer) RegisterSigningKey(ctx context.Context, req *chatv1.RegisterSigningKeyRequest) (*chatv1.RegisterSigningKeyResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if len(req.PublicKey) != ed25519.PublicKeySize {
+		return nil, status.Error(codes.InvalidArgument, "public_key must be a 32 byte Ed25519 key")
+	}
+	if err := callerIs(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+	client, err := k.homeClient(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.RegisterSigningKey(k.chat.cluster.forwardContext(ctx), req)
+	}
+	key, err := k.chat.signingKeys.register(req)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "signing key registered", logging.UserID, req.UserId, "key_id", key.KeyId, "endorsed_by", req.EndorsedBy)
+	return &chatv1.RegisterSigningKeyResponse{Key: key}, nil
+}
+
+func (k *KeyServer) ListSigningKeys(ctx context.Context, req *chatv1.ListSigningKeysRequest) (*chatv1.ListSigningKeysResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	client, err := k.homeClient(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.ListSigningKeys(k.chat.cluster.forwardContext(ctx), req)
+	}
+	return &chatv1.ListSigningKeysResponse{Keys: k.chat.signingKeys.list(req.UserId, req.IncludeRevoked)}, nil
+}
+
+// RevokeSigningKey revokes a key with a revocation signed by one of the
+// user's keys in force, which may be the key itself.
+func (k *KeyServer) RevokeSigningKey(ctx context.Context, req *chatv1.RevokeSigningKeyRequest) (*chatv1.RevokeSigningKeyResponse, error) {
+	if req.GetUserId() == "" || req.GetKeyId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id and key_id are required")
+	}
+	client, err := k.homeClient(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.RevokeSigningKey(k.chat.cluster.forwardContext(ctx), req)
+	}
+	key, err := k.chat.signingKeys.revoke(req)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "signing key revoked", logging.UserID, req.UserId, "key_id", key.KeyId, "signed_by", req.SignedBy)
+	return &chatv1.RevokeSigningKeyResponse{Key: key}, nil
+}
+
+// verifySender checks the signature of msg against the registered keys of
+// its sender, on the sender's home node, and reports whether it verified.
+// A signature that doesn't verify is refused, and so is an unsigned
+// message from a user with keys when signing.reject_unsigned is on.
+func (s *ChatServer) verifySender(msg *chatv1.ChatMessage) (bool, error) {
+	if err := s.signingKeys.current(); err != nil {
+		return false, rejectError(codes.Unavailable, reasonUnchecked,
+			fmt.Sprintf("the keys of user %s can't be checked yet; try again", msg.SenderId), nil)
+	}
+	if len(msg.Signature) == 0 {
+		if s.settings.Load().Signing.RejectUnsigned && s.signingKeys.hasActive(msg.SenderId) {
+			return false, rejectError(codes.PermissionDenied, reasonSignature,
+				fmt.Sprintf("user %s signs their messages and this one is unsigned", msg.SenderId), nil)
+		}
+		return false, nil
+	}
+	key := s.signingKeys.active(msg.SenderId, msg.SignatureKeyId)
+	if key == nil {
+		return false, rejectError(codes.InvalidArgument, reasonSignature,
+			fmt.Sprintf("signing key %q is not a registered key of user %s", msg.SignatureKeyId, msg.SenderId), nil)
+	}
+	if !msgsign.Verify(msg, key.PublicKey) {
+		return false, rejectError(codes.InvalidArgument, reasonSignature, "message signature does not verify", nil)
+	}
+	return true, nil
+}
+
+// ----- Key registry -----
+
+// keyRegistry holds the signing keys of the users this node is home to.
+// Every change rewrites its file, one SigningKey in JSON per line, so a
+// restart doesn't let anyone register a first key for a user again. With
+// the raft store, changes are committed through the Raft log instead and
+// every replica holds every user's keys; see RaftStore.signingKeys.
+type keyRegistry struct {
+	// writeMu serializes changes, from reading the keys a change starts
+	// from to committing it; mu guards keys, which replication sets.
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	path    string
+	keys    map[string][]*chatv1.SigningKey // user → keys, oldest first
+	// replicate, when set, commits a user's new keys in place of the file
+	// and calls set on every replica. catchUp then waits until the keys
+	// are those of every change committed so far.
+	replicate func(userID string, keys []*chatv1.SigningKey) error
+	catchUp   func() error
+}
+
+func openKeyRegistry(path string) (*keyRegistry, error) {
+	r := &keyRegistry{path: path, keys: make(map[string][]*chatv1.SigningKey)}
+	if path == "" {
+		return r, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		key := &chatv1.SigningKey{}
+		if err := protojson.Unmarshal(sc.Bytes(), key); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		r.keys[key.UserId] = append(r.keys[key.UserId], key)
+	}
+	return r, sc.Err()
+}
+
+// register adds the key of req. The user's first key needs nothing more;
+// later ones an endorsement by one of the user's keys in force. A key
+// registered again is returned as is.
+func (r *keyRegistry) register(req *chatv1.RegisterSigningKeyRequest) (*chatv1.SigningKey, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	if err := r.current(); err != nil {
+		return nil, err
+	}
+	id := msgsign.KeyID(req.PublicKey)
+	keys := r.userKeys(req.UserId)
+	if i := slices.IndexFunc(keys, func(k *chatv1.SigningKey) bool { return k.KeyId == id }); i >= 0 {
+		if keys[i].RevokedAt != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "signing key %s was revoked", id)
+		}
+		return keys[i], nil
+	}
+	if slices.ContainsFunc(keys, inForceKey) {
+		endorser := activeKey(keys, req.EndorsedBy)
+		if endorser == nil || !msgsign.VerifyEndorsement(endorser.PublicKey, req.UserId, req.PublicKey, req.Endorsement) {
+			return nil, status.Errorf(codes.PermissionDenied, "user %s already has a signing key: a new one needs an endorsement by it", req.UserId)
+		}
+	}
+	key := &chatv1.SigningKey{KeyId: id, UserId: req.UserId, PublicKey: req.PublicKey, CreatedAt: timestamppb.Now()}
+	if err := r.commit(req.UserId, append(keys, key)); err != nil {
+		return nil, err
+	}
+	return cloneKey(key), nil
+}
+
+// revoke revokes the key of req, signed by one of the user's keys in
+// force.
+func (r *keyRegistry) revoke(req *chatv1.RevokeSigningKeyRequest) (*chatv1.SigningKey, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	if err := r.current(); err != nil {
+		return nil, err
+	}
+	keys := r.userKeys(req.UserId)
+	key := activeKey(keys, req.KeyId)
+	if key == nil {
+		return nil, status.Errorf(codes.NotFound, "user %s has no signing key %s in force", req.UserId, req.KeyId)
+	}
+	signer := activeKey(keys, req.SignedBy)
+	if signer == nil || !msgsign.VerifyRevocation(signer.PublicKey, req.UserId, req.KeyId, req.Signature) {
+		return nil, status.Error(codes.PermissionDenied, "revocation must be signed by one of the user's signing keys")
+	}
+	key.RevokedAt = timestamppb.Now()
+	if err := r.commit(req.UserId, keys); err != nil {
+		return nil, err
+	}
+	return cloneKey(key), nil
+}
+
+// reset revokes every key of userID in force and returns them.
+func (r *keyRegistry) reset(userID string) ([]*chatv1.SigningKey, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	if err := r.current(); err != nil {
+		return nil, err
+	}
+	keys := r.userKeys(userID)
+	var revoked []*chatv1.SigningKey
+	now := timestamppb.Now()
+	for _, k := range keys {
+		if inForceKey(k) {
+			k.RevokedAt = now
+			revoked = append(revoked, k)
+		}
+	}
+	if len(revoked) == 0 {
+		return nil, nil
+	}
+	if err := r.commit(userID, keys); err != nil {
+		return nil, err
+	}
+	out := make([]*chatv1.SigningKey, len(revoked))
+	for i, k := range revoked {
+		out[i] = cloneKey(k)
+	}
+	return out, nil
+}
+
+// erase forgets every key of userID, revoked ones included.
+func (r *keyRegistry) erase(userID string) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	if err := r.current(); err != nil {
+		return err
+	}
+	if len(r.userKeys(userID)) == 0 {
+		return nil
+	}
+	return r.commit(userID, nil)
+}
+
+func (r *keyRegistry) list(userID string, revoked bool) []*chatv1.SigningKey {
+	var out []*chatv1.SigningKey
+	for _, k := range r.userKeys(userID) {
+		if revoked || inForceKey(k) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// active returns userID's key keyID if it is in force.
+func (r *keyRegistry) active(userID, keyID string) *chatv1.SigningKey {
+	return activeKey(r.userKeys(userID), keyID)
+}
+
+func (r *keyRegistry) hasActive(userID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.ContainsFunc(r.keys[userID], inForceKey)
+}
+
+// current waits until the registry holds every committed change.
+func (r *keyRegistry) current() error {
+	if r.catchUp == nil {
+		return nil
+	}
+	return r.catchUp()
+}
+
+// userKeys returns a copy of userID's keys, for a change to start from.
+func (r *keyRegistry) userKeys(userID string) []*chatv1.SigningKey {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]*chatv1.SigningKey, len(r.keys[userID]))
+	for i, k := range r.keys[userID] {
+		keys[i] = cloneKey(k)
+	}
+	return keys
+}
+
+// commit makes keys userID's keys, through replicate or in memory and in
+// the file; r.writeMu must be held.
+func (r *keyRegistry) commit(userID string, keys []*chatv1.SigningKey) error {
+	if r.replicate != nil {
+		err := r.replicate(userID, keys)
+		if _, ok := status.FromError(err); !ok {
+			err = status.Errorf(codes.Internal, "replicate signing keys: %v", err)
+		}
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev := r.keys[userID]
+	r.setLocked(userID, keys)
+	if err := r.saveLocked(); err != nil {
+		r.setLocked(userID, prev)
+		return status.Errorf(codes.Internal, "save signing keys: %v", err)
+	}
+	return nil
+}
+
+// set makes keys userID's keys as committed; no keys forget the user.
+func (r *keyRegistry) set(userID string, keys []*chatv1.SigningKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setLocked(userID, keys)
+}
+
+func (r *keyRegistry) setLocked(userID string, keys []*chatv1.SigningKey) {
+	if len(keys) == 0 {
+		delete(r.keys, userID)
+		return
+	}
+	r.keys[userID] = keys
+}
+
+// all returns every key of every user, for a snapshot.
+func (r *keyRegistry) all() []*chatv1.SigningKey {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*chatv1.SigningKey
+	for _, keys := range r.keys {
+		for _, k := range keys {
+			out = append(out, cloneKey(k))
+		}
+	}
+	return out
+}
+
+// load replaces every user's keys with keys, from a snapshot.
+func (r *keyRegistry) load(keys []*chatv1.SigningKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.keys)
+	for _, k := range keys {
+		r.keys[k.UserId] = append(r.keys[k.UserId], k)
+	}
+}
+
+// activeKey returns the key keyID of keys if it is in force.
+func activeKey(keys []*chatv1.SigningKey, keyID string) *chatv1.SigningKey {
+	for _, k := range keys {
+		if k.KeyId == keyID && inForceKey(k) {
+			return k
+		}
+	}
+	return nil
+}
+
+// saveLocked rewrites the file through a temporary one, so a crash leaves
+// the old or the new registry; r.mu must be held.
+func (r *keyRegistry) saveLocked() error {
+	if r.path == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, keys := range r.keys {
+		for _, k := range keys {
+			line, err := protojson.Marshal(k)
+			if err != nil {
+				return err
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+func inForceKey(k *chatv1.SigningKey) bool {
+	return k.RevokedAt == nil
+}
+
+func cloneKey(k *chatv1.SigningKey) *chatv1.SigningKey {
+	return proto.Clone(k).(*chatv1.SigningKey)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSigningKeysAcrossTheRaftGroup(t *testing.T) {
+	g := startRaftGroup(t, "a", "b", "c")
+	leader := g.settle(t)
+	asAlice := auth.WithUser(context.Background(), "alice")
+	register := func(ctx context.Context, n *testNode) error {
+		t.Helper()
+		pub, _, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = n.keys.RegisterSigningKey(ctx, &chatv1.RegisterSigningKeyRequest{UserId: "alice", PublicKey: pub})
+		return err
+	}
+
+	if err := register(auth.WithUser(context.Background(), "mallory"), g.nodes[0]); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("mallory registering alice's key: err = %v, want PermissionDenied", err)
+	}
+	if err := register(asAlice, g.nodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range g.nodes {
+		if err := register(asAlice, n); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("unendorsed second key through %s: err = %v, want PermissionDenied", n.node.ID, err)
+		}
+	}
+
+	for _, s := range g.stores {
+		if err := s.raft.Snapshot().Error(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A new leader holds the key too.
+	g.stop(t, leader)
+	next := g.settle(t)
+	if err := register(asAlice, g.nodes[next]); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("unendorsed second key after the leader changed: err = %v, want PermissionDenied", err)
+	}
+	if got := g.nodes[next].chat.signingKeys.list("alice", false); len(got) != 1 {
+		t.Errorf("new leader holds %d keys of alice, want 1", len(got))
+	}
+
+	// So does the old one, restored from its snapshot.
+	g.restart(t, leader)
+	eventually(t, "alice's key on the restarted replica", func() bool {
+		return len(g.stores[leader].signingKeys().list("alice", false)) == 1
+	})
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/metrics"
+	"google.golang.org/grpc/codes"
+)
+
+// checkSenderTimeout bounds asking a sender's home node about them.
+const checkSenderTimeout = time.Second
+
+// homeNode is the node that scores a user and keeps their restrictions,
+// picked on the same ring as room owners.
+func (s *ChatServer) homeNode(userID string) Node {
+	return s.cluster.Owner("user:" + userID)
+}
+
+// senderCheck is what a sender's home node says about a message.
+type senderCheck struct {
+	restriction *chatv1.UserRestriction // nil when the sender may post
+	verified    bool
+}
+
+// checkSender verifies and scores msg on its sender's home node. A message
+// whose signature fails is refused. When the home node can't be reached,
+// the restriction it last reported for the sender still holds, and a
+// signed message is refused as it can't be verified; any other message is
+// refused too unless spam.fail_open lets it through unscored.
+func (s *ChatServer) checkSender(ctx context.Context, msg *chatv1.ChatMessage) (senderCheck, error) {
+	home := s.homeNode(msg.SenderId)
+	if s.cluster.IsSelf(home) {
+		return s.checkHomeSender(ctx, msg)
+	}
+	client, err := s.cluster.clusterClient(home)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(s.cluster.forwardContext(ctx), checkSenderTimeout)
+		defer cancel()
+		var resp *chatv1.CheckSenderResponse
+		if resp, err = client.CheckSender(ctx, &chatv1.CheckSenderRequest{Message: msg}); err == nil {
+			s.restricted.remember(msg.SenderId, resp.Restriction)
+			return senderCheck{restriction: resp.Restriction, verified: resp.Verified}, nil
+		}
+		if isRejection(err) {
+			return senderCheck{}, err
+		}
+	}
+	if r := s.restricted.lookup(msg.SenderId); r != nil {
+		slog.WarnContext(ctx, "sender's home node unreachable, applying the restriction it last reported", logging.UserID, msg.SenderId, "home", home.ID, "err", err)
+		return senderCheck{restriction: r}, nil
+	}
+	if len(msg.Signature) > 0 || !s.spamSettings.Load().FailOpen {
+		slog.WarnContext(ctx, "sender's home node unreachable, message refused", logging.UserID, msg.SenderId, "home", home.ID, "err", err)
+		return senderCheck{}, rejectError(codes.Unavailable, reasonUnchecked,
+			fmt.Sprintf("the home node of user %s can't check this message; try again", msg.SenderId), nil)
+	}
+	slog.WarnContext(ctx, "sender's home node unreachable, message not verified or scored", logging.UserID, msg.SenderId, "home", home.ID, "err", err)
+	return senderCheck{}, nil
+}
+
+// restrictionCache holds the restrictions home nodes reported for senders
+// of rooms this node owns, so they still hold while a home node can't be
+// reached.
+type restrictionCache struct {
+	mu     sync.Mutex
+	byUser map[string]*chatv1.UserRestriction
+}
+
+func newRestrictionCache() *restrictionCache {
+	return &restrictionCache{byUser: make(map[string]*chatv1.UserRestriction)}
+}
+
+// remember records what a home node said about userID: r, or no
+// restriction when r is nil.
+func (c *restrictionCache) remember(userID string, r *chatv1.UserRestriction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r == nil {
+		delete(c.byUser, userID)
+		return
+	}
+	now := time.Now()
+	for id, old := range c.byUser {
+		if !old.ExpiresAt.AsTime().After(now) {
+			delete(c.byUser, id)
+		}
+	}
+	c.byUser[userID] = r
+}
+
+// lookup returns the restriction last reported for userID while it is in
+// force.
+func (c *restrictionCache) lookup(userID string) *chatv1.UserRestriction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := c.byUser[userID]
+	if r == nil || !r.ExpiresAt.AsTime().After(time.Now()) {
+		return nil
+	}
+	return r
+}
+
+// checkHomeSender verifies and scores msg for a sender this node is home
+// to.
+func (s *ChatServer) checkHomeSender(ctx context.Context, msg *chatv1.ChatMessage) (senderCheck, error) {
+	verified, err := s.verifySender(msg)
+	if err != nil {
+		slog.InfoContext(ctx, "message signature rejected", logging.UserID, msg.SenderId, logging.RoomID, msg.RoomId, "key_id", msg.SignatureKeyId, "err", err)
+		return senderCheck{}, err
+	}
+	return senderCheck{restriction: s.scoreSender(ctx, msg), verified: verified}, nil
+}
+
+// scoreSender scores msg for a sender this node is home to.
+func (s *ChatServer) scoreSender(ctx context.Context, msg *chatv1.ChatMessage) *chatv1.UserRestriction {
+	r, applied := s.spam.Check(msg.SenderId, msg.RoomId, msg.Text)
+	if applied {
+		metrics.UserRestrictions.WithLabelValues(restrictionLabel(r.Kind)).Inc()
+		slog.WarnContext(ctx, "user restricted for spam", logging.UserID, r.UserId, logging.RoomID, msg.RoomId,
+			"kind", restrictionLabel(r.Kind), "score", r.Score, "signals", r.Signals, "until", r.ExpiresAt.AsTime())
+		s.auditRestriction(ctx, r, msg.RoomId)
+	}
+	return r
+}
+
+// configureSpam applies the spam settings of a (re)loaded config.
+func (s *ChatServer) configureSpam(cfg *config.SpamConfig) {
+	s.spam.Configure(cfg)
+	s.spamSettings.Store(cfg)
+}
+
+// mutedError refuses a message of a muted sender.
+func mutedError(r *chatv1.UserRestriction) error {
+	return rejectError(codes.PermissionDenied, reasonMuted,
+		fmt.Sprintf("user %s is muted until %s", r.UserId, r.ExpiresAt.AsTime().Format(time.RFC3339)),
+		map[string]string{"restriction_id": r.Id})
+}
+
+// restrictionLabel is the metric and log name of a restriction kind.
+func restrictionLabel(kind chatv1.RestrictionKind) string {
+	switch kind {
+	case chatv1.RestrictionKind_RESTRICTION_KIND_MUTE:
+		return "mute"
+	case chatv1.RestrictionKind_RESTRICTION_KIND_SHADOW:
+		return "shadow"
+	}
+	return "unknown"
+}
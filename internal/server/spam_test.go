@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCheckSenderWithoutTheHomeNode(t *testing.T) {
+	a := startNode(t, "a", NewMemoryBroker(), nil, nil)
+	// x is a member nobody can dial.
+	x := Node{ID: "x", Addr: "127.0.0.1:1"}
+	a.chat.cluster.SetMembers([]Node{a.node, x})
+	room := roomOwnedBy(t, a, a)
+	user := func(prefix string) string {
+		t.Helper()
+		for i := range 1000 {
+			if id := fmt.Sprintf("%s-%d", prefix, i); a.chat.homeNode(id).ID == x.ID {
+				return id
+			}
+		}
+		t.Fatalf("no user at home on %s", x.ID)
+		return ""
+	}
+	send := func(msg *chatv1.ChatMessage) error {
+		msg.RoomId = room
+		_, err := a.client.SendMessage(context.Background(), &chatv1.SendMessageRequest{Message: msg})
+		return err
+	}
+
+	if err := send(&chatv1.ChatMessage{SenderId: user("open"), Text: "unscored"}); err != nil {
+		t.Errorf("unsigned message with fail_open: %v", err)
+	}
+
+	signed := &chatv1.ChatMessage{SenderId: user("signed"), Text: "unverified", Signature: []byte("sig"), SignatureKeyId: "k"}
+	if err := send(signed); status.Code(err) != codes.Unavailable {
+		t.Errorf("signed message: err = %v, want Unavailable", err)
+	}
+
+	muted := user("muted")
+	a.chat.restricted.remember(muted, &chatv1.UserRestriction{
+		UserId:    muted,
+		Kind:      chatv1.RestrictionKind_RESTRICTION_KIND_MUTE,
+		ExpiresAt: timestamppb.New(time.Now().Add(time.Hour)),
+	})
+	if err := send(&chatv1.ChatMessage{SenderId: muted, Text: "still muted"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("muted sender: err = %v, want PermissionDenied", err)
+	}
+
+	cfg := *a.chat.spamSettings.Load()
+	cfg.FailOpen = false
+	a.chat.configureSpam(&cfg)
+	if err := send(&chatv1.ChatMessage{SenderId: user("closed"), Text: "refused"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("unsigned message without fail_open: err = %v, want Unavailable", err)
+	}
+}
+
+func TestSpamScoresAcrossTheRaftGroup(t *testing.T) {
+	g := startRaftGroup(t, "a", "b", "c")
+	g.settle(t)
+	for _, n := range g.nodes {
+		cfg := *n.chat.spamSettings.Load()
+		cfg.BurstMessages, cfg.MuteScore, cfg.ShadowScore = 3, 2, 100
+		n.chat.configureSpam(&cfg)
+	}
+
+	// Each message enters on another node; a score kept per node would
+	// never reach the burst.
+	for i, n := range g.nodes {
+		msg := &chatv1.ChatMessage{RoomId: "lobby", SenderId: "mallory", Text: fmt.Sprintf("post %d", i)}
+		_, err := n.client.SendMessage(context.Background(), &chatv1.SendMessageRequest{Message: msg})
+		if i < 2 && err != nil {
+			t.Fatalf("post %d: %v", i, err)
+		}
+		if i == 2 && status.Code(err) != codes.PermissionDenied {
+			t.Errorf("third post in the window: err = %v, want PermissionDenied", err)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/search"
+	"google.golang.org/protobuf/proto"
+)
+
+// MessageStore persists chat history per room. Callers fill in id and
+// created_at; the store assigns each room's sequence.
+type MessageStore interface {
+	// Append assigns the next sequence of msg's room and stores it.
+	Append(ctx context.Context, msg *chatv1.ChatMessage) (*chatv1.ChatMessage, error)
+	// List returns the latest limit messages of a room, oldest first. A
+	// limit <= 0 returns the whole history.
+	List(ctx context.Context, roomID string, limit int) ([]*chatv1.ChatMessage, error)
+	// Rooms returns the ids of every room with stored history, settings or
+	// members.
+	Rooms(ctx context.Context) ([]string, error)
+	// Import merges history handed over by a room's previous owner and
+	// returns how many messages were accepted.
+	Import(ctx context.Context, roomID string, history []*chatv1.ChatMessage) (int, error)
+	// Drop removes the oldest n messages of a room. Dropping them all
+	// forgets the room, settings and members included.
+	Drop(ctx context.Context, roomID string, n int) error
+	// EraseSender marks every message of senderID in a room as erased,
+	// with alias as their sender and, when deleteContent is set, without
+	// their content. Ids and sequences stay, so replies and paging still
+	// line up. It returns how many messages it erased.
+	EraseSender(ctx context.Context, roomID, senderID, alias string, deleteContent bool) (int, error)
+	// Tombstone replaces the messages of a room with the given sequences
+	// by tombstones, then drops the tombstones nothing older precedes.
+	// Sequences don't change, so pages read before stay valid. It returns
+	// how many messages it removed.
+	Tombstone(ctx context.Context, roomID string, sequences []uint64) (int, error)
+	// Search returns the stored messages matching q; see package search.
+	Search(ctx context.Context, q search.Query) ([]*chatv1.ChatMessage, error)
+	// RoomSettings returns a room's settings; a room never configured
+	// gets the zero settings.
+	RoomSettings(ctx context.Context, roomID string) (*chatv1.RoomSettings, error)
+	// SetRoomSettings replaces the settings of settings.room_id.
+	SetRoomSettings(ctx context.Context, settings *chatv1.RoomSettings) error
+	// RoomMembers returns the ids of the users who joined a room, sorted.
+	RoomMembers(ctx context.Context, roomID string) ([]string, error)
+	// SetRoomMember records userID joining a room or, unless member is
+	// set, leaving it.
+	SetRoomMember(ctx context.Context, roomID, userID string, member bool) error
+	// Ready reports why the store can't take writes, or nil when it can.
+	Ready(ctx context.Context) error
+	Close() error
+}
+
+// MemoryStore keeps history in process memory.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	messages map[string][]*chatv1.ChatMessage // room_id → list of messages
+	roomSeq  map[string]uint64                // room_id → last assigned sequence
+	settings map[string]*chatv1.RoomSettings  // room_id → settings
+	members  map[string]map[string]struct{}   // room_id → user ids
+	index    *search.Index                    // kept in step with messages
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		messages: make(map[string][]*chatv1.ChatMessage),
+		roomSeq:  make(map[string]uint64),
+		settings: make(map[string]*chatv1.RoomSettings),
+		members:  make(map[string]map[string]struct{}),
+		index:    search.New(),
+	}
+}
+
+func (m *MemoryStore) Append(ctx context.Context, msg *chatv1.ChatMessage) (*chatv1.ChatMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.roomSeq[msg.RoomId]++
+	msg.Sequence = m.roomSeq[msg.RoomId]
+	m.messages[msg.RoomId] = append(m.messages[msg.RoomId], msg)
+	m.index.Put(msg)
+	return msg, nil
+}
+
+func (m *MemoryStore) List(ctx context.Context, roomID string, limit int) ([]*chatv1.ChatMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	msgs := m.messages[roomID]
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[len(msgs)-limit:]
+	}
+	return slices.Clone(msgs), nil
+}
+
+func (m *MemoryStore) Rooms(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rooms := make([]string, 0, len(m.messages))
+	for roomID := range m.messages {
+		rooms = append(rooms, roomID)
+	}
+	for roomID := range m.settings {
+		if _, ok := m.messages[roomID]; !ok {
+			rooms = append(rooms, roomID)
+		}
+	}
+	for roomID := range m.members {
+		_, stored := m.messages[roomID]
+		if _, configured := m.settings[roomID]; !stored && !configured {
+			rooms = append(rooms, roomID)
+		}
+	}
+	return rooms, nil
+}
+
+// Import puts the imported history first. Messages already stored for the
+// room are newer, so they are renumbered to follow it.
+func (m *MemoryStore) Import(ctx context.Context, roomID string, history []*chatv1.ChatMessage) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	imported := make(map[string]struct{}, len(history))
+	merged := make([]*chatv1.ChatMessage, 0, len(history)+len(m.messages[roomID]))
+	for _, msg := range history {
+		imported[msg.Id] = struct{}{}
+		merged = append(merged, msg)
+	}
+	slices.SortStableFunc(merged, func(a, b *chatv1.ChatMessage) int {
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+
+	var last uint64
+	if len(merged) > 0 {
+		last = merged[len(merged)-1].Sequence
+	}
+	for _, msg := range m.messages[roomID] {
+		if _, dup := imported[msg.Id]; dup {
+			continue
+		}
+		// Messages handed out by List are shared; renumber a copy.
+		msg = proto.Clone(msg).(*chatv1.ChatMessage)
+		last++
+		msg.Sequence = last
+		merged = append(merged, msg)
+	}
+
+	m.messages[roomID] = merged
+	m.roomSeq[roomID] = last
+	m.index.SetRoom(roomID, merged)
+	return len(history), nil
+}
+
+func (m *MemoryStore) Drop(ctx context.Context, roomID string, n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	msgs := m.messages[roomID]
+	if n >= len(msgs) {
+		delete(m.messages, roomID)
+		delete(m.roomSeq, roomID)
+		delete(m.settings, roomID)
+		delete(m.members, roomID)
+		m.index.SetRoom(roomID, nil)
+		return nil
+	}
+	for _, msg := range msgs[:n] {
+		m.index.Delete(roomID, msg.Id)
+	}
+	m.messages[roomID] = msgs[n:]
+	return nil
+}
+
+func (m *MemoryStore) EraseSender(ctx context.Context, roomID, senderID, alias string, deleteContent bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int
+	for i, msg := range m.messages[roomID] {
+		if msg.SenderId != senderID {
+			continue
+		}
+		// Messages handed out by List are shared; replace rather than
+		// edit them.
+		m.messages[roomID][i] = eraseMessage(msg, alias, deleteContent)
+		m.index.Put(m.messages[roomID][i])
+		n++
+	}
+	return n, nil
+}
+
+// eraseMessage returns a copy of msg sent by alias, without its signature
+// and, when deleteContent is set, without its content.
+func eraseMessage(msg *chatv1.ChatMessage, alias string, deleteContent bool) *chatv1.ChatMessage {
+	erased := proto.Clone(msg).(*chatv1.ChatMessage)
+	erased.SenderId = alias
+	erased.Signature = nil
+	erased.SignatureKeyId = ""
+	erased.Verified = false
+	erased.Erased = true
+	if deleteContent {
+		erased.Text = ""
+		erased.Encrypted = nil
+	}
+	return erased
+}
+
+func (m *MemoryStore) Tombstone(ctx context.Context, roomID string, sequences []uint64) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expired := make(map[uint64]struct{}, len(sequences))
+	for _, seq := range sequences {
+		expired[seq] = struct{}{}
+	}
+	msgs := slices.Clone(m.messages[roomID])
+	var n int
+	for i, msg := range msgs {
+		if _, ok := expired[msg.Sequence]; ok && !msg.Tombstone {
+			msgs[i] = &chatv1.ChatMessage{
+				Id:        msg.Id,
+				RoomId:    msg.RoomId,
+				Sequence:  msg.Sequence,
+				CreatedAt: msg.CreatedAt,
+				ThreadId:  msg.ThreadId,
+				Tombstone: true,
+			}
+			m.index.Put(msgs[i])
+			n++
+		}
+	}
+	// The room and its sequence counter stay even when nothing is left.
+	first := 0
+	for first < len(msgs) && msgs[first].Tombstone {
+		first++
+	}
+	m.messages[roomID] = msgs[first:]
+	return n, nil
+}
+
+// Search only takes the store's lock to read the index, which has its own
+// and which a Raft snapshot restore replaces.
+func (m *MemoryStore) Search(ctx context.Context, q search.Query) ([]*chatv1.ChatMessage, error) {
+	m.mu.RLock()
+	index := m.index
+	m.mu.RUnlock()
+	return index.Search(q), nil
+}
+
+func (m *MemoryStore) RoomSettings(ctx context.Context, roomID string) (*chatv1.RoomSettings, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if s, ok := m.settings[roomID]; ok {
+		return proto.Clone(s).(*chatv1.RoomSettings), nil
+	}
+	return &chatv1.RoomSettings{RoomId: roomID}, nil
+}
+
+func (m *MemoryStore) SetRoomSettings(ctx context.Context, settings *chatv1.RoomSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings[settings.RoomId] = proto.Clone(settings).(*chatv1.RoomSettings)
+	return nil
+}
+
+func (m *MemoryStore) RoomMembers(ctx context.Context, roomID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Sorted(maps.Keys(m.members[roomID])), nil
+}
+
+func (m *MemoryStore) SetRoomMember(ctx context.Context, roomID, userID string, member bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	users := m.members[roomID]
+	switch {
+	case member && users == nil:
+		m.members[roomID] = map[string]struct{}{userID: {}}
+	case member:
+		users[userID] = struct{}{}
+	default:
+		delete(users, userID)
+		if len(users) == 0 {
+			delete(m.members, roomID)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) Ready(ctx context.Context) error { return nil }
+
+func (m *MemoryStore) Close() error { return nil }
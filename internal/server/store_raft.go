@@ -0,0 +1,659 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/search"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// RaftPeer is a member of the replicated store's Raft group.
+type RaftPeer struct {
+	ID       string
+	RaftAddr string // Raft transport address
+	GRPCAddr string // ChatService address, used to forward writes to the leader
+}
+
+// RaftConfig configures a RaftStore.
+type RaftConfig struct {
+	NodeID    string
+	Dir       string // log, stable store and snapshots live here
+	BindAddr  string
+	Peers     []RaftPeer
+	Bootstrap bool
+	// ApplyTimeout bounds how long a write waits to be committed.
+	ApplyTimeout time.Duration
+	// SnapshotThreshold is how many log entries trigger a snapshot.
+	SnapshotThreshold uint64
+}
+
+// RaftStore is a MessageStore replicated through an embedded Raft group.
+// Writes are committed on a quorum before they return; followers forward
+// appends to the leader. Reads are served from the local replica and may
+// briefly lag the leader.
+type RaftStore struct {
+	cfg     RaftConfig
+	raft    *raft.Raft
+	fsm     *raftFSM
+	logs    *raftboltdb.BoltStore
+	cluster *Cluster
+	peers   map[string]string // node id → gRPC address
+	// caughtUpTerm is the last term this node, as leader, applied every
+	// entry committed before it.
+	caughtUpTerm atomic.Uint64
+}
+
+func NewRaftStore(cfg RaftConfig, cluster *Cluster) (*RaftStore, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	rc := raft.DefaultConfig()
+	rc.LocalID = raft.ServerID(cfg.NodeID)
+	rc.SnapshotThreshold = cfg.SnapshotThreshold
+	rc.Logger = hclog.New(&hclog.LoggerOptions{Name: "raft", Level: hclog.Info})
+
+	logs, err := raftboltdb.NewBoltStore(filepath.Join(cfg.Dir, "raft.db"))
+	if err != nil {
+		return nil, fmt.Errorf("open raft log: %w", err)
+	}
+	snaps, err := raft.NewFileSnapshotStore(cfg.Dir, 2, os.Stderr)
+	if err != nil {
+		logs.Close()
+		return nil, fmt.Errorf("open snapshot store: %w", err)
+	}
+	transport, err := raft.NewTCPTransport(cfg.BindAddr, nil, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		logs.Close()
+		return nil, fmt.Errorf("open raft transport: %w", err)
+	}
+
+	keys := &keyRegistry{keys: make(map[string][]*chatv1.SigningKey)}
+	fsm := &raftFSM{store: NewMemoryStore(), keys: keys}
+	r, err := raft.NewRaft(rc, fsm, logs, logs, snaps, transport)
+	if err != nil {
+		logs.Close()
+		return nil, err
+	}
+
+	s := &RaftStore{
+		cfg:     cfg,
+		raft:    r,
+		fsm:     fsm,
+		logs:    logs,
+		cluster: cluster,
+		peers:   make(map[string]string, len(cfg.Peers)),
+	}
+	servers := make([]raft.Server, 0, len(cfg.Peers))
+	for _, p := range cfg.Peers {
+		s.peers[p.ID] = p.GRPCAddr
+		servers = append(servers, raft.Server{ID: raft.ServerID(p.ID), Address: raft.ServerAddress(p.RaftAddr)})
+	}
+
+	cluster.pinOwner(s.leaderNode)
+	keys.replicate = s.replicateSigningKeys
+	keys.catchUp = s.catchUp
+
+	if cfg.Bootstrap {
+		existing, err := raft.HasExistingState(logs, logs, snaps)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if !existing {
+			if err := r.BootstrapCluster(raft.Configuration{Servers: servers}).Error(); err != nil && !errors.Is(err, raft.ErrCantBootstrap) {
+				s.Close()
+				return nil, fmt.Errorf("bootstrap raft: %w", err)
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *RaftStore) Append(ctx context.Context, msg *chatv1.ChatMessage) (*chatv1.ChatMessage, error) {
+	if s.raft.State() != raft.Leader {
+		return s.forwardAppend(ctx, msg)
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.apply(raftCommand{Op: opAppend, Messages: [][]byte{b}})
+	if err != nil {
+		return nil, err
+	}
+	stored := resp.(*chatv1.ChatMessage)
+	msg.Sequence = stored.Sequence
+	return msg, nil
+}
+
+// forwardAppend sends an append to the current leader over ClusterService.
+func (s *RaftStore) forwardAppend(ctx context.Context, msg *chatv1.ChatMessage) (*chatv1.ChatMessage, error) {
+	client, err := s.leaderClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.ApplyTimeout)
+	defer cancel()
+	resp, err := client.AppendMessage(s.cluster.forwardContext(ctx), &chatv1.AppendMessageRequest{Message: msg})
+	if err != nil {
+		return nil, err
+	}
+	msg.Sequence = resp.GetMessage().GetSequence()
+	return msg, nil
+}
+
+// leaderClient connects to the current leader to forward a write.
+func (s *RaftStore) leaderClient(ctx context.Context) (chatv1.ClusterServiceClient, error) {
+	if isForwarded(ctx) {
+		// The sender thought we were the leader; don't bounce it around.
+		return nil, status.Error(codes.Unavailable, "not the raft leader")
+	}
+	leader, ok := s.leaderNode()
+	if !ok {
+		return nil, status.Error(codes.Unavailable, "no raft leader")
+	}
+	return s.cluster.clusterClient(leader)
+}
+
+// leaderNode returns the current leader at its gRPC address, the owner of
+// every room and home of every user (see Cluster.pinOwner).
+func (s *RaftStore) leaderNode() (Node, bool) {
+	_, leaderID := s.raft.LeaderWithID()
+	addr, ok := s.peers[string(leaderID)]
+	if leaderID == "" || !ok {
+		return Node{}, false
+	}
+	return Node{ID: string(leaderID), Addr: addr}, true
+}
+
+func (s *RaftStore) List(ctx context.Context, roomID string, limit int) ([]*chatv1.ChatMessage, error) {
+	return s.fsm.store.List(ctx, roomID, limit)
+}
+
+func (s *RaftStore) Rooms(ctx context.Context) ([]string, error) {
+	return s.fsm.store.Rooms(ctx)
+}
+
+// Import commits through the leader, like Append. Rooms aren't handed
+// between owners when every node holds a full replica, so this is a
+// history import.
+func (s *RaftStore) Import(ctx context.Context, roomID string, history []*chatv1.ChatMessage) (int, error) {
+	if s.raft.State() != raft.Leader {
+		client, err := s.leaderClient(ctx)
+		if err != nil {
+			return 0, err
+		}
+		ctx, cancel := context.WithTimeout(ctx, s.cfg.ApplyTimeout)
+		defer cancel()
+		resp, err := client.TransferRoom(s.cluster.forwardContext(ctx), &chatv1.TransferRoomRequest{
+			RoomId:     roomID,
+			FromNodeId: s.cfg.NodeID,
+			Messages:   history,
+		})
+		return int(resp.GetAccepted()), err
+	}
+	cmd := raftCommand{Op: opImport, RoomID: roomID}
+	for _, m := range history {
+		b, err := proto.Marshal(m)
+		if err != nil {
+			return 0, err
+		}
+		cmd.Messages = append(cmd.Messages, b)
+	}
+	resp, err := s.apply(cmd)
+	if err != nil {
+		return 0, err
+	}
+	return resp.(int), nil
+}
+
+// Drop commits through the leader, like Append.
+func (s *RaftStore) Drop(ctx context.Context, roomID string, n int) error {
+	if s.raft.State() != raft.Leader {
+		client, err := s.leaderClient(ctx)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, s.cfg.ApplyTimeout)
+		defer cancel()
+		_, err = client.Drop(s.cluster.forwardContext(ctx), &chatv1.DropRequest{RoomId: roomID, Count: int32(n)})
+		return err
+	}
+	_, err := s.apply(raftCommand{Op: opDrop, RoomID: roomID, N: n})
+	return err
+}
+
+// EraseSender commits through the leader, like Append.
+func (s *RaftStore) EraseSender(ctx context.Context, roomID, senderID, alias string, deleteContent bool) (int, error) {
+	if s.raft.State() != raft.Leader {
+		client, err := s.leaderClient(ctx)
+		if err != nil {
+			return 0, err
+		}
+		ctx, cancel := context.WithTimeout(ctx, s.cfg.ApplyTimeout)
+		defer cancel()
+		resp, err := client.EraseSender(s.cluster.forwardContext(ctx), &chatv1.EraseSenderRequest{
+			RoomId:        roomID,
+			SenderId:      senderID,
+			Alias:         alias,
+			DeleteContent: deleteContent,
+		})
+		return int(resp.GetErased()), err
+	}
+	resp, err := s.apply(raftCommand{Op: opErase, RoomID: roomID, Sender: senderID, Alias: alias, Delete: deleteContent})
+	if err != nil {
+		return 0, err
+	}
+	return resp.(int), nil
+}
+
+// Tombstone commits through the leader, like Append.
+func (s *RaftStore) Tombstone(ctx context.Context, roomID string, sequences []uint64) (int, error) {
+	if s.raft.State() != raft.Leader {
+		client, err := s.leaderClient(ctx)
+		if err != nil {
+			return 0, err
+		}
+		ctx, cancel := context.WithTimeout(ctx, s.cfg.ApplyTimeout)
+		defer cancel()
+		resp, err := client.Tombstone(s.cluster.forwardContext(ctx), &chatv1.TombstoneRequest{RoomId: roomID, Sequences: sequences})
+		return int(resp.GetTombstoned()), err
+	}
+	resp, err := s.apply(raftCommand{Op: opTombstone, RoomID: roomID, Sequences: sequences})
+	if err != nil {
+		return 0, err
+	}
+	return resp.(int), nil
+}
+
+// leads reports whether this replica is the leader, which alone runs
+// retention: every replica holds every room.
+func (s *RaftStore) leads() bool {
+	return s.raft.State() == raft.Leader
+}
+
+// Search reads the local replica.
+func (s *RaftStore) Search(ctx context.Context, q search.Query) ([]*chatv1.ChatMessage, error) {
+	return s.fsm.store.Search(ctx, q)
+}
+
+func (s *RaftStore) RoomSettings(ctx context.Context, roomID string) (*chatv1.RoomSettings, error) {
+	return s.fsm.store.RoomSettings(ctx, roomID)
+}
+
+// SetRoomSettings commits through the leader, like Append.
+func (s *RaftStore) SetRoomSettings(ctx context.Context, settings *chatv1.RoomSettings) error {
+	if s.raft.State() != raft.Leader {
+		client, err := s.leaderClient(ctx)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, s.cfg.ApplyTimeout)
+		defer cancel()
+		_, err = client.SetRoomSettings(s.cluster.forwardContext(ctx), &chatv1.SetRoomSettingsRequest{Settings: settings})
+		return err
+	}
+	b, err := proto.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	_, err = s.apply(raftCommand{Op: opSettings, RoomID: settings.RoomId, Messages: [][]byte{b}})
+	return err
+}
+
+func (s *RaftStore) RoomMembers(ctx context.Context, roomID string) ([]string, error) {
+	return s.fsm.store.RoomMembers(ctx, roomID)
+}
+
+// SetRoomMember commits through the leader, like Append.
+func (s *RaftStore) SetRoomMember(ctx context.Context, roomID, userID string, member bool) error {
+	if s.raft.State() != raft.Leader {
+		client, err := s.leaderClient(ctx)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, s.cfg.ApplyTimeout)
+		defer cancel()
+		_, err = client.SetRoomMember(s.cluster.forwardContext(ctx), &chatv1.SetRoomMemberRequest{RoomId: roomID, UserId: userID, Member: member})
+		return err
+	}
+	_, err := s.apply(raftCommand{Op: opMember, RoomID: roomID, Sender: userID, Member: member})
+	return err
+}
+
+// signingKeys is the replicated registry of signing keys, which the
+// server uses in place of signing.keys_file. Its changes commit through
+// the leader, which is home to every user.
+func (s *RaftStore) signingKeys() *keyRegistry {
+	return s.fsm.keys
+}
+
+// catchUp waits until this node, as leader, has applied every entry
+// committed before it was elected, which a new leader may not have yet. It
+// waits once per term.
+func (s *RaftStore) catchUp() error {
+	term := s.raft.CurrentTerm()
+	if s.caughtUpTerm.Load() == term {
+		return nil
+	}
+	if err := s.raft.Barrier(s.cfg.ApplyTimeout).Error(); err != nil {
+		return status.Errorf(codes.Unavailable, "catch up with the raft log: %v", err)
+	}
+	s.caughtUpTerm.Store(term)
+	return nil
+}
+
+func (s *RaftStore) replicateSigningKeys(userID string, keys []*chatv1.SigningKey) error {
+	cmd := raftCommand{Op: opSigningKeys, Sender: userID}
+	for _, k := range keys {
+		b, err := proto.Marshal(k)
+		if err != nil {
+			return err
+		}
+		cmd.Messages = append(cmd.Messages, b)
+	}
+	_, err := s.apply(cmd)
+	return err
+}
+
+// Ready reports whether appends can be committed: this node leads, or
+// knows the leader to forward them to.
+func (s *RaftStore) Ready(ctx context.Context) error {
+	if state := s.raft.State(); state == raft.Shutdown {
+		return errors.New("raft is shut down")
+	}
+	_, leaderID := s.raft.LeaderWithID()
+	if leaderID == "" {
+		return errors.New("no raft leader")
+	}
+	if _, ok := s.peers[string(leaderID)]; !ok && leaderID != raft.ServerID(s.cfg.NodeID) {
+		return fmt.Errorf("raft leader %s is not in the peers file", leaderID)
+	}
+	return nil
+}
+
+func (s *RaftStore) Close() error {
+	err := s.raft.Shutdown().Error()
+	if cerr := s.logs.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *RaftStore) apply(cmd raftCommand) (any, error) {
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	f := s.raft.Apply(b, s.cfg.ApplyTimeout)
+	if err := f.Error(); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) || errors.Is(err, raft.ErrLeadershipLost) {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		return nil, err
+	}
+	if err, ok := f.Response().(error); ok {
+		return nil, err
+	}
+	return f.Response(), nil
+}
+
+// ----- FSM -----
+
+const (
+	opAppend    = "append"
+	opImport    = "import"
+	opDrop      = "drop"
+	opSettings  = "settings"
+	opErase     = "erase"
+	opTombstone = "tombstone"
+	opMember    = "member"
+	// opSigningKeys sets the signing keys of a user.
+	opSigningKeys = "signing_keys"
+)
+
+// raftCommand is one entry of the replicated log. Messages are serialized
+// ChatMessages, the serialized RoomSettings of an opSettings or the
+// serialized SigningKeys of an opSigningKeys, whose user is Sender. Sender,
+// Alias and Delete are the arguments of an opErase, Sequences those of an
+// opTombstone and Sender and Member those of an opMember.
+type raftCommand struct {
+	Op        string   `json:"op"`
+	RoomID    string   `json:"room_id,omitempty"`
+	N         int      `json:"n,omitempty"`
+	Messages  [][]byte `json:"messages,omitempty"`
+	Sender    string   `json:"sender,omitempty"`
+	Alias     string   `json:"alias,omitempty"`
+	Delete    bool     `json:"delete,omitempty"`
+	Sequences []uint64 `json:"sequences,omitempty"`
+	Member    bool     `json:"member,omitempty"`
+}
+
+// raftFSM applies committed commands to a MemoryStore and a key
+// registry. Every replica applies the same commands in the same order, so
+// sequences match.
+type raftFSM struct {
+	store *MemoryStore
+	keys  *keyRegistry
+}
+
+func (f *raftFSM) Apply(l *raft.Log) any {
+	var cmd raftCommand
+	if err := json.Unmarshal(l.Data, &cmd); err != nil {
+		return err
+	}
+	var msgs []*chatv1.ChatMessage
+	if cmd.Op != opSettings && cmd.Op != opSigningKeys {
+		var err error
+		if msgs, err = decodeMessages(cmd.Messages); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	switch cmd.Op {
+	case opAppend:
+		if len(msgs) != 1 {
+			return fmt.Errorf("append carries %d messages", len(msgs))
+		}
+		stored, _ := f.store.Append(ctx, msgs[0])
+		return proto.Clone(stored)
+	case opImport:
+		n, _ := f.store.Import(ctx, cmd.RoomID, msgs)
+		return n
+	case opDrop:
+		return f.store.Drop(ctx, cmd.RoomID, cmd.N)
+	case opErase:
+		n, _ := f.store.EraseSender(ctx, cmd.RoomID, cmd.Sender, cmd.Alias, cmd.Delete)
+		return n
+	case opTombstone:
+		n, _ := f.store.Tombstone(ctx, cmd.RoomID, cmd.Sequences)
+		return n
+	case opMember:
+		return f.store.SetRoomMember(ctx, cmd.RoomID, cmd.Sender, cmd.Member)
+	case opSettings:
+		if len(cmd.Messages) != 1 {
+			return fmt.Errorf("settings carries %d entries", len(cmd.Messages))
+		}
+		settings := &chatv1.RoomSettings{}
+		if err := proto.Unmarshal(cmd.Messages[0], settings); err != nil {
+			return err
+		}
+		return f.store.SetRoomSettings(ctx, settings)
+	case opSigningKeys:
+		keys, err := decodeSigningKeys(cmd.Messages)
+		if err != nil {
+			return err
+		}
+		f.keys.set(cmd.Sender, keys)
+		return nil
+	}
+	return fmt.Errorf("unknown raft command %q", cmd.Op)
+}
+
+// raftSnapshot is the whole store: room_id → serialized messages,
+// room_id → last assigned sequence, room_id → serialized settings and
+// room_id → member user ids, plus every serialized signing key. The sequence counters are kept apart
+// because retention can drop the messages that last used them.
+type raftSnapshot struct {
+	Rooms       map[string][][]byte `json:"rooms"`
+	RoomSeq     map[string]uint64   `json:"room_seq"`
+	Settings    map[string][]byte   `json:"settings,omitempty"`
+	Members     map[string][]string `json:"members,omitempty"`
+	SigningKeys [][]byte            `json:"signing_keys,omitempty"`
+}
+
+func (f *raftFSM) Snapshot() (raft.FSMSnapshot, error) {
+	ctx := context.Background()
+	rooms, _ := f.store.Rooms(ctx)
+	snap := &raftSnapshot{
+		Rooms:    make(map[string][][]byte, len(rooms)),
+		RoomSeq:  make(map[string]uint64, len(rooms)),
+		Settings: make(map[string][]byte),
+		Members:  make(map[string][]string),
+	}
+	// Raft doesn't apply while Snapshot runs, so the reads below agree.
+	f.store.mu.RLock()
+	for roomID, settings := range f.store.settings {
+		b, err := proto.Marshal(settings)
+		if err != nil {
+			f.store.mu.RUnlock()
+			return nil, err
+		}
+		snap.Settings[roomID] = b
+	}
+	maps.Copy(snap.RoomSeq, f.store.roomSeq)
+	for roomID, users := range f.store.members {
+		snap.Members[roomID] = slices.Sorted(maps.Keys(users))
+	}
+	f.store.mu.RUnlock()
+	for _, roomID := range rooms {
+		msgs, _ := f.store.List(ctx, roomID, 0)
+		for _, m := range msgs {
+			b, err := proto.Marshal(m)
+			if err != nil {
+				return nil, err
+			}
+			snap.Rooms[roomID] = append(snap.Rooms[roomID], b)
+		}
+	}
+	for _, k := range f.keys.all() {
+		b, err := proto.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		snap.SigningKeys = append(snap.SigningKeys, b)
+	}
+	return snap, nil
+}
+
+func (f *raftFSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	var snap raftSnapshot
+	if err := json.NewDecoder(rc).Decode(&snap); err != nil {
+		return err
+	}
+	restored := NewMemoryStore()
+	for roomID, raw := range snap.Rooms {
+		msgs, err := decodeMessages(raw)
+		if err != nil {
+			return err
+		}
+		restored.messages[roomID] = msgs
+		restored.index.SetRoom(roomID, msgs)
+		// Snapshots from before room_seq was kept fall back to the last
+		// message.
+		if len(msgs) > 0 {
+			restored.roomSeq[roomID] = msgs[len(msgs)-1].Sequence
+		}
+	}
+	maps.Copy(restored.roomSeq, snap.RoomSeq)
+	for roomID, b := range snap.Settings {
+		settings := &chatv1.RoomSettings{}
+		if err := proto.Unmarshal(b, settings); err != nil {
+			return err
+		}
+		restored.settings[roomID] = settings
+	}
+	for roomID, users := range snap.Members {
+		for _, userID := range users {
+			restored.SetRoomMember(context.Background(), roomID, userID, true)
+		}
+	}
+	keys, err := decodeSigningKeys(snap.SigningKeys)
+	if err != nil {
+		return err
+	}
+	f.store.mu.Lock()
+	f.store.messages, f.store.roomSeq, f.store.settings, f.store.index = restored.messages, restored.roomSeq, restored.settings, restored.index
+	f.store.members = restored.members
+	f.store.mu.Unlock()
+	f.keys.load(keys)
+	return nil
+}
+
+func (s *raftSnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := json.NewEncoder(sink).Encode(s); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *raftSnapshot) Release() {}
+
+func decodeMessages(raw [][]byte) ([]*chatv1.ChatMessage, error) {
+	msgs := make([]*chatv1.ChatMessage, 0, len(raw))
+	for _, b := range raw {
+		m := &chatv1.ChatMessage{}
+		if err := proto.Unmarshal(b, m); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+func decodeSigningKeys(raw [][]byte) ([]*chatv1.SigningKey, error) {
+	keys := make([]*chatv1.SigningKey, 0, len(raw))
+	for _, b := range raw {
+		k := &chatv1.SigningKey{}
+		if err := proto.Unmarshal(b, k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// LoadRaftPeersFile reads the Raft group: one
+// "<node_id> <raft_address> <grpc_address>" triple per line; blank lines
+// and lines starting with # are ignored.
+func LoadRaftPeersFile(path string) ([]RaftPeer, error) {
+	nodes, err := readFields(path, 3, `"<node_id> <raft_address> <grpc_address>"`)
+	if err != nil {
+		return nil, err
+	}
+	peers := make([]RaftPeer, 0, len(nodes))
+	for _, f := range nodes {
+		peers = append(peers, RaftPeer{ID: f[0], RaftAddr: f[1], GRPCAddr: f[2]})
+	}
+	return peers, nil
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// freeAddr returns a loopback address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+// raftGroup is a Raft group of nodes forwarding writes to the leader in
+// process.
+type raftGroup struct {
+	cfgs   []RaftConfig
+	nodes  []*testNode
+	stores []*RaftStore
+}
+
+func startRaftGroup(t *testing.T, ids ...string) *raftGroup {
+	t.Helper()
+	dir := t.TempDir()
+	var peers []RaftPeer
+	for i, id := range ids {
+		peers = append(peers, RaftPeer{ID: id, RaftAddr: freeAddr(t), GRPCAddr: testAddr(i)})
+	}
+	g := &raftGroup{stores: make([]*RaftStore, len(ids))}
+	for i, id := range ids {
+		g.cfgs = append(g.cfgs, RaftConfig{
+			NodeID:            id,
+			Dir:               fmt.Sprintf("%s/%s", dir, id),
+			BindAddr:          peers[i].RaftAddr,
+			Peers:             peers,
+			Bootstrap:         true,
+			ApplyTimeout:      2 * time.Second,
+			SnapshotThreshold: 8192,
+		})
+	}
+	g.nodes = startNodes(t, ids, func(id string, c *Cluster) MessageStore {
+		i := slices.Index(ids, id)
+		store, err := NewRaftStore(g.cfgs[i], c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.stores[i] = store
+		return store
+	})
+	t.Cleanup(func() {
+		for _, s := range g.stores {
+			if s != nil {
+				s.Close()
+			}
+		}
+	})
+	return g
+}
+
+// leader waits for one of the running stores to lead and returns its
+// index.
+func (g *raftGroup) leader(t *testing.T) int {
+	t.Helper()
+	leader := -1
+	waitFor(t, "a raft leader", 10*time.Second, func() bool {
+		for i, s := range g.stores {
+			if s != nil && s.raft.State() == raft.Leader {
+				leader = i
+				return true
+			}
+		}
+		return false
+	})
+	return leader
+}
+
+// settle waits for every running store to know the leader, which owns
+// every room, and returns its index.
+func (g *raftGroup) settle(t *testing.T) int {
+	t.Helper()
+	leader := g.leader(t)
+	waitFor(t, "every node to know the raft leader", 10*time.Second, func() bool {
+		for _, s := range g.stores {
+			if s == nil {
+				continue
+			}
+			if n, ok := s.leaderNode(); !ok || n.ID != g.cfgs[leader].NodeID {
+				return false
+			}
+		}
+		return true
+	})
+	return leader
+}
+
+// stop shuts down the i-th store, as if its node died.
+func (g *raftGroup) stop(t *testing.T, i int) {
+	t.Helper()
+	if err := g.stores[i].Close(); err != nil {
+		t.Fatal(err)
+	}
+	g.stores[i] = nil
+}
+
+// restart brings the i-th store back from its directory.
+func (g *raftGroup) restart(t *testing.T, i int) {
+	t.Helper()
+	store, err := NewRaftStore(g.cfgs[i], g.nodes[i].chat.cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.stores[i] = store
+	g.nodes[i].chat.store = store
+	g.nodes[i].chat.signingKeys = store.signingKeys()
+}
+
+// stored is a message as a replica holds it.
+type stored struct {
+	id  string
+	seq uint64
+}
+
+func history(t *testing.T, s *RaftStore, roomID string) []stored {
+	t.Helper()
+	msgs, err := s.List(context.Background(), roomID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]stored, len(msgs))
+	for i, m := range msgs {
+		out[i] = stored{m.Id, m.Sequence}
+	}
+	return out
+}
+
+// converge waits for every running replica to hold the same history of
+// roomID and returns it.
+func (g *raftGroup) converge(t *testing.T, roomID string) []stored {
+	t.Helper()
+	var want []stored
+	waitFor(t, "replicas to agree on "+roomID, 10*time.Second, func() bool {
+		want = nil
+		for _, s := range g.stores {
+			if s == nil {
+				continue
+			}
+			got := history(t, s, roomID)
+			if want == nil {
+				want = got
+			} else if !slices.Equal(got, want) {
+				return false
+			}
+		}
+		return true
+	})
+	return want
+}
+
+func testMessage(roomID, id string) *chatv1.ChatMessage {
+	return &chatv1.ChatMessage{Id: id, RoomId: roomID, SenderId: "alice", Text: id, CreatedAt: timestamppb.Now()}
+}
+
+func TestRaftStoreSurvivesLosingTheLeader(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs an election")
+	}
+	g := startRaftGroup(t, "a", "b", "c")
+	ctx := context.Background()
+	leader := g.leader(t)
+
+	// A room whose every message expired keeps its sequence counter.
+	for i := range 3 {
+		if _, err := g.stores[leader].Append(ctx, testMessage("expired", fmt.Sprintf("e%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := g.stores[leader].Tombstone(ctx, "expired", []uint64{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	// A member joined through a follower.
+	if err := g.stores[(leader+1)%len(g.stores)].SetRoomMember(ctx, "room", "alice", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Append through every replica in turn, followers forwarding to the
+	// leader, until told to stop. Only acknowledged appends count.
+	var (
+		mu     sync.Mutex
+		acked  = make(map[string]uint64)
+		down   atomic.Int32
+		stop   = make(chan struct{})
+		done   = make(chan struct{})
+		ackedN atomic.Int32
+	)
+	down.Store(-1)
+	var storesMu sync.Mutex
+	storeAt := func(i int) *RaftStore {
+		storesMu.Lock()
+		defer storesMu.Unlock()
+		return g.stores[i]
+	}
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			n := i % len(g.stores)
+			if int32(n) == down.Load() {
+				continue
+			}
+			msg := testMessage("room", fmt.Sprintf("m%d", i))
+			msg, err := storeAt(n).Append(ctx, msg)
+			if err != nil {
+				time.Sleep(20 * time.Millisecond)
+				continue
+			}
+			mu.Lock()
+			acked[msg.Id] = msg.Sequence
+			mu.Unlock()
+			ackedN.Add(1)
+		}
+	}()
+
+	waitFor(t, "appends before the snapshot", 10*time.Second, func() bool { return ackedN.Load() >= 50 })
+	// Every replica snapshots, so the one restarted below restores from
+	// its snapshot before replaying the rest of its log.
+	for _, s := range g.stores {
+		if err := s.raft.Snapshot().Error(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, "appends after the snapshot", 10*time.Second, func() bool { return ackedN.Load() >= 100 })
+
+	down.Store(int32(leader))
+	storesMu.Lock()
+	g.stop(t, leader)
+	storesMu.Unlock()
+	waitFor(t, "appends under a new leader", 20*time.Second, func() bool { return ackedN.Load() >= 200 })
+	close(stop)
+	<-done
+
+	check := func(when string) {
+		t.Helper()
+		at := make(map[string]uint64)
+		var last uint64
+		for _, m := range g.converge(t, "room") {
+			if m.seq <= last {
+				t.Fatalf("%s: sequence %d follows %d", when, m.seq, last)
+			}
+			last = m.seq
+			at[m.id] = m.seq
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for id, seq := range acked {
+			if got, ok := at[id]; !ok {
+				t.Errorf("%s: acknowledged message %s is lost", when, id)
+			} else if got != seq {
+				t.Errorf("%s: message %s has sequence %d, acknowledged as %d", when, id, got, seq)
+			}
+		}
+	}
+	check("after losing the leader")
+
+	g.restart(t, leader)
+	check("after restarting the old leader")
+
+	// The restarted replica restored the counter of the emptied room.
+	newLeader := g.leader(t)
+	msg, err := g.stores[newLeader].Append(ctx, testMessage("expired", "e3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Sequence != 4 {
+		t.Errorf("expired room continued at sequence %d, want 4", msg.Sequence)
+	}
+	if got, want := g.converge(t, "expired"), []stored{{"e3", 4}}; !slices.Equal(got, want) {
+		t.Errorf("expired room = %v, want %v", got, want)
+	}
+	waitFor(t, "the restarted replica's members", 5*time.Second, func() bool {
+		members, _ := g.stores[leader].RoomMembers(ctx, "room")
+		return slices.Equal(members, []string{"alice"})
+	})
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	exported    = tracetest.NewInMemoryExporter()
+	installOnce sync.Once
+)
+
+// recordSpans starts the test with no spans recorded. The tracer provider
+// is global, so it is installed once for every test.
+func recordSpans(t *testing.T) {
+	t.Helper()
+	installOnce.Do(func() { tracing.Install(exported, "gochat-test", 1) })
+	exported.Reset()
+}
+
+// spans returns every span ended so far.
+func spans(t *testing.T) tracetest.SpanStubs {
+	t.Helper()
+	if err := otel.GetTracerProvider().(*sdktrace.TracerProvider).ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return exported.GetSpans()
+}
+
+// findSpan returns the span named name of the given kind in trace.
+func findSpan(all tracetest.SpanStubs, traceID trace.TraceID, name string, kind trace.SpanKind) (tracetest.SpanStub, bool) {
+	for _, s := range all {
+		if s.SpanContext.TraceID() == traceID && s.Name == name && s.SpanKind == kind {
+			return s, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+// ancestors returns the names of the spans above s, nearest first.
+func ancestors(all tracetest.SpanStubs, s tracetest.SpanStub) []string {
+	byID := make(map[trace.SpanID]tracetest.SpanStub, len(all))
+	for _, span := range all {
+		byID[span.SpanContext.SpanID()] = span
+	}
+	var names []string
+	for s.Parent.IsValid() {
+		parent, ok := byID[s.Parent.SpanID()]
+		if !ok {
+			break
+		}
+		names = append(names, parent.SpanKind.String()+" "+parent.Name)
+		s = parent
+	}
+	return names
+}
+
+func TestTraceFollowsSendMessageToTheOwner(t *testing.T) {
+	recordSpans(t)
+	nodes := startCluster(t, "a", "b")
+	a, b := nodes[0], nodes[1]
+	room := roomOwnedBy(t, a, b)
+
+	ctx, root := tracing.Start(context.Background(), "test")
+	_, err := a.client.SendMessage(ctx, &chatv1.SendMessageRequest{
+		Message: &chatv1.ChatMessage{RoomId: room, SenderId: "alice", Text: "traced"},
+	})
+	root.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The owner appends inside the call a forwarded, which the test's call
+	// to a started. Server spans end once the response is on its way, so
+	// the last ones may still be open.
+	want := []string{
+		"server chat.v1.ChatService/SendMessage",
+		"client chat.v1.ChatService/SendMessage",
+		"server chat.v1.ChatService/SendMessage",
+		"client chat.v1.ChatService/SendMessage",
+		"internal test",
+	}
+	var got []string
+	waitFor(t, "the SendMessage spans", 5*time.Second, func() bool {
+		all := spans(t)
+		store, ok := findSpan(all, root.SpanContext().TraceID(), "store.Append", trace.SpanKindInternal)
+		got = ancestors(all, store)
+		return ok && slices.Equal(got, want)
+	})
+}
+
+func TestTraceCrossesTheBroker(t *testing.T) {
+	recordSpans(t)
+	nodes := startCluster(t, "a", "b")
+	a, b := nodes[0], nodes[1]
+	room := roomOwnedBy(t, a, a)
+
+	sender, listener := a.openStream(t), b.openStream(t)
+	sender.send(t, "alice", room, "over the broker")
+	listener.waitMessage(t, "over the broker")
+
+	// b continues the trace a started for the event: its delivery is a
+	// child of a's event span, and its fan-out a child of the delivery.
+	var event, deliver tracetest.SpanStub
+	waitFor(t, "the broker delivery span", 5*time.Second, func() bool {
+		all := spans(t)
+		var ok bool
+		for _, s := range all {
+			if s.Name == "ChatService.Stream/event" {
+				event, ok = s, true
+			}
+		}
+		if !ok {
+			return false
+		}
+		deliver, ok = findSpan(all, event.SpanContext.TraceID(), "broker.deliver", trace.SpanKindConsumer)
+		return ok && slices.ContainsFunc(all, func(s tracetest.SpanStub) bool {
+			return s.Name == "fanOut" && s.Parent.SpanID() == deliver.SpanContext.SpanID()
+		})
+	})
+	if deliver.Parent.SpanID() != event.SpanContext.SpanID() {
+		t.Errorf("broker.deliver parent = %s, want the stream event span %s", deliver.Parent.SpanID(), event.SpanContext.SpanID())
+	}
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExportUserData sends what this node holds about a user: their messages
+// and memberships in the rooms it owns and, on their home node, their
+// signing keys and restrictions. Unless the call was forwarded, it then
+// relays the same from every other node.
+func (a *AdminServer) ExportUserData(req *chatv1.ExportUserDataRequest, stream chatv1.AdminService_ExportUserDataServer) error {
+	if req.GetUserId() == "" {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	ctx := stream.Context()
+	if err := a.exportLocal(ctx, req.UserId, stream.Send); err != nil {
+		return err
+	}
+	if isForwarded(ctx) {
+		return nil
+	}
+	for _, n := range a.chat.cluster.Members() {
+		if a.chat.cluster.IsSelf(n) {
+			continue
+		}
+		client, err := a.chat.cluster.adminClient(n)
+		if err == nil {
+			var records chatv1.AdminService_ExportUserDataClient
+			if records, err = client.ExportUserData(a.forwardContext(ctx), req); err == nil {
+				err = relayStream(records.Recv, stream.Send)
+			}
+		}
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "node %s unreachable: %v", n.ID, err)
+		}
+	}
+	slog.InfoContext(ctx, "user data exported", logging.UserID, req.UserId, "actor", actor(ctx))
+	return nil
+}
+
+// relayStream passes what recv returns to send until recv reaches the end
+// of its stream.
+func relayStream[T any](recv func() (T, error), send func(T) error) error {
+	for {
+		v, err := recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := send(v); err != nil {
+			return err
+		}
+	}
+}
+
+// exportLocal hands send the records of userID this node holds: per room
+// it owns that they joined or posted in, a membership followed by the
+// user's messages, oldest first.
+func (a *AdminServer) exportLocal(ctx context.Context, userID string, send func(*chatv1.UserDataRecord) error) error {
+	rooms, err := a.chat.ownedRooms(ctx)
+	if err != nil {
+		return err
+	}
+	for _, roomID := range rooms {
+		msgs, err := a.chat.store.List(ctx, roomID, 0)
+		if err != nil {
+			return status.Errorf(codes.Internal, "list messages of %s: %v", roomID, err)
+		}
+		members, err := a.chat.store.RoomMembers(ctx, roomID)
+		if err != nil {
+			return status.Errorf(codes.Internal, "list members of %s: %v", roomID, err)
+		}
+		var sent []*chatv1.ChatMessage
+		for _, m := range msgs {
+			if m.SenderId == userID {
+				sent = append(sent, m)
+			}
+		}
+		_, joined := slices.BinarySearch(members, userID)
+		if len(sent) == 0 && !joined {
+			continue
+		}
+		membership := &chatv1.RoomMembership{RoomId: roomID, Messages: uint64(len(sent)), Joined: joined}
+		if len(sent) > 0 {
+			membership.FirstMessageAt, membership.LastMessageAt = sent[0].CreatedAt, sent[len(sent)-1].CreatedAt
+		}
+		if err := send(&chatv1.UserDataRecord{Record: &chatv1.UserDataRecord_Membership{Membership: membership}}); err != nil {
+			return err
+		}
+		for _, m := range sent {
+			if err := send(&chatv1.UserDataRecord{Record: &chatv1.UserDataRecord_Message{Message: m}}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !a.chat.cluster.IsSelf(a.chat.homeNode(userID)) {
+		return nil
+	}
+	for _, k := range a.chat.signingKeys.list(userID, true) {
+		if err := send(&chatv1.UserDataRecord{Record: &chatv1.UserDataRecord_SigningKey{SigningKey: k}}); err != nil {
+			return err
+		}
+	}
+	for _, r := range a.chat.spam.Restrictions(userID, true) {
+		if err := send(&chatv1.UserDataRecord{Record: &chatv1.UserDataRecord_Restriction{Restriction: r}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EraseUser erases a user on every node under one pseudonym. A node that
+// can't be reached fails the call after the others erased their part;
+// erasing again with the alias in the error finishes the job.
+func (a *AdminServer) EraseUser(ctx context.Context, req *chatv1.EraseUserRequest) (*chatv1.EraseUserResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.Mode != chatv1.EraseMode_ERASE_MODE_ANONYMIZE && req.Mode != chatv1.EraseMode_ERASE_MODE_DELETE {
+		return nil, status.Error(codes.InvalidArgument, "mode must be ERASE_MODE_ANONYMIZE or ERASE_MODE_DELETE")
+	}
+	alias := req.Alias
+	if alias == "" {
+		alias = "erased-" + uuid.NewString()[:8]
+	}
+	if alias == req.UserId {
+		return nil, status.Error(codes.InvalidArgument, "alias must differ from user_id")
+	}
+	resp, err := a.eraseLocal(ctx, req.UserId, alias, req.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if isForwarded(ctx) {
+		return resp, nil
+	}
+	fwd := &chatv1.EraseUserRequest{UserId: req.UserId, Mode: req.Mode, Alias: alias}
+	for _, n := range a.chat.cluster.Members() {
+		if a.chat.cluster.IsSelf(n) {
+			continue
+		}
+		client, err := a.chat.cluster.adminClient(n)
+		if err == nil {
+			var nodeResp *chatv1.EraseUserResponse
+			nodeResp, err = client.EraseUser(a.forwardContext(ctx), fwd)
+			resp.Messages += nodeResp.GetMessages()
+			resp.Rooms += nodeResp.GetRooms()
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "node %s unreachable, erase again with alias %s to finish: %v", n.ID, alias, err)
+		}
+	}
+	return resp, nil
+}
+
+// eraseLocal erases userID from the rooms this node owns and, on their
+// home node, forgets their keys and spam record.
+func (a *AdminServer) eraseLocal(ctx context.Context, userID, alias string, mode chatv1.EraseMode) (*chatv1.EraseUserResponse, error) {
+	deleteContent := mode == chatv1.EraseMode_ERASE_MODE_DELETE
+	resp := &chatv1.EraseUserResponse{Alias: alias}
+	var left int
+	rooms, err := a.chat.ownedRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, roomID := range rooms {
+		n, err := a.chat.store.EraseSender(ctx, roomID, userID, alias, deleteContent)
+		if err != nil {
+			if _, ok := status.FromError(err); ok {
+				return nil, err
+			}
+			return nil, status.Errorf(codes.Internal, "erase messages of %s: %v", roomID, err)
+		}
+		a.chat.flagged.erase(roomID, userID, alias, deleteContent)
+		if n > 0 {
+			resp.Messages += uint64(n)
+			resp.Rooms++
+		}
+		members, err := a.chat.store.RoomMembers(ctx, roomID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list members of %s: %v", roomID, err)
+		}
+		if _, joined := slices.BinarySearch(members, userID); joined {
+			if err := a.chat.store.SetRoomMember(ctx, roomID, userID, false); err != nil {
+				return nil, status.Errorf(codes.Internal, "remove member of %s: %v", roomID, err)
+			}
+			left++
+		}
+	}
+
+	home := a.chat.cluster.IsSelf(a.chat.homeNode(userID))
+	if home {
+		if err := a.chat.signingKeys.erase(userID); err != nil {
+			return nil, err
+		}
+		a.keys.forget(userID)
+		a.chat.spam.Forget(userID)
+	}
+	if resp.Messages == 0 && left == 0 && !home {
+		return resp, nil
+	}
+	slog.InfoContext(ctx, "user erased", logging.UserID, userID, "mode", eraseModeLabel(mode),
+		"messages", resp.Messages, "rooms", resp.Rooms, "memberships", left, "actor", actor(ctx))
+	// The alias stays out of the log, so it doesn't tie the pseudonym to
+	// the user.
+	a.chat.audit(ctx, &chatv1.AuditEvent{
+		Actor:  actor(ctx),
+		Action: auditUserErase,
+		UserId: userID,
+		Details: map[string]string{
+			"mode":     eraseModeLabel(mode),
+			"messages": strconv.FormatUint(resp.Messages, 10),
+			"rooms":    strconv.FormatUint(resp.Rooms, 10),
+		},
+	})
+	return resp, nil
+}
+
+func eraseModeLabel(m chatv1.EraseMode) string {
+	switch m {
+	case chatv1.EraseMode_ERASE_MODE_ANONYMIZE:
+		return "anonymize"
+	case chatv1.EraseMode_ERASE_MODE_DELETE:
+		return "delete"
+	}
+	return "unknown"
+}
@@ -0,0 +1,317 @@
+// Package slackimport reads Slack workspace export archives and turns each
+// channel's history into gochat messages. A channel becomes a room named
+// after it, a Slack user the sender id given by a user map or else their
+// Slack handle, and a thread reply points at the message that started its
+// thread. Joins, topic changes and other channel events are left out.
+package slackimport
+
+import (
+	"archive/zip"
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Options control how an archive maps onto gochat.
+type Options struct {
+	// RoomPrefix goes in front of channel names to make room ids.
+	RoomPrefix string
+	// Users maps Slack user ids or handles, and bot ids, to sender ids.
+	// Anyone not in it keeps their Slack handle.
+	Users map[string]string
+}
+
+// Channel is a conversation of the archive: a public or private channel,
+// a group DM or a DM.
+type Channel struct {
+	ID   string
+	Name string
+	dir  string
+}
+
+// Archive is an open Slack export.
+type Archive struct {
+	zr       *zip.ReadCloser
+	opts     Options
+	channels []Channel
+	users    map[string]string // Slack user id → handle
+	names    map[string]string // channel id → name
+}
+
+type user struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type channel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type message struct {
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype"`
+	User     string `json:"user"`
+	BotID    string `json:"bot_id"`
+	Username string `json:"username"`
+	Text     string `json:"text"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts"`
+	Files    []struct {
+		Name string `json:"name"`
+	} `json:"files"`
+}
+
+// kept are the message subtypes that carry something someone said.
+var kept = map[string]bool{
+	"":                 true,
+	"bot_message":      true,
+	"file_share":       true,
+	"me_message":       true,
+	"thread_broadcast": true,
+}
+
+// Open reads the users and channel lists of the export at path.
+func Open(path string, opts Options) (*Archive, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{zr: zr, opts: opts, users: make(map[string]string), names: make(map[string]string)}
+	var users []user
+	if err := a.readJSON("users.json", &users); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		zr.Close()
+		return nil, err
+	}
+	for _, u := range users {
+		a.users[u.ID] = u.Name
+	}
+	// DMs have no name and are stored under their id.
+	for _, list := range []struct {
+		file  string
+		named bool
+	}{{"channels.json", true}, {"groups.json", true}, {"mpims.json", true}, {"dms.json", false}} {
+		var chans []channel
+		if err := a.readJSON(list.file, &chans); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			zr.Close()
+			return nil, err
+		}
+		for _, c := range chans {
+			ch := Channel{ID: c.ID, Name: c.ID, dir: c.ID}
+			if list.named {
+				ch.Name, ch.dir = c.Name, c.Name
+			}
+			a.channels = append(a.channels, ch)
+			a.names[c.ID] = ch.Name
+		}
+	}
+	if len(a.channels) == 0 {
+		zr.Close()
+		return nil, fmt.Errorf("%s: no channels.json, groups.json, mpims.json or dms.json; not a Slack export?", path)
+	}
+	return a, nil
+}
+
+// Close closes the archive.
+func (a *Archive) Close() error {
+	return a.zr.Close()
+}
+
+// Channels lists the conversations of the archive.
+func (a *Archive) Channels() []Channel {
+	return slices.Clone(a.channels)
+}
+
+// RoomID is the room a channel is imported into.
+func (a *Archive) RoomID(ch Channel) string {
+	return a.opts.RoomPrefix + ch.Name
+}
+
+// Messages returns the history of ch as messages of its room, oldest
+// first, and how many Slack messages were left out.
+func (a *Archive) Messages(ch Channel) ([]*chatv1.ChatMessage, int, error) {
+	var days []string
+	for _, f := range a.zr.File {
+		if path.Dir(f.Name) == ch.dir && path.Ext(f.Name) == ".json" {
+			days = append(days, f.Name)
+		}
+	}
+	slices.Sort(days)
+
+	var raw []message
+	for _, day := range days {
+		var msgs []message
+		if err := a.readJSON(day, &msgs); err != nil {
+			return nil, 0, err
+		}
+		raw = append(raw, msgs...)
+	}
+
+	type dated struct {
+		at  time.Time
+		msg *chatv1.ChatMessage
+	}
+	var out []dated
+	skipped := 0
+	for _, m := range raw {
+		at, err := parseTS(m.TS)
+		sender := a.sender(m)
+		text := a.text(m)
+		if err != nil || m.Type != "message" || !kept[m.Subtype] || sender == "" || text == "" {
+			skipped++
+			continue
+		}
+		msg := &chatv1.ChatMessage{
+			Id:        messageID(ch, m.TS),
+			RoomId:    a.RoomID(ch),
+			SenderId:  sender,
+			Text:      text,
+			CreatedAt: timestamppb.New(at),
+		}
+		if m.ThreadTS != "" && m.ThreadTS != m.TS {
+			msg.ThreadId = messageID(ch, m.ThreadTS)
+		}
+		out = append(out, dated{at, msg})
+	}
+	slices.SortStableFunc(out, func(x, y dated) int { return x.at.Compare(y.at) })
+	msgs := make([]*chatv1.ChatMessage, len(out))
+	for i, d := range out {
+		msgs[i] = d.msg
+	}
+	return msgs, skipped, nil
+}
+
+// ReadUserMap reads a user map: one "<slack id or handle> <sender id>"
+// pair per line; blank lines and lines starting with # are ignored.
+func ReadUserMap(r io.Reader) (map[string]string, error) {
+	users := make(map[string]string)
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) != 2 {
+			return nil, fmt.Errorf("line %d: want \"<slack id or handle> <sender id>\"", n)
+		}
+		users[f[0]] = f[1]
+	}
+	return users, sc.Err()
+}
+
+// sender is the sender id of m, or "" when it names nobody.
+func (a *Archive) sender(m message) string {
+	if m.User != "" {
+		return a.userID(m.User)
+	}
+	if m.BotID != "" {
+		if id, ok := a.opts.Users[m.BotID]; ok {
+			return id
+		}
+		return cmp.Or(m.Username, m.BotID)
+	}
+	return ""
+}
+
+// userID maps a Slack user id to a sender id.
+func (a *Archive) userID(slackID string) string {
+	if id, ok := a.opts.Users[slackID]; ok {
+		return id
+	}
+	name := a.users[slackID]
+	if id, ok := a.opts.Users[name]; ok && name != "" {
+		return id
+	}
+	return cmp.Or(name, slackID)
+}
+
+// markup matches Slack's <...> references: mentions, channels, links.
+var markup = regexp.MustCompile(`<([^<>]*)>`)
+
+// text renders m's text without Slack markup, followed by the names of
+// its files.
+func (a *Archive) text(m message) string {
+	text := markup.ReplaceAllStringFunc(m.Text, func(ref string) string {
+		target, label, _ := strings.Cut(ref[1:len(ref)-1], "|")
+		switch {
+		case strings.HasPrefix(target, "@"):
+			return "@" + a.userID(target[1:])
+		case strings.HasPrefix(target, "#"):
+			if name, ok := a.names[target[1:]]; ok {
+				return "#" + a.opts.RoomPrefix + name
+			}
+			return "#" + label
+		case strings.HasPrefix(target, "!"):
+			if label != "" {
+				return label
+			}
+			return "@" + strings.TrimPrefix(target, "!")
+		case label != "" && label != target:
+			return label + " (" + target + ")"
+		}
+		return strings.TrimPrefix(target, "mailto:")
+	})
+	text = html.UnescapeString(text)
+	for _, f := range m.Files {
+		if text != "" {
+			text += "\n"
+		}
+		text += "[file: " + f.Name + "]"
+	}
+	return text
+}
+
+func (a *Archive) readJSON(name string, v any) error {
+	f, err := a.zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// messageID names a Slack message: channel and timestamp identify it.
+func messageID(ch Channel, ts string) string {
+	return "slack-" + ch.ID + "-" + ts
+}
+
+// parseTS reads a Slack timestamp, seconds with a microsecond fraction.
+func parseTS(ts string) (time.Time, error) {
+	secs, frac, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad timestamp %q", ts)
+	}
+	var ns int64
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		frac += strings.Repeat("0", 9-len(frac))
+		if ns, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("bad timestamp %q", ts)
+		}
+	}
+	return time.Unix(s, ns).UTC(), nil
+}
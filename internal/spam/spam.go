@@ -0,0 +1,261 @@
+// Package spam scores how users behave across rooms and restricts the ones
+// that look like spammers: a temporary mute first, a shadow restriction
+// when the score keeps climbing. Every restriction is kept, in force or
+// not, so operators can review and lift them.
+package spam
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/moderation"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// What each signal adds to a sender's score.
+const (
+	duplicateWeight = 3
+	burstWeight     = 2
+	newUserLink     = 2
+	mentionWeight   = 2
+)
+
+// keepRestrictions bounds the restrictions remembered per user.
+const keepRestrictions = 20
+
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@\w+`)
+
+// post is what is remembered of a recent message.
+type post struct {
+	at   time.Time
+	room string
+	text uint64 // hash of the normalized text
+}
+
+type user struct {
+	firstSeen    time.Time
+	score        float64
+	scoredAt     time.Time
+	recent       []post
+	restrictions []*chatv1.UserRestriction // oldest first
+}
+
+// Detector holds the recent behavior and restrictions of users.
+type Detector struct {
+	mu        sync.Mutex
+	cfg       *config.SpamConfig
+	users     map[string]*user
+	lastSweep time.Time
+}
+
+func New(cfg *config.SpamConfig) *Detector {
+	return &Detector{cfg: cfg, users: make(map[string]*user), lastSweep: time.Now()}
+}
+
+// Configure applies reloaded settings.
+func (d *Detector) Configure(cfg *config.SpamConfig) {
+	d.mu.Lock()
+	d.cfg = cfg
+	d.mu.Unlock()
+}
+
+// Check scores a message its sender is posting to roomID and returns the
+// restriction the sender is under, or nil, and whether this message
+// brought it on. Messages of a restricted sender are not scored.
+func (d *Detector) Check(userID, roomID, text string) (*chatv1.UserRestriction, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	d.sweep(now)
+
+	u, ok := d.users[userID]
+	if !ok {
+		u = &user{firstSeen: now, scoredAt: now}
+		d.users[userID] = u
+	}
+	if r := active(u, now); r != nil {
+		return cloneRestriction(r), false
+	}
+	if !d.cfg.Enabled {
+		return nil, false
+	}
+
+	cutoff := now.Add(-d.cfg.Window)
+	u.recent = slices.DeleteFunc(u.recent, func(p post) bool { return p.at.Before(cutoff) })
+	u.recent = append(u.recent, post{at: now, room: roomID, text: hashText(text)})
+
+	var signals []string
+	add := func(weight float64, format string, args ...any) {
+		u.score += weight
+		signals = append(signals, fmt.Sprintf(format, args...))
+	}
+	u.score = d.decayed(u, now)
+	u.scoredAt = now
+	// Encrypted messages have no text to compare.
+	if rooms := d.duplicateRooms(u); text != "" && rooms >= d.cfg.DuplicateRooms {
+		add(duplicateWeight, "same text in %d rooms", rooms)
+	}
+	if n := len(u.recent); n >= d.cfg.BurstMessages {
+		add(burstWeight, "%d messages in %s", n, d.cfg.Window)
+	}
+	if now.Sub(u.firstSeen) < d.cfg.NewUserAge && moderation.HasLink(text) {
+		add(newUserLink, "link from a user first seen %s ago", now.Sub(u.firstSeen).Round(time.Second))
+	}
+	if n := len(mentionPattern.FindAllString(text, -1)); n > d.cfg.MaxMentions {
+		add(mentionWeight, "%d mentions in one message", n)
+	}
+
+	var r *chatv1.UserRestriction
+	switch {
+	case u.score >= d.cfg.ShadowScore:
+		r = restriction(userID, chatv1.RestrictionKind_RESTRICTION_KIND_SHADOW, u.score, signals, now, d.cfg.ShadowDuration)
+	case u.score >= d.cfg.MuteScore:
+		r = restriction(userID, chatv1.RestrictionKind_RESTRICTION_KIND_MUTE, u.score, signals, now, d.cfg.MuteDuration)
+	default:
+		return nil, false
+	}
+	u.restrictions = append(u.restrictions, r)
+	if len(u.restrictions) > keepRestrictions {
+		u.restrictions = slices.Clone(u.restrictions[len(u.restrictions)-keepRestrictions:])
+	}
+	return cloneRestriction(r), true
+}
+
+// Lift ends the restriction userID is under and clears their score. It
+// returns the lifted restriction, or nil when none was in force.
+func (d *Detector) Lift(userID, actor string) *chatv1.UserRestriction {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	u, ok := d.users[userID]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	u.score = 0
+	u.recent = nil
+	r := active(u, now)
+	if r == nil {
+		return nil
+	}
+	r.LiftedAt = timestamppb.New(now)
+	r.LiftedBy = actor
+	return cloneRestriction(r)
+}
+
+// Forget drops everything remembered about userID, restrictions included.
+func (d *Detector) Forget(userID string) {
+	d.mu.Lock()
+	delete(d.users, userID)
+	d.mu.Unlock()
+}
+
+// Restrictions returns the restrictions of userID, or of every user when
+// userID is empty, oldest first. Only those in force are returned unless
+// inactive is set.
+func (d *Detector) Restrictions(userID string, inactive bool) []*chatv1.UserRestriction {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	var out []*chatv1.UserRestriction
+	for id, u := range d.users {
+		if userID != "" && id != userID {
+			continue
+		}
+		for _, r := range u.restrictions {
+			if inactive || inForce(r, now) {
+				out = append(out, cloneRestriction(r))
+			}
+		}
+	}
+	Sort(out)
+	return out
+}
+
+// Sort orders restrictions oldest first.
+func Sort(rs []*chatv1.UserRestriction) {
+	slices.SortStableFunc(rs, func(a, b *chatv1.UserRestriction) int {
+		return a.CreatedAt.AsTime().Compare(b.CreatedAt.AsTime())
+	})
+}
+
+// decayed is u's score now: it halves every ScoreHalfLife.
+func (d *Detector) decayed(u *user, now time.Time) float64 {
+	halves := now.Sub(u.scoredAt).Seconds() / d.cfg.ScoreHalfLife.Seconds()
+	return u.score * math.Pow(0.5, halves)
+}
+
+// duplicateRooms counts the rooms the latest recent text went to.
+func (d *Detector) duplicateRooms(u *user) int {
+	last := u.recent[len(u.recent)-1].text
+	rooms := make(map[string]struct{})
+	for _, p := range u.recent {
+		if p.text == last {
+			rooms[p.room] = struct{}{}
+		}
+	}
+	return len(rooms)
+}
+
+// sweep forgets users with nothing left worth keeping: no recent posts,
+// a score decayed to nothing, no restriction in force and past being new.
+// d.mu must be held.
+func (d *Detector) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < d.cfg.Window {
+		return
+	}
+	d.lastSweep = now
+	for id, u := range d.users {
+		idle := len(u.recent) == 0 || now.Sub(u.recent[len(u.recent)-1].at) > d.cfg.Window
+		if idle && d.decayed(u, now) < 0.01 && active(u, now) == nil && now.Sub(u.firstSeen) > d.cfg.NewUserAge {
+			delete(d.users, id)
+		}
+	}
+}
+
+func restriction(userID string, kind chatv1.RestrictionKind, score float64, signals []string, now time.Time, d time.Duration) *chatv1.UserRestriction {
+	return &chatv1.UserRestriction{
+		Id:        uuid.NewString(),
+		UserId:    userID,
+		Kind:      kind,
+		Signals:   signals,
+		Score:     score,
+		CreatedAt: timestamppb.New(now),
+		ExpiresAt: timestamppb.New(now.Add(d)),
+	}
+}
+
+// active returns the restriction u is under now, if any.
+func active(u *user, now time.Time) *chatv1.UserRestriction {
+	for _, r := range slices.Backward(u.restrictions) {
+		if inForce(r, now) {
+			return r
+		}
+	}
+	return nil
+}
+
+func inForce(r *chatv1.UserRestriction, now time.Time) bool {
+	return r.LiftedAt == nil && now.Before(r.ExpiresAt.AsTime())
+}
+
+func cloneRestriction(r *chatv1.UserRestriction) *chatv1.UserRestriction {
+	return proto.Clone(r).(*chatv1.UserRestriction)
+}
+
+// hashText normalizes text, so trivial variations still count as the
+// same, and hashes it.
+func hashText(text string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(strings.Fields(strings.ToLower(text)), " ")))
+	return h.Sum64()
+}
@@ -0,0 +1,146 @@
+// Package tracing sets up OpenTelemetry for a gochat role and carries
+// trace context across the hops a message takes: HTTP headers, gRPC
+// metadata and the trace_context of StreamEvents, which is how it crosses
+// WebSocket frames, long polls and the broker.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/config"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/stats"
+)
+
+var (
+	tracer     = otel.Tracer("github.com/qinyul/go-chat")
+	propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+)
+
+func init() {
+	// Context is propagated even when no exporter is installed, so a
+	// gateway without tracing doesn't break the traces of its callers.
+	otel.SetTextMapPropagator(propagator)
+}
+
+// Setup installs the exporter cfg names for the role. The returned
+// function flushes pending spans; call it on shutdown.
+func Setup(ctx context.Context, cfg config.TracingConfig, role string) (func(context.Context) error, error) {
+	switch cfg.Exporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("create OTLP exporter: %w", err)
+		}
+		name := cfg.ServiceName
+		if name == "" {
+			name = "gochat-" + role
+		}
+		return Install(exp, name, cfg.SampleRatio), nil
+	}
+	return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+}
+
+// Install makes exp receive the spans of this process, sampling
+// sampleRatio of new traces. Tests pass a tracetest.InMemoryExporter.
+func Install(exp sdktrace.SpanExporter, serviceName string, sampleRatio float64) func(context.Context) error {
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown
+}
+
+// ServerHandler and ClientHandler trace gRPC calls and carry the context in
+// their metadata.
+func ServerHandler() stats.Handler { return otelgrpc.NewServerHandler() }
+func ClientHandler() stats.Handler { return otelgrpc.NewClientHandler() }
+
+// Start starts an internal span.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartEvent starts a span for handling evt. The span continues the trace
+// in evt's trace_context, if any, and evt is stamped with the new span so
+// the next hop continues from here.
+func StartEvent(ctx context.Context, name string, evt *chatv1.StreamEvent) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(Extract(ctx, evt.TraceContext), name,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(EventAttributes(evt)...))
+	Inject(ctx, evt)
+	return ctx, span
+}
+
+// Extract returns ctx continuing the trace in carrier, a trace_context map.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// Inject writes the span context of ctx into evt's trace_context.
+func Inject(ctx context.Context, evt *chatv1.StreamEvent) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	if evt.TraceContext == nil {
+		evt.TraceContext = make(map[string]string)
+	}
+	propagator.Inject(ctx, propagation.MapCarrier(evt.TraceContext))
+}
+
+// EventAttributes describes evt without its content.
+func EventAttributes(evt *chatv1.StreamEvent) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("chat.event_type", evt.Type.String())}
+	if msg := evt.GetMessage(); msg != nil {
+		attrs = append(attrs, attribute.String("chat.room_id", msg.RoomId), attribute.String("chat.message_id", msg.Id))
+	}
+	return attrs
+}
+
+// Fail records err on span.
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Middleware continues the trace in the request headers with a server span
+// per request, named after the chi route once it is known.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(r.Method)))
+		defer span.End()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+		if rc := chi.RouteContext(ctx); rc != nil && rc.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rc.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rc.RoutePattern()))
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package wsgateway
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/qinyul/go-chat/internal/metrics"
+)
+
+// ConnConfig tunes the per-socket connection manager.
+type ConnConfig struct {
+	// PingInterval is how often the writer pings an idle client.
+	PingInterval time.Duration
+	// PongWait is how long we wait for any frame (pong included) before
+	// treating the client as gone. Must be longer than PingInterval.
+	PongWait time.Duration
+	// WriteWait bounds every single frame write.
+	WriteWait time.Duration
+	// MaxMessageSize is the largest frame accepted from the client.
+	MaxMessageSize int64
+	// SendBuffer is how many outbound frames may queue before writers block.
+	SendBuffer int
+	// Compression enables permessage-deflate when the client offers it.
+	Compression bool
+}
+
+var errConnClosed = errors.New("websocket connection closed")
+
+type outFrame struct {
+	messageType int
+	data        []byte
+}
+
+// wsConn owns a websocket.Conn. gorilla/websocket allows one concurrent
+// reader and one concurrent writer, so every write goes through the send
+// queue and is performed by writeLoop, which also keeps the peer alive with
+// pings. Reads stay on the handler goroutine.
+type wsConn struct {
+	conn *websocket.Conn
+	cfg  ConnConfig
+
+	send    chan outFrame
+	closing chan outFrame // the single close frame, sent once
+	done    chan struct{} // closed when writeLoop exits
+
+	closeOnce sync.Once
+}
+
+func newWSConn(conn *websocket.Conn, cfg ConnConfig) *wsConn {
+	c := &wsConn{
+		conn:    conn,
+		cfg:     cfg,
+		send:    make(chan outFrame, cfg.SendBuffer),
+		closing: make(chan outFrame, 1),
+		done:    make(chan struct{}),
+	}
+
+	conn.SetReadLimit(cfg.MaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(cfg.PongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(cfg.PongWait))
+	})
+	conn.EnableWriteCompression(cfg.Compression)
+
+	go c.writeLoop()
+	return c
+}
+
+// ReadMessage reads the next data frame. Every frame received pushes the
+// idle deadline forward.
+func (c *wsConn) ReadMessage() (int, []byte, error) {
+	msgType, data, err := c.conn.ReadMessage()
+	if err == nil {
+		c.conn.SetReadDeadline(time.Now().Add(c.cfg.PongWait))
+		metrics.WSMessages.WithLabelValues("in").Inc()
+		metrics.WSBytes.WithLabelValues("in").Add(float64(len(data)))
+	}
+	return msgType, data, err
+}
+
+// Write queues a data frame. It blocks while the queue is full and fails
+// once the connection is closing.
+func (c *wsConn) Write(messageType int, data []byte) error {
+	select {
+	case <-c.done:
+		return errConnClosed
+	default:
+	}
+	select {
+	case c.send <- outFrame{messageType: messageType, data: data}:
+		return nil
+	case <-c.done:
+		return errConnClosed
+	}
+}
+
+// maxCloseReason is the room left for a reason in a 125 byte control frame
+// after the 2 byte close code.
+const maxCloseReason = 123
+
+// Close sends a close frame with the given code and reason after any
+// queued frames, then stops the writer. Only the first call has an effect.
+func (c *wsConn) Close(code int, reason string) {
+	if len(reason) > maxCloseReason {
+		// Cut on a rune boundary: a close reason must be valid UTF-8.
+		n := maxCloseReason
+		for n > 0 && !utf8.RuneStart(reason[n]) {
+			n--
+		}
+		reason = reason[:n]
+	}
+	c.closeOnce.Do(func() {
+		c.closing <- outFrame{
+			messageType: websocket.CloseMessage,
+			data:        websocket.FormatCloseMessage(code, reason),
+		}
+	})
+}
+
+// Release tears the socket down. Call it when the handler is done reading.
+func (c *wsConn) Release() {
+	c.Close(websocket.CloseNormalClosure, "")
+	select {
+	case <-c.done:
+	case <-time.After(c.cfg.WriteWait):
+	}
+	c.conn.Close()
+}
+
+func (c *wsConn) writeLoop() {
+	ticker := time.NewTicker(c.cfg.PingInterval)
+	defer func() {
+		ticker.Stop()
+		close(c.done)
+	}()
+
+	for {
+		select {
+		case f := <-c.send:
+			if err := c.write(f); err != nil {
+				slog.Debug("ws write failed", "err", err)
+				c.conn.Close()
+				return
+			}
+
+		case f := <-c.closing:
+			// Flush what is already queued so the client sees the last
+			// events before the close frame.
+			for len(c.send) > 0 {
+				if err := c.write(<-c.send); err != nil {
+					slog.Debug("ws write failed", "err", err)
+					c.conn.Close()
+					return
+				}
+			}
+			c.conn.WriteControl(f.messageType, f.data, time.Now().Add(c.cfg.WriteWait))
+			return
+
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.cfg.WriteWait)); err != nil {
+				slog.Debug("ws ping failed", "err", err)
+				c.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (c *wsConn) write(f outFrame) error {
+	c.conn.SetWriteDeadline(time.Now().Add(c.cfg.WriteWait))
+	if err := c.conn.WriteMessage(f.messageType, f.data); err != nil {
+		return err
+	}
+	metrics.WSMessages.WithLabelValues("out").Inc()
+	metrics.WSBytes.WithLabelValues("out").Add(float64(len(f.data)))
+	return nil
+}
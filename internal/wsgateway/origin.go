@@ -0,0 +1,266 @@
+package wsgateway
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/metrics"
+)
+
+// OriginPolicy decides which browser origins may open a socket.
+//
+// Entries are full origins ("https://chat.example.com") or wildcard
+// subdomains ("https://*.example.com", which matches any subdomain but not
+// the apex). With no entries only same-origin requests are accepted.
+type OriginPolicy struct {
+	exact    map[string]struct{}
+	wildcard []wildcardOrigin
+}
+
+type wildcardOrigin struct {
+	scheme string
+	suffix string // ".example.com[:port]"
+}
+
+func NewOriginPolicy(allowed []string) (*OriginPolicy, error) {
+	p := &OriginPolicy{exact: make(map[string]struct{})}
+	for _, raw := range allowed {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid allowed origin %q", raw)
+		}
+		scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+		if rest, ok := strings.CutPrefix(host, "*."); ok {
+			p.wildcard = append(p.wildcard, wildcardOrigin{scheme: scheme, suffix: "." + rest})
+			continue
+		}
+		p.exact[scheme+"://"+host] = struct{}{}
+	}
+	return p, nil
+}
+
+// Check implements websocket.Upgrader.CheckOrigin.
+func (p *OriginPolicy) Check(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// Browsers always send Origin on WebSocket handshakes; native and
+		// mobile clients don't carry ambient cookies, so they are not a
+		// CSRF vector.
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		metrics.HandshakeRejections.WithLabelValues("origin").Inc()
+		return false
+	}
+	scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+
+	if len(p.exact) == 0 && len(p.wildcard) == 0 {
+		if strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+	}
+	if _, ok := p.exact[scheme+"://"+host]; ok {
+		return true
+	}
+	for _, w := range p.wildcard {
+		if scheme == w.scheme && strings.HasSuffix(host, w.suffix) && len(host) > len(w.suffix) {
+			return true
+		}
+	}
+
+	slog.Warn("ws handshake rejected: origin not allowed", "origin", origin)
+	metrics.HandshakeRejections.WithLabelValues("origin").Inc()
+	return false
+}
+
+// ----- Handshake tickets -----
+
+var (
+	errTicketMissing = errors.New("ticket required")
+	errTicketInvalid = errors.New("invalid ticket")
+	errTicketExpired = errors.New("ticket expired")
+	errTicketReused  = errors.New("ticket already used")
+)
+
+// TicketIssuer hands out short-lived, single-use tickets that a browser
+// passes as ?ticket= when opening /ws. The ticket is obtained with an
+// authenticated REST call, so a cross-site page can't open a socket on the
+// strength of the visitor's cookies alone.
+type TicketIssuer struct {
+	secret []byte
+
+	mu   sync.Mutex
+	ttl  time.Duration
+	used map[string]time.Time // nonce → expiry
+}
+
+type ticketClaims struct {
+	UserID  string `json:"uid"`
+	Expires int64  `json:"exp"`
+	Nonce   string `json:"n"`
+}
+
+func NewTicketIssuer(secret []byte, ttl time.Duration) *TicketIssuer {
+	return &TicketIssuer{
+		secret: secret,
+		ttl:    ttl,
+		used:   make(map[string]time.Time),
+	}
+}
+
+// SetTTL changes the lifetime of tickets issued from now on.
+func (t *TicketIssuer) SetTTL(ttl time.Duration) {
+	t.mu.Lock()
+	t.ttl = ttl
+	t.mu.Unlock()
+}
+
+// Issue returns a signed ticket for userID.
+func (t *TicketIssuer) Issue(userID string) (string, time.Time, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", time.Time{}, err
+	}
+	t.mu.Lock()
+	exp := time.Now().Add(t.ttl)
+	t.mu.Unlock()
+	payload, err := json.Marshal(ticketClaims{
+		UserID:  userID,
+		Expires: exp.Unix(),
+		Nonce:   base64.RawURLEncoding.EncodeToString(nonce),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + t.sign(body), exp, nil
+}
+
+// Redeem validates a ticket and marks it used, returning the user it was
+// issued to.
+func (t *TicketIssuer) Redeem(ticket string) (string, error) {
+	if ticket == "" {
+		return "", errTicketMissing
+	}
+	body, sig, ok := strings.Cut(ticket, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(t.sign(body))) {
+		return "", errTicketInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return "", errTicketInvalid
+	}
+	var c ticketClaims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return "", errTicketInvalid
+	}
+	exp := time.Unix(c.Expires, 0)
+	now := time.Now()
+	if now.After(exp) {
+		return "", errTicketExpired
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for n, e := range t.used {
+		if now.After(e) {
+			delete(t.used, n)
+		}
+	}
+	if _, seen := t.used[c.Nonce]; seen {
+		return "", errTicketReused
+	}
+	t.used[c.Nonce] = exp
+	return c.UserID, nil
+}
+
+func (t *TicketIssuer) sign(body string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// rejectionReason maps a ticket error to its metrics key.
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, errTicketMissing):
+		return "ticket_missing"
+	case errors.Is(err, errTicketExpired):
+		return "ticket_expired"
+	case errors.Is(err, errTicketReused):
+		return "ticket_reused"
+	}
+	return "ticket_invalid"
+}
+
+// bindUser makes evt speak for userID, the user a ticket was issued to,
+// whatever sender or user id the client put in it. An empty userID, from
+// a connection that needed no ticket, leaves evt as it is.
+func bindUser(evt *chatv1.StreamEvent, userID string) {
+	if userID == "" {
+		return
+	}
+	switch p := evt.Payload.(type) {
+	case *chatv1.StreamEvent_Message:
+		if p.Message != nil {
+			p.Message.SenderId = userID
+		}
+	case *chatv1.StreamEvent_Typing:
+		if p.Typing != nil {
+			p.Typing.UserId = userID
+		}
+	case *chatv1.StreamEvent_Presence:
+		if p.Presence != nil {
+			p.Presence.UserId = userID
+		}
+	}
+}
+
+// ----- Ticket handler -----
+
+type TicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// POST /ws/ticket → short-lived ticket for the authenticated caller
+func (s *Server) handleIssueTicket(w http.ResponseWriter, r *http.Request) {
+	tokenAuth := s.tokenAuth.Load()
+	if tokenAuth == nil {
+		http.Error(w, "ticket issuing is not configured", http.StatusNotFound)
+		return
+	}
+	userID, ok := tokenAuth.Authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ticket, exp, err := s.tickets.Issue(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(TicketResponse{Ticket: ticket, ExpiresAt: exp})
+}
@@ -0,0 +1,395 @@
+package wsgateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/metrics"
+	"github.com/qinyul/go-chat/internal/tracing"
+	"google.golang.org/grpc"
+)
+
+// PollConfig tunes the long-poll fallback transport.
+type PollConfig struct {
+	// Wait is how long GET /poll holds the request open when no events
+	// are buffered. Keep it below proxy idle timeouts.
+	Wait time.Duration
+	// IdleTimeout expires sessions that haven't been polled for this long.
+	IdleTimeout time.Duration
+	// MaxBuffered caps events held between polls; the oldest are dropped.
+	MaxBuffered int
+}
+
+var (
+	errSessionNotFound = errors.New("poll session not found or expired")
+	errInvalidCursor   = errors.New("invalid cursor")
+)
+
+// pollSession holds a backend Stream for a client without a persistent
+// connection and buffers its events between polls.
+type pollSession struct {
+	id     string
+	userID string // the ticket's user, or "" without tickets
+	stream grpc.BidiStreamingClient[chatv1.StreamEvent, chatv1.StreamEvent]
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	events   []*chatv1.StreamEvent // events[i] has sequence base+i+1
+	base     uint64
+	dropped  uint64
+	lastSeen time.Time
+	ended    error         // set once the backend stream is gone
+	notify   chan struct{} // closed and replaced whenever events arrive
+	sendMu   sync.Mutex    // grpc streams allow one concurrent sender
+}
+
+func (p *pollSession) cursor(seq uint64) string {
+	return p.id + "." + strconv.FormatUint(seq, 10)
+}
+
+func (p *pollSession) append(evt *chatv1.StreamEvent, max int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, evt)
+	if over := len(p.events) - max; over > 0 {
+		p.events = p.events[over:]
+		p.base += uint64(over)
+		p.dropped += uint64(over)
+		metrics.OutboundDropped.WithLabelValues("poll").Add(float64(over))
+	}
+	close(p.notify)
+	p.notify = make(chan struct{})
+}
+
+func (p *pollSession) end(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ended == nil {
+		p.ended = err
+		close(p.notify)
+		p.notify = make(chan struct{})
+	}
+}
+
+// since acknowledges everything up to seq and returns what follows it.
+// missed reports events that were dropped before the client collected them.
+func (p *pollSession) since(seq uint64) (events []*chatv1.StreamEvent, next, missed uint64, wait <-chan struct{}, ended error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastSeen = time.Now()
+
+	if seq < p.base {
+		missed = p.base - seq
+		seq = p.base
+	}
+	// Everything the client has acknowledged can be released.
+	if ack := seq - p.base; ack > 0 {
+		if ack > uint64(len(p.events)) {
+			ack = uint64(len(p.events))
+		}
+		p.events = p.events[ack:]
+		p.base += ack
+	}
+	events = append(events, p.events...)
+	return events, p.base + uint64(len(p.events)), missed, p.notify, p.ended
+}
+
+// PollManager owns all long-poll sessions of the gateway.
+type PollManager struct {
+	grpcClient chatv1.ChatServiceClient
+	cfg        atomic.Pointer[PollConfig]
+
+	mu       sync.Mutex
+	sessions map[string]*pollSession
+}
+
+func NewPollManager(client chatv1.ChatServiceClient, cfg PollConfig) *PollManager {
+	m := &PollManager{
+		grpcClient: client,
+		sessions:   make(map[string]*pollSession),
+	}
+	m.cfg.Store(&cfg)
+	return m
+}
+
+// SetConfig applies a reloaded config. Wait and MaxBuffered take effect on
+// the next poll and event; IdleTimeout is fixed once Run has started.
+func (m *PollManager) SetConfig(cfg PollConfig) {
+	m.cfg.Store(&cfg)
+}
+
+// Run expires idle sessions until ctx is done.
+func (m *PollManager) Run(ctx context.Context) {
+	idleTimeout := m.cfg.Load().IdleTimeout
+	ticker := time.NewTicker(idleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for id, p := range m.sessions {
+				p.mu.Lock()
+				idle := now.Sub(p.lastSeen) > idleTimeout
+				p.mu.Unlock()
+				if idle {
+					slog.Debug("poll session expired", "session", id)
+					p.cancel()
+					delete(m.sessions, id)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+func (m *PollManager) open(userID string) (*pollSession, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := m.grpcClient.Stream(auth.WithUser(ctx, userID))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	p := &pollSession{
+		id:       uuid.NewString(),
+		userID:   userID,
+		stream:   stream,
+		cancel:   cancel,
+		lastSeen: time.Now(),
+		notify:   make(chan struct{}),
+	}
+
+	go func() {
+		for {
+			evt, err := stream.Recv()
+			if err != nil {
+				slog.Info("poll session backend stream closed", "session", p.id, "err", err)
+				p.end(err)
+				return
+			}
+			p.append(evt, m.cfg.Load().MaxBuffered)
+			if isGoingAway(evt) {
+				// Let the backend finish shutting down; the client
+				// collects the goodbye and then gets 410 Gone.
+				cancel()
+			}
+		}
+	}()
+
+	m.mu.Lock()
+	m.sessions[p.id] = p
+	m.mu.Unlock()
+	return p, nil
+}
+
+func (m *PollManager) get(id string) (*pollSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.sessions[id]
+	if !ok {
+		return nil, errSessionNotFound
+	}
+	return p, nil
+}
+
+func (m *PollManager) remove(id string) {
+	m.mu.Lock()
+	p, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	if ok {
+		p.cancel()
+	}
+}
+
+// Close ends every session. Pending polls return 410 Gone.
+func (m *PollManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, p := range m.sessions {
+		p.cancel()
+		delete(m.sessions, id)
+	}
+}
+
+// parseCursor splits "<session_id>.<seq>".
+func parseCursor(cursor string) (string, uint64, error) {
+	id, seqStr, ok := strings.Cut(cursor, ".")
+	if !ok || id == "" {
+		return "", 0, errInvalidCursor
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return "", 0, errInvalidCursor
+	}
+	return id, seq, nil
+}
+
+// ----- HTTP handlers -----
+
+type PollSubscribeResponse struct {
+	SessionID string `json:"session_id"`
+	Cursor    string `json:"cursor"`
+}
+
+type PollResponse struct {
+	Cursor string            `json:"cursor"`
+	Events []json.RawMessage `json:"events"`
+	// Missed counts events dropped because the client polled too slowly.
+	Missed uint64 `json:"missed,omitempty"`
+}
+
+type PollSendRequest struct {
+	SessionID string          `json:"session_id"`
+	Event     json.RawMessage `json:"event"`
+}
+
+// POST /poll/subscribe → opens a session with its own backend Stream
+func (s *Server) handlePollSubscribe(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if !s.upgrader.CheckOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	var userID string
+	if s.requireTicket {
+		var err error
+		if userID, err = s.tickets.Redeem(r.URL.Query().Get("ticket")); err != nil {
+			slog.WarnContext(r.Context(), "poll subscribe rejected", "err", err)
+			metrics.HandshakeRejections.WithLabelValues(rejectionReason(err)).Inc()
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	p, err := s.polls.open(userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to open backend stream", "err", err)
+		http.Error(w, "internal: cannot open backend stream", http.StatusBadGateway)
+		return
+	}
+	slog.InfoContext(r.Context(), "poll session opened", "session", p.id)
+
+	writeJSON(w, PollSubscribeResponse{SessionID: p.id, Cursor: p.cursor(0)})
+}
+
+// GET /poll?cursor=<cursor> → events after the cursor, waiting up to the
+// configured poll wait when none are buffered
+func (s *Server) handlePoll(w http.ResponseWriter, r *http.Request) {
+	if !s.upgrader.CheckOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	id, seq, err := parseCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p, err := s.polls.get(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	timer := time.NewTimer(s.polls.cfg.Load().Wait)
+	defer timer.Stop()
+
+	for {
+		events, next, missed, wait, ended := p.since(seq)
+		if len(events) > 0 || missed > 0 {
+			resp := PollResponse{Cursor: p.cursor(next), Missed: missed, Events: make([]json.RawMessage, 0, len(events))}
+			for _, evt := range events {
+				b, err := jsonMarshal.Marshal(evt)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				resp.Events = append(resp.Events, b)
+			}
+			writeJSON(w, resp)
+			return
+		}
+		if ended != nil {
+			s.polls.remove(id)
+			http.Error(w, fmt.Sprintf("backend stream ended: %v", ended), http.StatusGone)
+			return
+		}
+
+		select {
+		case <-wait:
+		case <-timer.C:
+			writeJSON(w, PollResponse{Cursor: p.cursor(next), Events: []json.RawMessage{}})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// POST /poll/send → forwards one StreamEvent on the session's backend Stream
+func (s *Server) handlePollSend(w http.ResponseWriter, r *http.Request) {
+	if !s.upgrader.CheckOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.connCfg.Load().MaxMessageSize)
+	var req PollSendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p, err := s.polls.get(req.SessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	evt := &chatv1.StreamEvent{}
+	if err := jsonUnmarshal.Unmarshal(req.Event, evt); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	bindUser(evt, p.userID)
+
+	p.mu.Lock()
+	p.lastSeen = time.Now()
+	p.mu.Unlock()
+
+	// The request's own span is the parent unless the event carries one.
+	_, span := tracing.StartEvent(r.Context(), "poll.send", evt)
+	defer span.End()
+	p.sendMu.Lock()
+	err = p.stream.Send(evt)
+	p.sendMu.Unlock()
+	if err != nil {
+		tracing.Fail(span, err)
+		slog.WarnContext(r.Context(), "backend stream send failed", "session", p.id, "err", err)
+		s.polls.remove(p.id)
+		http.Error(w, "backend stream closed", http.StatusGone)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,84 @@
+package wsgateway
+
+import (
+	"fmt"
+
+	"github.com/gorilla/websocket"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Sec-WebSocket-Protocol values understood by the gateway. Clients that do
+// not ask for any of these fall back to the legacy WSRequest/WSEnvelope JSON.
+const (
+	ProtocolJSONv1  = "gochat.v1.json"
+	ProtocolProtov1 = "gochat.v1.proto"
+)
+
+// supportedProtocols is in server preference order: when a client offers
+// both, the compact binary protocol wins.
+var supportedProtocols = []string{ProtocolProtov1, ProtocolJSONv1}
+
+// wireCodec converts StreamEvents to and from WebSocket frames for a
+// negotiated subprotocol.
+type wireCodec interface {
+	Decode(messageType int, data []byte) (*chatv1.StreamEvent, error)
+	Encode(evt *chatv1.StreamEvent) (messageType int, data []byte, err error)
+}
+
+// codecFor returns the codec for a negotiated subprotocol, or nil when the
+// connection speaks the legacy JSON envelope.
+func codecFor(subprotocol string) wireCodec {
+	switch subprotocol {
+	case ProtocolJSONv1:
+		return jsonCodec{}
+	case ProtocolProtov1:
+		return protoCodec{}
+	}
+	return nil
+}
+
+// jsonCodec carries one protojson encoded StreamEvent per text frame, using
+// the exact proto field names (room_id, sender_id, ...).
+type jsonCodec struct{}
+
+var (
+	jsonMarshal   = protojson.MarshalOptions{UseProtoNames: true}
+	jsonUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+func (jsonCodec) Decode(messageType int, data []byte) (*chatv1.StreamEvent, error) {
+	if messageType != websocket.TextMessage {
+		return nil, fmt.Errorf("%s expects text frames", ProtocolJSONv1)
+	}
+	evt := &chatv1.StreamEvent{}
+	if err := jsonUnmarshal.Unmarshal(data, evt); err != nil {
+		return nil, err
+	}
+	return evt, nil
+}
+
+func (jsonCodec) Encode(evt *chatv1.StreamEvent) (int, []byte, error) {
+	b, err := jsonMarshal.Marshal(evt)
+	return websocket.TextMessage, b, err
+}
+
+// protoCodec carries one serialized StreamEvent per binary frame.
+type protoCodec struct{}
+
+func (protoCodec) Decode(messageType int, data []byte) (*chatv1.StreamEvent, error) {
+	if messageType != websocket.BinaryMessage {
+		return nil, fmt.Errorf("%s expects binary frames", ProtocolProtov1)
+	}
+	evt := &chatv1.StreamEvent{}
+	if err := proto.Unmarshal(data, evt); err != nil {
+		return nil, err
+	}
+	return evt, nil
+}
+
+func (protoCodec) Encode(evt *chatv1.StreamEvent) (int, []byte, error) {
+	b, err := proto.Marshal(evt)
+	return websocket.BinaryMessage, b, err
+}
@@ -0,0 +1,492 @@
+// Package wsgateway bridges WebSocket and long-poll clients to the
+// ChatService Stream RPC.
+package wsgateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"github.com/qinyul/go-chat/internal/auth"
+	"github.com/qinyul/go-chat/internal/config"
+	"github.com/qinyul/go-chat/internal/logging"
+	"github.com/qinyul/go-chat/internal/metrics"
+	"github.com/qinyul/go-chat/internal/ratelimit"
+	"github.com/qinyul/go-chat/internal/tracing"
+)
+
+type WSRequest struct {
+	Type    string `json:"type"`
+	Message struct {
+		RoomID   string `json:"room_id"`
+		SenderID string `json:"sender_id"`
+		Text     string `json:"text"`
+		// Encrypted replaces Text in end-to-end encrypted rooms; its
+		// bytes are base64.
+		Encrypted *chatv1.EncryptedPayload `json:"encrypted,omitempty"`
+	} `json:"message"`
+	// TraceContext carries the client's traceparent and tracestate.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+type WSError struct {
+	Error string `json:"error"`
+}
+
+type WSEnvelope struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+type Server struct {
+	grpcClient chatv1.ChatServiceClient
+	upgrader   websocket.Upgrader
+
+	// Reloaded on SIGHUP; see apply.
+	connCfg   atomic.Pointer[ConnConfig]
+	origins   atomic.Pointer[OriginPolicy]
+	tokenAuth atomic.Pointer[auth.TokenAuth]
+	// ips limits the requests and frames of each client IP.
+	ips *ratelimit.Limiter
+
+	// requireTicket makes /ws refuse handshakes without a valid ticket.
+	requireTicket bool
+	tickets       *TicketIssuer
+
+	polls     *PollManager
+	stopPolls context.CancelFunc
+
+	// Hijacked sockets aren't tracked by http.Server.Shutdown, so the
+	// gateway tracks them itself to close them on shutdown.
+	mu       sync.Mutex
+	conns    map[*wsConn]struct{}
+	handlers sync.WaitGroup
+	draining atomic.Bool
+}
+
+// New returns a WebSocket gateway that opens backend streams on conn.
+func New(cfg *config.Config, conn grpc.ClientConnInterface) (*Server, error) {
+	wc := cfg.WebSocket
+
+	secret := make([]byte, 32)
+	if wc.TicketSecretFile != "" {
+		var err error
+		if secret, err = os.ReadFile(wc.TicketSecretFile); err != nil {
+			return nil, fmt.Errorf("failed to read ticket secret: %w", err)
+		}
+	} else if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate ticket secret: %w", err)
+	}
+
+	grpcClient := chatv1.NewChatServiceClient(conn)
+	s := &Server{
+		grpcClient:    grpcClient,
+		requireTicket: wc.RequireTicket,
+		tickets:       NewTicketIssuer(secret, wc.TicketTTL),
+		polls:         NewPollManager(grpcClient, pollConfig(wc)),
+		ips:           ratelimit.New(ratelimit.Limit{Rate: cfg.RateLimit.IPRate, Burst: cfg.RateLimit.IPBurst}),
+		conns:         make(map[*wsConn]struct{}),
+	}
+	s.upgrader = websocket.Upgrader{
+		Subprotocols:      supportedProtocols,
+		EnableCompression: wc.Compression,
+		CheckOrigin:       s.checkOrigin,
+	}
+	if err := s.apply(wc); err != nil {
+		return nil, fmt.Errorf("invalid websocket config: %w", err)
+	}
+
+	pollCtx, stopPolls := context.WithCancel(context.Background())
+	s.stopPolls = stopPolls
+	go s.polls.Run(pollCtx)
+	return s, nil
+}
+
+// Mount registers the WebSocket, ticket and long-poll routes on r.
+func (s *Server) Mount(r chi.Router) {
+	r = r.With(s.ips.Middleware)
+	r.Get("/ws", s.handleWS)
+	r.Post("/ws/ticket", s.handleIssueTicket)
+	r.Post("/poll/subscribe", s.handlePollSubscribe)
+	r.Get("/poll", s.handlePoll)
+	r.Post("/poll/send", s.handlePollSend)
+}
+
+// Reload applies the reloadable settings of cfg.
+func (s *Server) Reload(cfg *config.Config) error {
+	s.ips.SetLimit(ratelimit.Limit{Rate: cfg.RateLimit.IPRate, Burst: cfg.RateLimit.IPBurst})
+	return s.apply(cfg.WebSocket)
+}
+
+// apply installs the settings that can change at runtime. Open sockets
+// keep the connection settings they were opened with.
+func (s *Server) apply(cfg config.WebSocketConfig) error {
+	origins, err := NewOriginPolicy(cfg.AllowedOrigins)
+	if err != nil {
+		return err
+	}
+	var tokenAuth *auth.TokenAuth
+	if cfg.APITokensFile != "" {
+		if tokenAuth, err = auth.LoadTokenAuth(cfg.APITokensFile); err != nil {
+			return fmt.Errorf("failed to load API tokens: %w", err)
+		}
+	}
+
+	s.connCfg.Store(&ConnConfig{
+		PingInterval:   cfg.PingInterval,
+		PongWait:       cfg.PongWait,
+		WriteWait:      cfg.WriteWait,
+		MaxMessageSize: cfg.MaxMessageSize,
+		SendBuffer:     cfg.SendBuffer,
+		Compression:    cfg.Compression,
+	})
+	s.origins.Store(origins)
+	s.tokenAuth.Store(tokenAuth)
+	s.tickets.SetTTL(cfg.TicketTTL)
+	s.polls.SetConfig(pollConfig(cfg))
+	return nil
+}
+
+func pollConfig(cfg config.WebSocketConfig) PollConfig {
+	return PollConfig{
+		Wait:        cfg.Poll.Wait,
+		IdleTimeout: cfg.Poll.IdleTimeout,
+		MaxBuffered: cfg.Poll.MaxBuffered,
+	}
+}
+
+func (s *Server) checkOrigin(r *http.Request) bool {
+	return s.origins.Load().Check(r)
+}
+
+// Shutdown refuses new sockets and long-poll sessions, sends a going-away
+// close frame on every open socket and waits for their handlers to return.
+// Sockets still open when ctx is done are dropped.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+	s.stopPolls()
+	s.polls.Close()
+
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close(websocket.CloseGoingAway, "server shutting down")
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.handlers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for c := range s.conns {
+			c.conn.Close()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) track(c *wsConn) {
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+}
+
+func (s *Server) untrack(c *wsConn) {
+	s.mu.Lock()
+	delete(s.conns, c)
+	s.mu.Unlock()
+}
+
+// ----- WebSocket handler -----
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	s.handlers.Add(1)
+	defer s.handlers.Done()
+
+	logCtx := r.Context()
+	// userID is who the ticket was issued to; frames on the socket speak
+	// for them whatever ids they carry.
+	var userID string
+	if s.requireTicket {
+		var err error
+		userID, err = s.tickets.Redeem(r.URL.Query().Get("ticket"))
+		if err != nil {
+			slog.WarnContext(logCtx, "ws handshake rejected", "err", err)
+			metrics.HandshakeRejections.WithLabelValues(rejectionReason(err)).Inc()
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		logCtx = logging.With(logCtx, logging.UserID, userID)
+		slog.DebugContext(logCtx, "ws ticket redeemed")
+	}
+
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.WarnContext(logCtx, "ws upgrade failed", "err", err)
+		return
+	}
+
+	conn := newWSConn(ws, *s.connCfg.Load())
+	s.track(conn)
+	metrics.WSConnections.Inc()
+	defer func() {
+		s.untrack(conn)
+		conn.Release()
+		metrics.WSConnections.Dec()
+	}()
+	codec := codecFor(ws.Subprotocol())
+	slog.InfoContext(logCtx, "websocket connected", "subprotocol", ws.Subprotocol())
+
+	// Create an independent context for the gRPC stream(s), carrying the
+	// request's log attributes to the backend. When the HTTP request
+	// context is Done (client closed), cancel this stream ctx.
+	ctx, cancel := context.WithCancel(logging.With(context.Background(), logging.RequestID, logging.RequestIDFrom(logCtx)))
+	defer cancel()
+	go func() {
+		<-r.Context().Done()
+		cancel()
+	}()
+
+	// Open one long-lived gRPC stream for this WS connection, for the
+	// ticket's user.
+	stream, err := s.grpcClient.Stream(auth.WithUser(ctx, userID))
+
+	if err != nil {
+		slog.ErrorContext(logCtx, "failed to open backend stream", "err", err)
+		s.sendError(conn, "internal: cannot open backend stream")
+		conn.Close(websocket.CloseInternalServerErr, "cannot open backend stream")
+		return
+	}
+
+	defer stream.CloseSend()
+
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				slog.InfoContext(logCtx, "backend stream closed", "err", err)
+				conn.Close(closeCodeFor(err))
+				return
+			}
+			if codec != nil {
+				s.sendEvent(conn, codec, event)
+			} else {
+				s.sendWS(conn, "StreamEvent", event)
+			}
+			// The backend is shutting down: pass the goodbye on and drop
+			// the stream so the backend doesn't wait for us.
+			if isGoingAway(event) {
+				conn.Close(websocket.CloseGoingAway, "server going away")
+				cancel()
+				return
+			}
+		}
+	}()
+
+	ip := ratelimit.ClientIP(r)
+	for {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.WarnContext(logCtx, "ws read failed", "err", err)
+			}
+			if errors.Is(err, websocket.ErrReadLimit) {
+				conn.Close(websocket.CloseMessageTooBig, "message too big")
+			}
+			return
+		}
+		if ok, wait := s.ips.Allow(ip, 1); !ok {
+			metrics.RateLimited.WithLabelValues("ip").Inc()
+			s.sendRateLimited(conn, codec, wait)
+			continue
+		}
+
+		if codec != nil {
+			evt, err := codec.Decode(msgType, msg)
+			if err != nil {
+				slog.WarnContext(logCtx, "ws decode failed", "err", err)
+				s.sendInvalidFrame(conn, codec, err)
+				continue
+			}
+			bindUser(evt, userID)
+			_, span := tracing.StartEvent(ctx, "wsgateway.receive", evt)
+			err = stream.Send(evt)
+			span.End()
+			if err != nil {
+				slog.WarnContext(logCtx, "backend stream send failed", "err", err)
+				return
+			}
+			continue
+		}
+
+		var req WSRequest
+
+		if err := json.Unmarshal(msg, &req); err != nil {
+			s.sendError(conn, "invalid json")
+			continue
+		}
+		if userID != "" {
+			req.Message.SenderID = userID
+		}
+		s.processWSRequest(logCtx, conn, req, stream)
+	}
+}
+
+// ----- WS Request Processor -----
+func (s *Server) processWSRequest(logCtx context.Context, conn *wsConn, req WSRequest, stream grpc.BidiStreamingClient[chatv1.StreamEvent, chatv1.StreamEvent]) {
+	ctx := logging.With(logCtx, logging.UserID, req.Message.SenderID, logging.RoomID, req.Message.RoomID)
+	slog.DebugContext(ctx, "ws request", "type", req.Type, "text", logging.Content(req.Message.Text))
+	ctx, span := tracing.Start(tracing.Extract(ctx, req.TraceContext), "wsgateway."+req.Type)
+	defer span.End()
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	switch req.Type {
+	case "SendMessage":
+		evt := toProtoStreamEvent(&req)
+		tracing.Inject(ctx, evt)
+		if err := stream.Send(evt); err != nil {
+			slog.WarnContext(ctx, "backend stream send failed", "err", err)
+			return
+		}
+
+	case "GetMessages":
+		var r chatv1.GetMessageRequest
+		b, _ := json.Marshal(req.Message)
+		json.Unmarshal(b, &r)
+
+		resp, err := s.grpcClient.Getmessages(ctx, &r)
+		if err != nil {
+			s.sendError(conn, err.Error())
+			return
+		}
+
+		s.sendWS(conn, "GetMessageResult", resp)
+
+	case "StreamEvent":
+		evt := toProtoStreamEvent(&req)
+		tracing.Inject(ctx, evt)
+		if err := stream.Send(evt); err != nil {
+			slog.WarnContext(ctx, "backend stream send failed", "err", err)
+			return
+		}
+	}
+
+}
+
+func (s *Server) sendWS(conn *wsConn, msgType string, data interface{}) {
+	out := WSEnvelope{
+		Type: msgType,
+		Data: data,
+	}
+	b, _ := json.Marshal(out)
+	conn.Write(websocket.TextMessage, b)
+}
+
+// sendEvent writes a StreamEvent using the connection's negotiated subprotocol.
+func (s *Server) sendEvent(conn *wsConn, codec wireCodec, evt *chatv1.StreamEvent) {
+	msgType, b, err := codec.Encode(evt)
+	if err != nil {
+		slog.Error("ws encode failed", "err", err)
+		return
+	}
+	conn.Write(msgType, b)
+}
+
+func (s *Server) sendError(conn *wsConn, msg string) {
+	b, _ := json.Marshal(WSError{Error: msg})
+	conn.Write(websocket.TextMessage, b)
+}
+
+// sendRateLimited tells the client its last frame was dropped, as a
+// CONTROL_ACTION_RATE_LIMITED event or, on the legacy protocol, an error.
+func (s *Server) sendRateLimited(conn *wsConn, codec wireCodec, wait time.Duration) {
+	if codec == nil {
+		s.sendError(conn, "rate limited: retry after "+ratelimit.RetryAfter(wait)+"s")
+		return
+	}
+	s.sendEvent(conn, codec, &chatv1.StreamEvent{
+		Type: chatv1.EventType_EVENT_TYPE_CONTROL,
+		Payload: &chatv1.StreamEvent_Control{Control: &chatv1.ControlEvent{
+			Action:     chatv1.ControlAction_CONTROL_ACTION_RATE_LIMITED,
+			Reason:     "too many frames from this address",
+			RetryAfter: durationpb.New(wait),
+		}},
+	})
+}
+
+// sendInvalidFrame tells a subprotocol client that its last frame didn't
+// decode and was skipped.
+func (s *Server) sendInvalidFrame(conn *wsConn, codec wireCodec, err error) {
+	s.sendEvent(conn, codec, &chatv1.StreamEvent{
+		Type: chatv1.EventType_EVENT_TYPE_CONTROL,
+		Payload: &chatv1.StreamEvent_Control{Control: &chatv1.ControlEvent{
+			Action: chatv1.ControlAction_CONTROL_ACTION_REJECTED,
+			Reason: "invalid frame: " + err.Error(),
+		}},
+	})
+}
+
+func isGoingAway(evt *chatv1.StreamEvent) bool {
+	return evt.GetControl().GetAction() == chatv1.ControlAction_CONTROL_ACTION_GOING_AWAY
+}
+
+// closeCodeFor maps how the backend stream ended to a WebSocket close code
+// and reason, so clients can tell a clean end from a reason to reconnect.
+func closeCodeFor(err error) (int, string) {
+	if errors.Is(err, io.EOF) {
+		return websocket.CloseNormalClosure, "backend stream ended"
+	}
+	switch status.Code(err) {
+	case codes.Canceled:
+		return websocket.CloseGoingAway, "stream canceled"
+	case codes.Unavailable:
+		return websocket.CloseTryAgainLater, "backend unavailable"
+	case codes.ResourceExhausted:
+		return websocket.CloseTryAgainLater, "backend overloaded"
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return websocket.ClosePolicyViolation, status.Convert(err).Message()
+	}
+	return websocket.CloseInternalServerErr, "backend stream error"
+}
+
+func toProtoStreamEvent(req *WSRequest) *chatv1.StreamEvent {
+	return &chatv1.StreamEvent{
+		Type: chatv1.EventType_EVENT_TYPE_MESSAGE,
+		Payload: &chatv1.StreamEvent_Message{
+			Message: &chatv1.ChatMessage{
+				RoomId:    req.Message.RoomID,
+				SenderId:  req.Message.SenderID,
+				Text:      req.Message.Text,
+				Encrypted: req.Message.Encrypted,
+			},
+		},
+	}
+}
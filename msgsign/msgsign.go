@@ -0,0 +1,113 @@
+// Package msgsign signs chat messages so anyone holding the sender's
+// public key can tell a message came from its sender_id. The server
+// checks signatures against the keys users register through KeyService.
+//
+// A signature is Ed25519 over the message's canonical form: the label
+// "gochat-msg-v1" and a zero byte, then id, room_id, sender_id and text as
+// fields, created_at as big-endian int64 seconds and int32 nanos, and the
+// encrypted payload's ciphertext and key_id as fields, its iteration as a
+// big-endian uint32 and its signature as a field; for a reply in a thread,
+// thread_id follows as a field. A field is its length as a big-endian
+// uint32 followed by its bytes; absent values are empty or 0. Sequence and
+// the signature itself are not covered.
+package msgsign
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Labels separating what a key may sign.
+const (
+	messageLabel    = "gochat-msg-v1"
+	endorseLabel    = "gochat-key-v1"
+	revocationLabel = "gochat-revoke-v1"
+)
+
+// KeyID names a public key: the hex of the first 8 bytes of its SHA-256.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Canonical is the byte string a message's signature covers.
+func Canonical(msg *chatv1.ChatMessage) []byte {
+	b := label(messageLabel)
+	for _, s := range []string{msg.Id, msg.RoomId, msg.SenderId, msg.Text} {
+		b = field(b, []byte(s))
+	}
+	b = binary.BigEndian.AppendUint64(b, uint64(msg.GetCreatedAt().GetSeconds()))
+	b = binary.BigEndian.AppendUint32(b, uint32(msg.GetCreatedAt().GetNanos()))
+	enc := msg.GetEncrypted()
+	b = field(b, enc.GetCiphertext())
+	b = field(b, []byte(enc.GetKeyId()))
+	b = binary.BigEndian.AppendUint32(b, enc.GetIteration())
+	b = field(b, enc.GetSignature())
+	if msg.ThreadId != "" {
+		b = field(b, []byte(msg.ThreadId))
+	}
+	return b
+}
+
+// Sign signs msg with priv, first giving it an id and a creation time if
+// it has none, since the server would fill those in after signing.
+func Sign(msg *chatv1.ChatMessage, priv ed25519.PrivateKey) {
+	if msg.Id == "" {
+		msg.Id = uuid.NewString()
+	}
+	if msg.CreatedAt == nil {
+		msg.CreatedAt = timestamppb.Now()
+	}
+	msg.SignatureKeyId = KeyID(priv.Public().(ed25519.PublicKey))
+	msg.Signature = ed25519.Sign(priv, Canonical(msg))
+}
+
+// Verify reports whether msg carries a valid signature by pub.
+func Verify(msg *chatv1.ChatMessage, pub ed25519.PublicKey) bool {
+	return len(msg.Signature) > 0 && ed25519.Verify(pub, Canonical(msg), msg.Signature)
+}
+
+// Endorse returns the endorsement by priv of newKey as a signing key of
+// userID, which registering a user's second and later keys takes.
+func Endorse(priv ed25519.PrivateKey, userID string, newKey ed25519.PublicKey) []byte {
+	return ed25519.Sign(priv, endorsement(userID, newKey))
+}
+
+// VerifyEndorsement reports whether sig is pub's endorsement of newKey.
+func VerifyEndorsement(pub ed25519.PublicKey, userID string, newKey ed25519.PublicKey, sig []byte) bool {
+	return ed25519.Verify(pub, endorsement(userID, newKey), sig)
+}
+
+// Revoke returns priv's signature over the revocation of userID's key
+// keyID.
+func Revoke(priv ed25519.PrivateKey, userID, keyID string) []byte {
+	return ed25519.Sign(priv, revocation(userID, keyID))
+}
+
+// VerifyRevocation reports whether sig is pub's revocation of keyID.
+func VerifyRevocation(pub ed25519.PublicKey, userID, keyID string, sig []byte) bool {
+	return ed25519.Verify(pub, revocation(userID, keyID), sig)
+}
+
+func endorsement(userID string, newKey ed25519.PublicKey) []byte {
+	return field(field(label(endorseLabel), []byte(userID)), newKey)
+}
+
+func revocation(userID, keyID string) []byte {
+	return field(field(label(revocationLabel), []byte(userID)), []byte(keyID))
+}
+
+func label(l string) []byte {
+	return append([]byte(l), 0)
+}
+
+func field(b, v []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,126 @@
+package msgsign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/qinyul/go-chat/gen/go/chat/chatv1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func testKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+func TestCanonical(t *testing.T) {
+	at := &timestamppb.Timestamp{Seconds: 1, Nanos: 2}
+	for _, tc := range []struct {
+		name string
+		msg  *chatv1.ChatMessage
+		want string // hex after the label
+	}{
+		{"plain", &chatv1.ChatMessage{Id: "m", RoomId: "r", SenderId: "s", Text: "hi", CreatedAt: at},
+			"000000016d" + "0000000172" + "0000000173" + "000000026869" +
+				"0000000000000001" + "00000002" + "00000000" + "00000000" + "00000000" + "00000000"},
+		{"encrypted reply", &chatv1.ChatMessage{Id: "m", RoomId: "r", SenderId: "s", CreatedAt: at, ThreadId: "t",
+			Encrypted: &chatv1.EncryptedPayload{Ciphertext: []byte{0xff}, KeyId: "k", Iteration: 3, Signature: []byte{0xee}}},
+			"000000016d" + "0000000172" + "0000000173" + "00000000" +
+				"0000000000000001" + "00000002" + "00000001ff" + "000000016b" + "00000003" + "00000001ee" + "0000000174"},
+		{"empty", &chatv1.ChatMessage{},
+			"00000000" + "00000000" + "00000000" + "00000000" +
+				"0000000000000000" + "00000000" + "00000000" + "00000000" + "00000000" + "00000000"},
+	} {
+		got := Canonical(tc.msg)
+		prefix := hex.EncodeToString([]byte("gochat-msg-v1\x00"))
+		if h := hex.EncodeToString(got); h != prefix+tc.want {
+			t.Errorf("%s: Canonical = %s, want %s", tc.name, h, prefix+tc.want)
+		}
+	}
+}
+
+func TestCanonicalKeepsFieldsApart(t *testing.T) {
+	a := Canonical(&chatv1.ChatMessage{Id: "ab", RoomId: "c"})
+	b := Canonical(&chatv1.ChatMessage{Id: "a", RoomId: "bc"})
+	if bytes.Equal(a, b) {
+		t.Error("moving a byte between id and room_id leaves the canonical form unchanged")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	priv := testKey(1)
+	pub := priv.Public().(ed25519.PublicKey)
+	signed := &chatv1.ChatMessage{RoomId: "dev", SenderId: "alice", Text: "ship it", ThreadId: "t1",
+		Encrypted: &chatv1.EncryptedPayload{Ciphertext: []byte("c"), KeyId: "k", Iteration: 1}}
+	Sign(signed, priv)
+	if signed.Id == "" || signed.CreatedAt == nil {
+		t.Fatal("Sign left the id or creation time unset")
+	}
+	if signed.SignatureKeyId != KeyID(pub) {
+		t.Errorf("signature_key_id = %s, want %s", signed.SignatureKeyId, KeyID(pub))
+	}
+
+	for _, tc := range []struct {
+		name   string
+		change func(m *chatv1.ChatMessage)
+		pub    ed25519.PublicKey
+		want   bool
+	}{
+		{"as signed", func(m *chatv1.ChatMessage) {}, pub, true},
+		{"sequenced", func(m *chatv1.ChatMessage) { m.Sequence = 7 }, pub, true},
+		{"other key", func(m *chatv1.ChatMessage) {}, testKey(2).Public().(ed25519.PublicKey), false},
+		{"id", func(m *chatv1.ChatMessage) { m.Id += "x" }, pub, false},
+		{"room", func(m *chatv1.ChatMessage) { m.RoomId = "ops" }, pub, false},
+		{"sender", func(m *chatv1.ChatMessage) { m.SenderId = "mallory" }, pub, false},
+		{"text", func(m *chatv1.ChatMessage) { m.Text = "ship it!" }, pub, false},
+		{"time", func(m *chatv1.ChatMessage) { m.CreatedAt.Nanos++ }, pub, false},
+		{"thread", func(m *chatv1.ChatMessage) { m.ThreadId = "" }, pub, false},
+		{"ciphertext", func(m *chatv1.ChatMessage) { m.Encrypted.Ciphertext = []byte("d") }, pub, false},
+		{"iteration", func(m *chatv1.ChatMessage) { m.Encrypted.Iteration++ }, pub, false},
+		{"signature", func(m *chatv1.ChatMessage) { m.Signature[0] ^= 1 }, pub, false},
+		{"unsigned", func(m *chatv1.ChatMessage) { m.Signature = nil }, pub, false},
+	} {
+		m := proto.Clone(signed).(*chatv1.ChatMessage)
+		tc.change(m)
+		if got := Verify(m, tc.pub); got != tc.want {
+			t.Errorf("%s: Verify = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEndorsementAndRevocation(t *testing.T) {
+	old, next := testKey(1), testKey(2)
+	oldPub, nextPub := old.Public().(ed25519.PublicKey), next.Public().(ed25519.PublicKey)
+
+	sig := Endorse(old, "alice", nextPub)
+	for _, tc := range []struct {
+		name   string
+		pub    ed25519.PublicKey
+		userID string
+		key    ed25519.PublicKey
+		want   bool
+	}{
+		{"as endorsed", oldPub, "alice", nextPub, true},
+		{"by the new key", nextPub, "alice", nextPub, false},
+		{"for another user", oldPub, "bob", nextPub, false},
+		{"of another key", oldPub, "alice", oldPub, false},
+	} {
+		if got := VerifyEndorsement(tc.pub, tc.userID, tc.key, sig); got != tc.want {
+			t.Errorf("endorsement %s: %t, want %t", tc.name, got, tc.want)
+		}
+	}
+
+	rev := Revoke(old, "alice", KeyID(nextPub))
+	if !VerifyRevocation(oldPub, "alice", KeyID(nextPub), rev) {
+		t.Error("revocation doesn't verify")
+	}
+	if VerifyRevocation(oldPub, "alice", KeyID(oldPub), rev) {
+		t.Error("revocation verifies for another key")
+	}
+	// The labels keep one kind of signature from passing for another.
+	if VerifyRevocation(oldPub, "alice", string(nextPub), sig) {
+		t.Error("an endorsement passes for a revocation")
+	}
+}